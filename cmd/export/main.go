@@ -0,0 +1,85 @@
+// Command export writes a library to disk, as a folder of Markdown notes
+// that can be opened as an Obsidian vault or as JSON that can be imported
+// again.
+//
+//	go run ./cmd/export -user 1 -out vault
+//	go run ./cmd/export -workspace 3 -format json -out library.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"module/lynkbin/internal/db"
+	"module/lynkbin/internal/exporter"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/services/exports"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	godotenv.Load()
+	userId := flag.Int64("user", 0, "export the personal library of this user")
+	workspaceId := flag.Int64("workspace", 0, "export the library of this workspace instead")
+	format := flag.String("format", exporter.FormatMarkdown, "markdown or json")
+	out := flag.String("out", "", "directory for markdown, file for json (- for stdout)")
+	flag.Parse()
+
+	if (*userId == 0) == (*workspaceId == 0) || *out == "" {
+		fmt.Fprintln(os.Stderr, "usage: export (-user ID | -workspace ID) [-format markdown|json] -out PATH")
+		os.Exit(2)
+	}
+	if *format != exporter.FormatMarkdown && *format != exporter.FormatJSON {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(2)
+	}
+
+	database := db.ConnectDB(os.Getenv("DB_URL"))
+	exportService := exports.NewExportService(repo.NewPostRepo(database), repo.NewCollectionRepo(database))
+	library, err := exportService.Library(repo.Scope{UserId: *userId, WorkspaceId: *workspaceId})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load library: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *format == exporter.FormatJSON {
+		err = writeJSON(library, *out)
+	} else {
+		err = writeVault(library, *out)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write export: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d posts\n", len(library))
+}
+
+func writeJSON(library []exporter.Post, out string) error {
+	data, err := exporter.LibraryJSON(library)
+	if err != nil {
+		return err
+	}
+	if out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(out, data, 0o644)
+}
+
+func writeVault(library []exporter.Post, dir string) error {
+	for _, file := range exporter.MarkdownFiles(library) {
+		path := filepath.Join(dir, filepath.FromSlash(file.Name))
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(path, file.Data, 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,87 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"module/lynkbin/internal/api"
 	"module/lynkbin/internal/utilities"
 
 	"github.com/gin-contrib/cors"
-	"github.com/go-telegram/bot"
-	"github.com/go-telegram/bot/models"
 	"github.com/joho/godotenv"
-	"golang.org/x/net/proxy"
 
 	"github.com/gin-gonic/gin"
 )
 
-// createHTTPClientWithProxy creates an HTTP client with SOCKS5 or HTTP proxy support
-func createHTTPClientWithProxy(proxyURL string) *http.Client {
-	if proxyURL == "" {
-		// No proxy, return default client
-		return &http.Client{
-			Timeout: 30 * time.Second,
-		}
-	}
-
-	parsedURL, err := url.Parse(proxyURL)
-	if err != nil {
-		fmt.Printf("Error parsing proxy URL: %v, using default client\n", err)
-		return &http.Client{Timeout: 30 * time.Second}
-	}
-
-	var transport *http.Transport
-
-	if parsedURL.Scheme == "socks5" {
-		// SOCKS5 proxy
-		dialer, err := proxy.SOCKS5("tcp", parsedURL.Host, nil, proxy.Direct)
-		if err != nil {
-			fmt.Printf("Error creating SOCKS5 proxy: %v, using default client\n", err)
-			return &http.Client{Timeout: 30 * time.Second}
-		}
-
-		transport = &http.Transport{
-			Dial: dialer.Dial,
-		}
-	} else {
-		// HTTP/HTTPS proxy
-		transport = &http.Transport{
-			Proxy: http.ProxyURL(parsedURL),
-		}
-	}
-
-	return &http.Client{
-		Transport: transport,
-		Timeout:   30 * time.Second,
-	}
-}
-
 func main() {
 	godotenv.Load()
 	fmt.Println("Hello, World!")
 
-	// ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	// defer cancel()
-
-	// telegramBotFatherToken := os.Getenv("TELEGRAM_BOTFATHER_TOKEN")
-
-	// // Create custom HTTP client with proxy (optional)
-	// httpClient := createHTTPClientWithProxy("socks5://10.76.175.247:1088")
-
-	// opts := []bot.Option{
-	// 	bot.WithDefaultHandler(handler),
-	// 	bot.WithCheckInitTimeout(30 * time.Second),
-	// 	bot.WithHTTPClient(30*time.Second, httpClient), // Add custom HTTP client with proxy
-	// }
-	// b, err := bot.New(telegramBotFatherToken, opts...)
-	// if err != nil {
-	// 	panic(err)
-	// }
-	// b.Start(ctx)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
 
 	server := gin.New()
 
@@ -103,6 +45,18 @@ func main() {
 
 	api.RegisterRoutes(&server.RouterGroup, container)
 
+	if container.BotService != nil {
+		go container.BotService.Start(ctx)
+	}
+	go container.DigestService.Run(ctx)
+	go container.UserService.RunAccountPurge(ctx)
+	go container.ImportService.Run(ctx)
+	go container.SubscriptionService.Run(ctx)
+	go container.WebhookService.Run(ctx)
+	if container.EventRelay != nil {
+		go container.EventRelay.Run(ctx)
+	}
+
 	// server.GET("/", func(c *gin.Context) {
 	// 	scraperConfig := &scraper.ScraperConfig{
 	// 		Proxy: "socks5://10.101.116.69:1088",
@@ -119,14 +73,18 @@ func main() {
 	// 	c.JSON(200, gin.H{"content": content})
 	// })
 
-	server.Run(":8080")
-}
-
-func handler(ctx context.Context, b *bot.Bot, update *models.Update) {
-	fmt.Printf("message: %s\n", update.Message.Text)
-	fmt.Printf("message Id: %d\n", update.Message.Chat.ID)
-	b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   update.Message.Text,
-	})
+	httpServer := &http.Server{Addr: ":8080", Handler: server}
+	httpServer.RegisterOnShutdown(container.StreamService.Close)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	err := httpServer.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("server stopped: %v\n", err)
+		os.Exit(1)
+	}
 }
@@ -2,12 +2,33 @@ package api
 
 import (
 	"fmt"
+	"module/lynkbin/internal/auth"
 	"module/lynkbin/internal/clients/gemini"
+	"module/lynkbin/internal/clients/mailer"
 	"module/lynkbin/internal/db"
+	"module/lynkbin/internal/events"
 	"module/lynkbin/internal/middleware"
 	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/services/apikeys"
+	"module/lynkbin/internal/services/ask"
+	"module/lynkbin/internal/services/authors"
+	"module/lynkbin/internal/services/bot"
+	"module/lynkbin/internal/services/categories"
+	"module/lynkbin/internal/services/collections"
+	"module/lynkbin/internal/services/digest"
+	"module/lynkbin/internal/services/exports"
+	"module/lynkbin/internal/services/feeds"
+	"module/lynkbin/internal/services/imports"
 	"module/lynkbin/internal/services/posts"
+	"module/lynkbin/internal/services/reviews"
+	"module/lynkbin/internal/services/shares"
+	"module/lynkbin/internal/services/stream"
+	"module/lynkbin/internal/services/subscriptions"
+	"module/lynkbin/internal/services/tags"
+	"module/lynkbin/internal/services/telegram"
 	"module/lynkbin/internal/services/users"
+	"module/lynkbin/internal/services/webhooks"
+	"module/lynkbin/internal/services/workspaces"
 	"os"
 )
 
@@ -15,6 +36,29 @@ type Container struct {
 	MiddlewareService *middleware.MiddlewareService
 	UserService       *users.UserService
 	PostService       *posts.PostService
+	TagService        *tags.TagService
+	CategoryService   *categories.CategoryService
+	AuthorService     *authors.AuthorService
+	TelegramService   *telegram.TelegramService
+	DigestService     *digest.DigestService
+	AskService        *ask.AskService
+	CollectionService *collections.CollectionService
+	ShareService      *shares.ShareService
+	WorkspaceService  *workspaces.WorkspaceService
+	ApiKeyService     *apikeys.ApiKeyService
+	ImportService     *imports.ImportService
+	ExportService     *exports.ExportService
+	FeedService       *feeds.FeedService
+	// SubscriptionService polls the feeds users follow.
+	SubscriptionService *subscriptions.SubscriptionService
+	WebhookService      *webhooks.WebhookService
+	StreamService       *stream.StreamService
+	ReviewService       *reviews.ReviewService
+	// EventRelay is nil unless events are shared between instances through
+	// Postgres.
+	EventRelay *events.PostgresRelay
+	// BotService is nil when no Telegram bot token is configured.
+	BotService *bot.BotService
 }
 
 func NewContainer() *Container {
@@ -28,6 +72,12 @@ func NewContainer() *Container {
 	// 	return nil
 	// }
 
+	tokenManager, err := auth.NewTokenManagerFromEnv()
+	if err != nil {
+		fmt.Printf("failed to load JWT configuration: %v\n", err)
+		return nil
+	}
+
 	geminiClient, err := gemini.NewGeminiClient("gemini-2.5-flash")
 	if err != nil {
 		fmt.Printf("failed to create gemini client: %v\n", err)
@@ -35,14 +85,132 @@ func NewContainer() *Container {
 	}
 	postRepo := repo.NewPostRepo(database)
 	userRepo := repo.NewUserRepo(database)
+	tagRepo := repo.NewTagRepo(database)
+	categoryRepo := repo.NewCategoryRepo(database)
+	authorRepo := repo.NewAuthorRepo(database)
+	sessionRepo := repo.NewSessionRepo(database)
+	telegramRepo := repo.NewTelegramRepo(database)
+	digestRepo := repo.NewDigestRepo(database)
+	collectionRepo := repo.NewCollectionRepo(database)
+	shareRepo := repo.NewShareRepo(database)
+	workspaceRepo := repo.NewWorkspaceRepo(database)
+	apiKeyRepo := repo.NewApiKeyRepo(database)
+	identityRepo := repo.NewIdentityRepo(database)
+	accountRepo := repo.NewAccountRepo(database)
+	importRepo := repo.NewImportRepo(database)
+	feedRepo := repo.NewFeedRepo(database)
+	subscriptionRepo := repo.NewSubscriptionRepo(database)
+	webhookRepo := repo.NewWebhookRepo(database)
+	reviewRepo := repo.NewReviewRepo(database)
+
+	middlewareService := middleware.NewMiddlewareService(userRepo, sessionRepo, telegramRepo, workspaceRepo, apiKeyRepo, tokenManager, os.Getenv("TELEGRAM_BOT_SERVICE_TOKEN"))
+	oidcProviders, err := auth.OIDCProvidersFromEnv()
+	if err != nil {
+		fmt.Printf("failed to load OIDC configuration: %v\n", err)
+		return nil
+	}
+
+	appMailer, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		fmt.Printf("failed to load mailer configuration: %v\n", err)
+		return nil
+	}
+	accountConfig, err := users.AccountConfigFromEnv()
+	if err != nil {
+		fmt.Printf("failed to load account configuration: %v\n", err)
+		return nil
+	}
+	if accountConfig.RequireEmailVerification && appMailer == nil {
+		fmt.Println("REQUIRE_EMAIL_VERIFICATION needs a mailer, set MAILER or SMTP_HOST")
+		return nil
+	}
 
-	middlewareService := middleware.NewMiddlewareService(userRepo)
-	userService := users.NewUserService(userRepo)
-	postService := posts.NewPostService(postRepo, geminiClient)
+	eventBus := events.NewBus()
+	webhookService := webhooks.NewWebhookService(webhookRepo)
+	eventBus.Subscribe(webhookService.HandleEvent)
+
+	eventsBackend, err := stream.BackendFromEnv()
+	if err != nil {
+		fmt.Printf("failed to load events configuration: %v\n", err)
+		return nil
+	}
+	broker := events.NewBroker()
+	var eventRelay *events.PostgresRelay
+	var relay events.Relay = broker
+	if eventsBackend == stream.BackendPostgres {
+		eventRelay = events.NewPostgresRelay(database, dbUrl, broker)
+		relay = eventRelay
+	}
+	streamService := stream.NewStreamService(broker, relay, middlewareService.StillAuthorized)
+	eventBus.Subscribe(streamService.HandleEvent)
+
+	userService := users.NewUserService(userRepo, sessionRepo, identityRepo, accountRepo, tokenManager, oidcProviders, appMailer, accountConfig)
+	postService := posts.NewPostService(postRepo, tagRepo, categoryRepo, authorRepo, geminiClient, eventBus)
+	tagService := tags.NewTagService(tagRepo, eventBus)
+	categoryService := categories.NewCategoryService(categoryRepo)
+	authorService := authors.NewAuthorService(authorRepo, eventBus)
+	telegramService := telegram.NewTelegramService(telegramRepo)
+	reviewService := reviews.NewReviewService(reviewRepo, postRepo)
+
+	var botService *bot.BotService
+	if botConfig := bot.BotConfigFromEnv(); botConfig.Token != "" {
+		botService, err = bot.NewBotService(botConfig, postService, reviewService, postRepo, tagRepo, telegramRepo)
+		if err != nil {
+			// The API stays useful without the bot, so don't fail startup.
+			fmt.Printf("failed to create telegram bot: %v\n", err)
+		}
+	}
+
+	var digestTransports []digest.Transport
+	if appMailer != nil {
+		digestTransports = append(digestTransports, digest.NewEmailTransport(appMailer))
+	}
+	if botService != nil {
+		digestTransports = append(digestTransports, digest.NewTelegramTransport(botService, telegramRepo))
+	}
+	digestService := digest.NewDigestService(digestRepo, userRepo, reviewService, geminiClient, digestTransports...)
+	askService := ask.NewAskService(postRepo, geminiClient)
+	collectionService := collections.NewCollectionService(collectionRepo)
+	shareService := shares.NewShareService(shareRepo, postRepo, collectionRepo)
+	workspaceService := workspaces.NewWorkspaceService(workspaceRepo, userRepo)
+	apiKeyService := apikeys.NewApiKeyService(apiKeyRepo, workspaceRepo)
+	importRate, err := imports.ImportRateFromEnv()
+	if err != nil {
+		fmt.Printf("failed to load import configuration: %v\n", err)
+		return nil
+	}
+	importService := imports.NewImportService(importRepo, postRepo, collectionRepo, postService, importRate)
+	exportService := exports.NewExportService(postRepo, collectionRepo)
+	feedService := feeds.NewFeedService(feedRepo, postRepo, collectionRepo, os.Getenv("API_URL"))
+	pollInterval, err := subscriptions.PollIntervalFromEnv()
+	if err != nil {
+		fmt.Printf("failed to load subscription configuration: %v\n", err)
+		return nil
+	}
+	subscriptionService := subscriptions.NewSubscriptionService(subscriptionRepo, postRepo, postService, pollInterval)
 
 	return &Container{
-		MiddlewareService: middlewareService,
-		UserService:       userService,
-		PostService:       postService,
+		MiddlewareService:   middlewareService,
+		UserService:         userService,
+		PostService:         postService,
+		TagService:          tagService,
+		CategoryService:     categoryService,
+		AuthorService:       authorService,
+		TelegramService:     telegramService,
+		DigestService:       digestService,
+		AskService:          askService,
+		CollectionService:   collectionService,
+		ShareService:        shareService,
+		WorkspaceService:    workspaceService,
+		ApiKeyService:       apiKeyService,
+		ImportService:       importService,
+		ExportService:       exportService,
+		FeedService:         feedService,
+		SubscriptionService: subscriptionService,
+		WebhookService:      webhookService,
+		StreamService:       streamService,
+		ReviewService:       reviewService,
+		EventRelay:          eventRelay,
+		BotService:          botService,
 	}
 }
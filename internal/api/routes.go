@@ -10,17 +10,143 @@ func RegisterRoutes(router *gin.RouterGroup, container *Container) {
 	userRoutes := router.Group("/users")
 
 	userRoutes.GET("/me", middlewareService.AuthMiddleware, container.UserService.GetCurrentUser)
+	userRoutes.DELETE("/me", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.UserService.DeleteAccount)
+	userRoutes.GET("/me/export", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.UserService.ExportAccount)
 	userRoutes.POST("/register", container.UserService.RegisterUser)
 	userRoutes.POST("/login", container.UserService.LoginUser)
+	userRoutes.POST("/refresh", container.UserService.RefreshToken)
+	userRoutes.POST("/verify", container.UserService.VerifyEmail)
+	userRoutes.POST("/verify/resend", container.UserService.ResendVerification)
+	userRoutes.POST("/forgot-password", container.UserService.ForgotPassword)
+	userRoutes.POST("/reset-password", container.UserService.ResetPassword)
+	userRoutes.POST("/logout", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.UserService.Logout)
+	userRoutes.POST("/logout-all", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.UserService.LogoutAllSessions)
+	userRoutes.GET("/sessions", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.UserService.GetSessions)
+	userRoutes.GET("/identities", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.UserService.GetIdentities)
+	userRoutes.GET("/oidc/providers", container.UserService.GetOIDCProviders)
+	userRoutes.GET("/oidc/:provider/start", container.UserService.StartOIDCLogin)
+	userRoutes.POST("/oidc/:provider/callback", container.UserService.FinishOIDCLogin)
+	userRoutes.GET("/api-keys", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.ApiKeyService.GetApiKeys)
+	userRoutes.POST("/api-keys", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.ApiKeyService.CreateApiKey)
+	userRoutes.DELETE("/api-keys/:id", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.ApiKeyService.RevokeApiKey)
+
+	router.GET("/events", middlewareService.AuthMiddleware, container.StreamService.StreamEvents)
 
 	postRoutes := router.Group("/posts")
-	postRoutes.POST("", middlewareService.AuthMiddleware, container.PostService.CreatePost)
+	postRoutes.POST("", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.PostService.CreatePost)
 	postRoutes.GET("", middlewareService.AuthMiddleware, container.PostService.GetPosts)
-	postRoutes.DELETE("/:id", middlewareService.AuthMiddleware, container.PostService.DeletePost)
+	postRoutes.GET("/reading-list", middlewareService.AuthMiddleware, container.PostService.GetReadingList)
+	postRoutes.DELETE("/:id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.PostService.DeletePost)
+	// Reading state is each user's own, so viewers and read-only keys may
+	// keep theirs.
+	postRoutes.PATCH("/:id", middlewareService.AuthMiddleware, container.PostService.UpdatePost)
+	postRoutes.POST("/:id/open", middlewareService.AuthMiddleware, container.PostService.MarkPostOpened)
 	postRoutes.GET("/authors", middlewareService.AuthMiddleware, container.PostService.GetUserAuthors)
 	postRoutes.GET("/categories", middlewareService.AuthMiddleware, container.PostService.GetUserCategories)
 	postRoutes.GET("/tags", middlewareService.AuthMiddleware, container.PostService.GetUserTags)
 	postRoutes.GET("/recent", middlewareService.AuthMiddleware, container.PostService.GetRecentPosts)
+	postRoutes.GET("/review", middlewareService.AuthMiddleware, container.ReviewService.GetReviewPosts)
+	// Like reading state, the review schedule is the user's own.
+	postRoutes.POST("/:id/review", middlewareService.AuthMiddleware, container.ReviewService.ReviewPost)
+	postRoutes.GET("/export", middlewareService.AuthMiddleware, container.ExportService.ExportLibrary)
 
 	postRoutes.GET("/counts", middlewareService.AuthMiddleware, container.PostService.GetAllUserPostsTagsAndCategoriesCount)
+
+	tagRoutes := router.Group("/tags")
+	tagRoutes.GET("", middlewareService.AuthMiddleware, container.TagService.GetTags)
+	tagRoutes.POST("/merge", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.TagService.MergeTags)
+	tagRoutes.POST("/rename", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.TagService.RenameTag)
+	tagRoutes.GET("/synonyms", middlewareService.AuthMiddleware, container.TagService.GetTagSynonyms)
+	tagRoutes.POST("/synonyms", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.TagService.AddTagSynonym)
+
+	categoryRoutes := router.Group("/categories")
+	categoryRoutes.GET("", middlewareService.AuthMiddleware, container.CategoryService.GetCategories)
+	categoryRoutes.GET("/tree", middlewareService.AuthMiddleware, container.CategoryService.GetCategoryTree)
+	categoryRoutes.POST("", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.CategoryService.CreateCategory)
+	categoryRoutes.PUT("/:id/pin", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.CategoryService.PinCategory)
+	categoryRoutes.DELETE("/:id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.CategoryService.DeleteCategory)
+
+	authorRoutes := router.Group("/authors")
+	authorRoutes.GET("", middlewareService.AuthMiddleware, container.AuthorService.GetAuthors)
+	authorRoutes.GET("/profiles", middlewareService.AuthMiddleware, container.AuthorService.GetAuthorProfiles)
+	authorRoutes.POST("/merge", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.AuthorService.MergeAuthors)
+	authorRoutes.GET("/:id", middlewareService.AuthMiddleware, container.AuthorService.GetAuthor)
+
+	collectionRoutes := router.Group("/collections")
+	collectionRoutes.GET("", middlewareService.AuthMiddleware, container.CollectionService.GetCollections)
+	collectionRoutes.POST("", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.CollectionService.CreateCollection)
+	collectionRoutes.GET("/:id", middlewareService.AuthMiddleware, container.CollectionService.GetCollection)
+	collectionRoutes.PUT("/:id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.CollectionService.UpdateCollection)
+	collectionRoutes.DELETE("/:id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.CollectionService.DeleteCollection)
+	collectionRoutes.POST("/:id/posts", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.CollectionService.AddPost)
+	collectionRoutes.PUT("/:id/posts/:post_id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.CollectionService.UpdatePost)
+	collectionRoutes.DELETE("/:id/posts/:post_id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.CollectionService.RemovePost)
+	collectionRoutes.PUT("/:id/order", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.CollectionService.ReorderPosts)
+
+	shareRoutes := router.Group("/shares")
+	shareRoutes.GET("", middlewareService.AuthMiddleware, container.ShareService.GetShareLinks)
+	shareRoutes.POST("", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.ShareService.CreateShareLink)
+	shareRoutes.DELETE("/:id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.ShareService.RevokeShareLink)
+
+	feedRoutes := router.Group("/feeds")
+	feedRoutes.GET("", middlewareService.AuthMiddleware, container.FeedService.GetFeeds)
+	feedRoutes.POST("", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.FeedService.CreateFeed)
+	feedRoutes.DELETE("/:id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.FeedService.RevokeFeed)
+
+	subscriptionRoutes := router.Group("/subscriptions")
+	subscriptionRoutes.GET("", middlewareService.AuthMiddleware, container.SubscriptionService.GetSubscriptions)
+	subscriptionRoutes.POST("", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.SubscriptionService.CreateSubscription)
+	subscriptionRoutes.PUT("/:id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.SubscriptionService.UpdateSubscription)
+	subscriptionRoutes.DELETE("/:id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.SubscriptionService.DeleteSubscription)
+	subscriptionRoutes.GET("/:id/entries", middlewareService.AuthMiddleware, container.SubscriptionService.GetSubscriptionEntries)
+
+	webhookRoutes := router.Group("/webhooks")
+	webhookRoutes.GET("", middlewareService.AuthMiddleware, container.WebhookService.GetWebhooks)
+	webhookRoutes.POST("", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.WebhookService.CreateWebhook)
+	webhookRoutes.PUT("/:id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.WebhookService.UpdateWebhook)
+	webhookRoutes.DELETE("/:id", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.WebhookService.DeleteWebhook)
+	webhookRoutes.GET("/:id/deliveries", middlewareService.AuthMiddleware, container.WebhookService.GetDeliveries)
+	webhookRoutes.POST("/:id/deliveries/:delivery_id/replay", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.WebhookService.ReplayDelivery)
+
+	importRoutes := router.Group("/imports")
+	importRoutes.GET("", middlewareService.AuthMiddleware, container.ImportService.GetImports)
+	importRoutes.POST("", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.ImportService.CreateImport)
+	importRoutes.GET("/:id", middlewareService.AuthMiddleware, container.ImportService.GetImport)
+	importRoutes.GET("/:id/items", middlewareService.AuthMiddleware, container.ImportService.GetImportItems)
+	importRoutes.POST("/:id/cancel", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.ImportService.CancelImport)
+
+	workspaceRoutes := router.Group("/workspaces")
+	workspaceRoutes.GET("", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.GetWorkspaces)
+	workspaceRoutes.POST("", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.CreateWorkspace)
+	workspaceRoutes.POST("/invites/accept", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.AcceptInvite)
+	workspaceRoutes.GET("/:id", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.GetWorkspace)
+	workspaceRoutes.PUT("/:id", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.UpdateWorkspace)
+	workspaceRoutes.DELETE("/:id", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.DeleteWorkspace)
+	workspaceRoutes.PUT("/:id/members/:user_id", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.UpdateMember)
+	workspaceRoutes.DELETE("/:id/members/:user_id", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.RemoveMember)
+	workspaceRoutes.GET("/:id/invites", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.GetInvites)
+	workspaceRoutes.POST("/:id/invites", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.CreateInvite)
+	workspaceRoutes.DELETE("/:id/invites/:invite_id", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.WorkspaceService.RevokeInvite)
+
+	// Public routes are unauthenticated and read-only.
+	publicRoutes := router.Group("/public")
+	publicRoutes.GET("/:slug", container.ShareService.GetPublicShare)
+	publicRoutes.GET("/feeds/:token/:format", container.FeedService.GetPublicFeed)
+
+	telegramRoutes := router.Group("/telegram")
+	telegramRoutes.POST("/link-code", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.TelegramService.CreateLinkCode)
+	telegramRoutes.GET("/links", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.TelegramService.GetLinks)
+	telegramRoutes.DELETE("/links/:chat_id", middlewareService.AuthMiddleware, middlewareService.RequireSession, container.TelegramService.DeleteLink)
+	telegramRoutes.POST("/link", middlewareService.BotServiceMiddleware, container.TelegramService.LinkChat)
+	if container.BotService != nil && container.BotService.IsWebhookMode() {
+		telegramRoutes.POST("/webhook", gin.WrapF(container.BotService.WebhookHandler()))
+	}
+
+	digestRoutes := router.Group("/digest")
+	digestRoutes.GET("/settings", middlewareService.AuthMiddleware, container.DigestService.GetDigestSettings)
+	digestRoutes.PUT("/settings", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.DigestService.UpdateDigestSettings)
+	digestRoutes.GET("/preview", middlewareService.AuthMiddleware, container.DigestService.PreviewDigest)
+	digestRoutes.POST("/send", middlewareService.AuthMiddleware, middlewareService.RequireWriteAccess, container.DigestService.SendDigestNow)
+
+	router.POST("/ask", middlewareService.AuthMiddleware, container.AskService.Ask)
 }
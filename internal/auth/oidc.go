@@ -0,0 +1,470 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCProviderConfig describes an identity provider. Providers that support
+// discovery only need an Issuer; plain OAuth2 providers such as GitHub set
+// the endpoints directly and are identified through their userinfo endpoint.
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	AuthURL      string
+	TokenURL     string
+	UserinfoURL  string
+	// EmailsURL lists the account's emails when userinfo doesn't say whether
+	// the email is verified (GitHub).
+	EmailsURL string
+}
+
+// oidcPresets fill in what is known about common providers so only the
+// client credentials have to be configured.
+var oidcPresets = map[string]OIDCProviderConfig{
+	"google": {
+		Issuer: "https://accounts.google.com",
+		Scopes: []string{"openid", "email", "profile"},
+	},
+	"github": {
+		AuthURL:     "https://github.com/login/oauth/authorize",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		UserinfoURL: "https://api.github.com/user",
+		EmailsURL:   "https://api.github.com/user/emails",
+		Scopes:      []string{"read:user", "user:email"},
+	},
+}
+
+// OIDCIdentity is who the provider says signed in.
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type OIDCProvider struct {
+	config     OIDCProviderConfig
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	jwksURL  string
+	keys     map[string]any
+	resolved bool
+}
+
+// OIDCProvidersFromEnv reads OIDC_PROVIDERS, a comma separated list of
+// provider names, and OIDC_<NAME>_* for each of them: CLIENT_ID,
+// CLIENT_SECRET and REDIRECT_URL are required; ISSUER, SCOPES, AUTH_URL,
+// TOKEN_URL, USERINFO_URL and EMAILS_URL override the preset.
+func OIDCProvidersFromEnv() (map[string]*OIDCProvider, error) {
+	providers := map[string]*OIDCProvider{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		config := oidcPresets[name]
+		config.Name = name
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config.ClientId = os.Getenv(prefix + "CLIENT_ID")
+		config.ClientSecret = os.Getenv(prefix + "CLIENT_SECRET")
+		config.RedirectURL = os.Getenv(prefix + "REDIRECT_URL")
+		if value := os.Getenv(prefix + "ISSUER"); value != "" {
+			config.Issuer = strings.TrimSuffix(value, "/")
+		}
+		if value := os.Getenv(prefix + "SCOPES"); value != "" {
+			config.Scopes = strings.Fields(strings.ReplaceAll(value, ",", " "))
+		}
+		if value := os.Getenv(prefix + "AUTH_URL"); value != "" {
+			config.AuthURL = value
+		}
+		if value := os.Getenv(prefix + "TOKEN_URL"); value != "" {
+			config.TokenURL = value
+		}
+		if value := os.Getenv(prefix + "USERINFO_URL"); value != "" {
+			config.UserinfoURL = value
+		}
+		if value := os.Getenv(prefix + "EMAILS_URL"); value != "" {
+			config.EmailsURL = value
+		}
+		if config.ClientId == "" || config.RedirectURL == "" {
+			return nil, fmt.Errorf("%sCLIENT_ID and %sREDIRECT_URL are required", prefix, prefix)
+		}
+		if config.Issuer == "" && (config.AuthURL == "" || config.TokenURL == "" || config.UserinfoURL == "") {
+			return nil, fmt.Errorf("%sISSUER or %sAUTH_URL, %sTOKEN_URL and %sUSERINFO_URL are required", prefix, prefix, prefix, prefix)
+		}
+		if len(config.Scopes) == 0 {
+			config.Scopes = []string{"openid", "email", "profile"}
+		}
+		providers[name] = NewOIDCProvider(config)
+	}
+	return providers, nil
+}
+
+func NewOIDCProvider(config OIDCProviderConfig) *OIDCProvider {
+	return &OIDCProvider{config: config, httpClient: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// NewPKCEVerifier returns a code verifier and its S256 challenge.
+func NewPKCEVerifier() (string, string, error) {
+	verifier, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// discover loads the provider's endpoints from its discovery document once.
+// Endpoints set in the config win over discovered ones.
+func (p *OIDCProvider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resolved || p.config.Issuer == "" {
+		return nil
+	}
+	var document struct {
+		Issuer      string `json:"issuer"`
+		AuthURL     string `json:"authorization_endpoint"`
+		TokenURL    string `json:"token_endpoint"`
+		UserinfoURL string `json:"userinfo_endpoint"`
+		JWKSURL     string `json:"jwks_uri"`
+	}
+	err := p.getJSON(ctx, p.config.Issuer+"/.well-known/openid-configuration", "", &document)
+	if err != nil {
+		return fmt.Errorf("discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(document.Issuer, "/") != p.config.Issuer {
+		return fmt.Errorf("discovery returned issuer %q, expected %q", document.Issuer, p.config.Issuer)
+	}
+	if p.config.AuthURL == "" {
+		p.config.AuthURL = document.AuthURL
+	}
+	if p.config.TokenURL == "" {
+		p.config.TokenURL = document.TokenURL
+	}
+	if p.config.UserinfoURL == "" {
+		p.config.UserinfoURL = document.UserinfoURL
+	}
+	p.jwksURL = document.JWKSURL
+	p.resolved = true
+	return nil
+}
+
+// AuthorizationURL is where the user is sent to sign in.
+func (p *OIDCProvider) AuthorizationURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	authURL, err := url.Parse(p.config.AuthURL)
+	if err != nil {
+		return "", err
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientId)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	if p.config.Issuer != "" {
+		query.Set("nonce", nonce)
+	}
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// Exchange redeems the authorization code and returns the signed-in
+// identity, taken from the ID token when the provider issues one and from
+// the userinfo endpoint otherwise.
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (OIDCIdentity, error) {
+	err := p.discover(ctx)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientId},
+		"code_verifier": {codeVerifier},
+	}
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	var tokens struct {
+		AccessToken string `json:"access_token"`
+		IdToken     string `json:"id_token"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	err = p.doJSON(request, &tokens)
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("token exchange failed: %w", err)
+	}
+	if tokens.Error != "" {
+		return OIDCIdentity{}, fmt.Errorf("token exchange failed: %s %s", tokens.Error, tokens.Description)
+	}
+
+	if tokens.IdToken != "" && p.config.Issuer != "" {
+		return p.verifyIdToken(ctx, tokens.IdToken, nonce)
+	}
+	if tokens.AccessToken == "" {
+		return OIDCIdentity{}, errors.New("token response has neither an ID token nor an access token")
+	}
+	return p.userinfo(ctx, tokens.AccessToken)
+}
+
+func (p *OIDCProvider) verifyIdToken(ctx context.Context, idToken string, nonce string) (OIDCIdentity, error) {
+	var claims struct {
+		jwt.RegisteredClaims
+		Nonce         string `json:"nonce"`
+		Email         string `json:"email"`
+		EmailVerified any    `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return OIDCIdentity{}, errors.New("invalid ID token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return OIDCIdentity{}, errors.New("invalid ID token: missing subject")
+	}
+	return OIDCIdentity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: isTrue(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// signingKey returns the provider key with the given id, reloading the key
+// set once when the id is unknown in case the provider rotated its keys.
+func (p *OIDCProvider) signingKey(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	key, ok = keys[kid]
+	if !ok {
+		// Providers with a single key don't always set kid.
+		if kid == "" && len(keys) == 1 {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func (p *OIDCProvider) fetchKeys(ctx context.Context) (map[string]any, error) {
+	if p.jwksURL == "" {
+		return nil, errors.New("provider has no jwks_uri")
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	err := p.getJSON(ctx, p.jwksURL, "", &set)
+	if err != nil {
+		return nil, fmt.Errorf("loading signing keys failed: %w", err)
+	}
+
+	keys := map[string]any{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		switch jwk.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch jwk.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+			y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[jwk.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	return keys, nil
+}
+
+func (p *OIDCProvider) userinfo(ctx context.Context, accessToken string) (OIDCIdentity, error) {
+	if p.config.UserinfoURL == "" {
+		return OIDCIdentity{}, errors.New("provider has no userinfo endpoint")
+	}
+	var info map[string]any
+	err := p.getJSON(ctx, p.config.UserinfoURL, accessToken, &info)
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("userinfo failed: %w", err)
+	}
+
+	identity := OIDCIdentity{
+		Subject:       claimString(info, "sub", "id"),
+		Email:         claimString(info, "email"),
+		EmailVerified: isTrue(info["email_verified"]),
+		Name:          claimString(info, "name", "login"),
+	}
+	if identity.Subject == "" {
+		return OIDCIdentity{}, errors.New("userinfo has no subject")
+	}
+
+	if p.config.EmailsURL != "" {
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		err = p.getJSON(ctx, p.config.EmailsURL, accessToken, &emails)
+		if err != nil {
+			return OIDCIdentity{}, fmt.Errorf("loading emails failed: %w", err)
+		}
+		for _, email := range emails {
+			if email.Primary {
+				identity.Email = email.Email
+				identity.EmailVerified = email.Verified
+			}
+		}
+	}
+	return identity, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, accessToken string, target any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return p.doJSON(request, target)
+}
+
+func (p *OIDCProvider) doJSON(request *http.Request, target any) error {
+	response, err := p.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	// Token endpoints report errors as JSON with a 400, so let the caller
+	// see the body.
+	if response.StatusCode >= 300 && response.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("%s returned %s", request.URL.Host, response.Status)
+	}
+	return json.Unmarshal(body, target)
+}
+
+// claimString returns the first of the keys that is set, formatting numeric
+// ids (GitHub) as strings.
+func claimString(claims map[string]any, keys ...string) string {
+	for _, key := range keys {
+		switch value := claims[key].(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case float64:
+			return strconv.FormatInt(int64(value), 10)
+		}
+	}
+	return ""
+}
+
+// isTrue reads email_verified, which some providers send as a string.
+func isTrue(value any) bool {
+	switch value := value.(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/utilities"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// TokenManager signs and verifies access tokens. Several keys can be loaded at
+// once so a key can be rotated: tokens are signed with the active key and the
+// kid header tells verification which key to use.
+type TokenManager struct {
+	keys            map[string][]byte
+	activeKeyId     string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// NewTokenManagerFromEnv reads the signing keys from JWT_SIGNING_KEYS as a
+// comma separated list of kid:secret pairs (or a single JWT_SECRET), the
+// signing key from JWT_ACTIVE_KEY_ID and the token lifetimes from
+// JWT_ACCESS_TOKEN_TTL and JWT_REFRESH_TOKEN_TTL.
+func NewTokenManagerFromEnv() (*TokenManager, error) {
+	keys := map[string][]byte{}
+	activeKeyId := ""
+	for _, pair := range strings.Split(os.Getenv("JWT_SIGNING_KEYS"), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kid, secret, found := strings.Cut(pair, ":")
+		if !found || kid == "" || secret == "" {
+			return nil, fmt.Errorf("invalid JWT_SIGNING_KEYS entry %q, expected kid:secret", pair)
+		}
+		keys[kid] = []byte(secret)
+		if activeKeyId == "" {
+			activeKeyId = kid
+		}
+	}
+	if len(keys) == 0 {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("no JWT signing keys configured, set JWT_SIGNING_KEYS or JWT_SECRET")
+		}
+		keys["default"] = []byte(secret)
+		activeKeyId = "default"
+	}
+	if kid := os.Getenv("JWT_ACTIVE_KEY_ID"); kid != "" {
+		if _, ok := keys[kid]; !ok {
+			return nil, fmt.Errorf("JWT_ACTIVE_KEY_ID %q is not one of the configured keys", kid)
+		}
+		activeKeyId = kid
+	}
+
+	accessTokenTTL, err := durationFromEnv("JWT_ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	refreshTokenTTL, err := durationFromEnv("JWT_REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenManager{
+		keys:            keys,
+		activeKeyId:     activeKeyId,
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+	}, nil
+}
+
+func durationFromEnv(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return duration, nil
+}
+
+// Sign signs arbitrary claims with the active key.
+func (m *TokenManager) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = m.activeKeyId
+	return token.SignedString(m.keys[m.activeKeyId])
+}
+
+// Parse verifies a token signed by any of the configured keys into claims.
+func (m *TokenManager) Parse(tokenString string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := m.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	return err
+}
+
+func (m *TokenManager) IssueAccessToken(userId int64, sessionId string) (string, error) {
+	now := time.Now()
+	return m.Sign(utilities.CustomClaims{
+		UserId:    userId,
+		SessionId: sessionId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.AccessTokenTTL)),
+		},
+	})
+}
+
+func (m *TokenManager) ParseAccessToken(tokenString string) (*utilities.CustomClaims, error) {
+	claims := &utilities.CustomClaims{}
+	err := m.Parse(tokenString, claims)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserId == 0 || claims.SessionId == "" {
+		return nil, errors.New("token is not an access token")
+	}
+	return claims, nil
+}
+
+// NewOpaqueToken returns a random URL-safe token, used for refresh tokens and
+// other secrets that are only ever stored hashed.
+func NewOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewSessionId() (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ApiKeyPrefix marks API keys so they can be told apart from access tokens
+// in an Authorization header, and spotted by secret scanners.
+const ApiKeyPrefix = "lb_"
+
+// NewApiKey returns a new API key and the short prefix of it that is kept in
+// the clear for display.
+func NewApiKey() (string, string, error) {
+	token, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	key := ApiKeyPrefix + token
+	return key, key[:len(ApiKeyPrefix)+6], nil
+}
+
+const linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// NewLinkCode returns a short code that is easy to type into a chat. It
+// avoids characters that are easily confused such as O/0 and I/1.
+func NewLinkCode(length int) (string, error) {
+	buf := make([]byte, length)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	for i := range buf {
+		buf[i] = linkCodeAlphabet[int(buf[i])%len(linkCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// ActionClaims are carried by the tokens emailed to users to verify their
+// address or reset their password. The token ID is recorded so each token
+// can be used only once.
+type ActionClaims struct {
+	Purpose string `json:"purpose"`
+	UserId  int64  `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+func (m *TokenManager) IssueActionToken(purpose string, userId int64, tokenId string, expiresAt time.Time) (string, error) {
+	return m.Sign(ActionClaims{
+		Purpose: purpose,
+		UserId:  userId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenId,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+}
+
+// ParseActionToken verifies a token issued for purpose. Access tokens and
+// tokens issued for another purpose are rejected.
+func (m *TokenManager) ParseActionToken(tokenString string, purpose string) (*ActionClaims, error) {
+	claims := &ActionClaims{}
+	err := m.Parse(tokenString, claims)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose || claims.UserId == 0 || claims.ID == "" {
+		return nil, errors.New("token was not issued for this purpose")
+	}
+	return claims, nil
+}
@@ -19,8 +19,13 @@ type GeminiClient struct {
 
 func NewGeminiClient(model string) (*GeminiClient, error) {
 	ctx := context.Background()
+	// GEMINI_BASE_URL points the client at another server speaking the
+	// Gemini API, e.g. a local stand-in during development.
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
 		APIKey: os.Getenv("GEMINI_API_KEY"),
+		HTTPOptions: genai.HTTPOptions{
+			BaseURL: os.Getenv("GEMINI_BASE_URL"),
+		},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gemini client: %w", err)
@@ -39,6 +44,28 @@ func (c *GeminiClient) GenerateContent(ctx context.Context, prompt string) (stri
 	return result.Text(), nil
 }
 
+// GenerateContentStream calls onChunk with each piece of the response as it
+// is generated and returns the full text once done.
+func (c *GeminiClient) GenerateContentStream(ctx context.Context, prompt string, onChunk func(string) error) (string, error) {
+	var full strings.Builder
+	for result, err := range c.client.Models.GenerateContentStream(ctx, c.model, genai.Text(prompt), nil) {
+		if err != nil {
+			fmt.Printf("failed to stream content: %v\n", err)
+			return full.String(), err
+		}
+		chunk := result.Text()
+		if chunk == "" {
+			continue
+		}
+		full.WriteString(chunk)
+		err = onChunk(chunk)
+		if err != nil {
+			return full.String(), err
+		}
+	}
+	return full.String(), nil
+}
+
 func (c *GeminiClient) GenerateContentWithMedia(ctx context.Context, prompt string, Media []dto.Media) (string, error) {
 	parts := []*genai.Part{}
 
@@ -0,0 +1,91 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogMailer prints messages to stdout instead of sending them, for local
+// development.
+type LogMailer struct{}
+
+func (m *LogMailer) Send(ctx context.Context, message Message) error {
+	if len(message.To) == 0 {
+		return errors.New("message has no recipients")
+	}
+	fmt.Printf("Email to %s: %s\n%s\n", strings.Join(message.To, ", "), message.Subject, message.Text)
+	return nil
+}
+
+// FileMailer writes each message as an .eml file into Dir, so it can be
+// opened with a mail client or read by tests.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	if len(message.To) == 0 {
+		return errors.New("message has no recipients")
+	}
+	body, err := buildMessage(m.From, message)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(m.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(m.Dir, time.Now().UTC().Format("20060102T150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(body)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// NewMailerFromEnv picks the mailer named by MAILER: smtp (see
+// NewSMTPMailerFromEnv), log, or file, which writes into MAILER_DIR (default
+// "mail"). Without MAILER, SMTP is used when SMTP_HOST is set. It returns nil
+// when email delivery is left unconfigured.
+func NewMailerFromEnv() (Mailer, error) {
+	kind := strings.ToLower(os.Getenv("MAILER"))
+	if kind == "" && os.Getenv("SMTP_HOST") != "" {
+		kind = "smtp"
+	}
+	switch kind {
+	case "":
+		return nil, nil
+	case "smtp":
+		smtpMailer, err := NewSMTPMailerFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if smtpMailer == nil {
+			return nil, errors.New("SMTP_HOST is required when MAILER is smtp")
+		}
+		return smtpMailer, nil
+	case "log":
+		return &LogMailer{}, nil
+	case "file":
+		dir := os.Getenv("MAILER_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		from := os.Getenv("SMTP_FROM")
+		if from == "" {
+			from = "lynkbin@localhost"
+		}
+		return &FileMailer{Dir: filepath.Clean(dir), From: from}, nil
+	}
+	return nil, fmt.Errorf("unknown MAILER %q, expected smtp, log or file", kind)
+}
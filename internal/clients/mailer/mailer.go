@@ -0,0 +1,136 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	// HTML is optional; when set the message carries both parts.
+	HTML string
+}
+
+// Mailer delivers email. SMTPMailer is used in production; tests can point it
+// at a local SMTP sink or swap in their own implementation.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPMailerFromEnv reads SMTP_HOST, SMTP_PORT (default 587),
+// SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM. It returns nil when SMTP_HOST is
+// not set so email delivery can be left unconfigured.
+func NewSMTPMailerFromEnv() (*SMTPMailer, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		return nil, errors.New("SMTP_FROM is required when SMTP_HOST is set")
+	}
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	if len(message.To) == 0 {
+		return errors.New("message has no recipients")
+	}
+	body, err := buildMessage(m.From, message)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	// net/smtp has no context support, so give up waiting when ctx is done
+	// and let the send finish in the background.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.Host+":"+m.Port, auth, m.From, message.To, body)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func buildMessage(from string, message Message) ([]byte, error) {
+	var buf bytes.Buffer
+	headers := []string{
+		"From: " + from,
+		"To: " + strings.Join(message.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+	}
+
+	if message.HTML == "" {
+		headers = append(headers, "Content-Type: text/plain; charset=utf-8")
+		buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+		buf.WriteString(message.Text)
+		return buf.Bytes(), nil
+	}
+
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return nil, err
+		}
+		_, err = partWriter.Write([]byte(part.body))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	headers = append(headers, fmt.Sprintf("Content-Type: multipart/alternative; boundary=%s", writer.Boundary()))
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+	buf.Write(parts.Bytes())
+	return buf.Bytes(), nil
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"fmt"
 	"module/lynkbin/internal/models"
+	"strings"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -25,17 +26,83 @@ func MigrateDB(db *gorm.DB) error {
 	err := db.AutoMigrate(
 		&models.Post{},
 		&models.User{},
+		&models.RefreshToken{},
+		&models.TelegramLink{},
+		&models.TelegramLinkCode{},
 		&models.UserAuthor{},
+		&models.Author{},
 		&models.UserTags{},
 		&models.AllTags{},
+		&models.TagSynonym{},
 		&models.UserCategories{},
 		&models.AllCategories{},
+		&models.CategoryNode{},
+		&models.DigestSchedule{},
+		&models.Collection{},
+		&models.CollectionItem{},
+		&models.ShareLink{},
+		&models.Workspace{},
+		&models.WorkspaceMember{},
+		&models.WorkspaceInvite{},
+		&models.ApiKey{},
+		&models.UserIdentity{},
+		&models.OIDCLoginState{},
+		&models.UserToken{},
+		&models.ImportJob{},
+		&models.ImportItem{},
+		&models.Feed{},
+		&models.Subscription{},
+		&models.SubscriptionEntry{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.PostReview{},
+		&models.PostReviewFeedback{},
+		&models.PostState{},
 	)
 
 	if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
+	err = migrateWorkspaceKeys(db)
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
 	fmt.Println("Database migrations completed successfully")
 	return nil
 }
+
+// workspacePrimaryKeys are the tables whose primary key gained workspace_id
+// with workspaces. AutoMigrate adds the column but never touches an existing
+// primary key, so older databases are fixed up here.
+var workspacePrimaryKeys = []struct {
+	table   string
+	columns []string
+}{
+	{"user_tags", []string{"user_id", "workspace_id", "platform"}},
+	{"user_categories", []string{"user_id", "workspace_id", "platform"}},
+	{"user_authors", []string{"user_id", "workspace_id", "platform"}},
+	{"tag_synonyms", []string{"user_id", "workspace_id", "alias"}},
+}
+
+func migrateWorkspaceKeys(db *gorm.DB) error {
+	for _, key := range workspacePrimaryKeys {
+		var count int64
+		err := db.Raw(`SELECT COUNT(*) FROM pg_index i
+			JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+			WHERE i.indrelid = ?::regclass AND i.indisprimary AND a.attname = 'workspace_id'`, key.table).Scan(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		err = db.Exec(fmt.Sprintf("ALTER TABLE %[1]s DROP CONSTRAINT %[1]s_pkey, ADD PRIMARY KEY (%[2]s)", key.table, strings.Join(key.columns, ", "))).Error
+		if err != nil {
+			return err
+		}
+	}
+	// Category paths used to be unique per user; workspaces share user 0.
+	return db.Exec("DROP INDEX IF EXISTS idx_category_nodes_user_path").Error
+}
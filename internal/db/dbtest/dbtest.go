@@ -0,0 +1,37 @@
+// Package dbtest opens databases for tests that never connect to Postgres,
+// so the queries code builds can be checked and answered in memory.
+package dbtest
+
+import (
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+)
+
+// New returns a dry-run database that hands every query to answer, with
+// its SQL already built, instead of sending it to a server. answer fills
+// tx.Statement.Dest and sets tx.RowsAffected to return rows, or adds
+// gorm.ErrRecordNotFound to find nothing; a nil answer returns no rows.
+func New(t testing.TB, answer func(tx *gorm.DB)) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost", PreferSimpleProtocol: true}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	err = db.Callback().Query().Replace("gorm:query", func(tx *gorm.DB) {
+		callbacks.BuildQuerySQL(tx)
+		if answer != nil {
+			answer(tx)
+		}
+	})
+	if err != nil {
+		t.Fatalf("replace query callback: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,7 @@
+package dto
+
+type AskRequest struct {
+	Question string `json:"question" validate:"required,max=500"`
+	// Limit is how many posts to retrieve as context, 8 by default.
+	Limit int `json:"limit" validate:"omitempty,min=1,max=20"`
+}
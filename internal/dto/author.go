@@ -0,0 +1,6 @@
+package dto
+
+type MergeAuthorsRequest struct {
+	SourceIds []int64 `json:"source_ids" validate:"required,min=1"`
+	TargetId  int64   `json:"target_id" validate:"required"`
+}
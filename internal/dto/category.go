@@ -0,0 +1,19 @@
+package dto
+
+import "module/lynkbin/internal/models"
+
+type CreateCategoryRequest struct {
+	Name     string `json:"name" validate:"required,max=60"`
+	ParentId *int64 `json:"parent_id"`
+	Pinned   *bool  `json:"pinned"`
+}
+
+type PinCategoryRequest struct {
+	Pinned bool `json:"pinned"`
+}
+
+type CategoryTreeNode struct {
+	models.CategoryNode
+	PostCount int64               `json:"post_count"`
+	Children  []*CategoryTreeNode `json:"children"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+type CollectionRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+type AddCollectionPostRequest struct {
+	PostId int64  `json:"post_id" validate:"required"`
+	Note   string `json:"note" validate:"max=1000"`
+}
+
+type UpdateCollectionPostRequest struct {
+	Note string `json:"note" validate:"max=1000"`
+}
+
+type ReorderCollectionRequest struct {
+	PostIds []int64 `json:"post_ids" validate:"required"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+type UpdateDigestSettingsRequest struct {
+	Enabled           bool     `json:"enabled"`
+	Frequency         string   `json:"frequency" validate:"required,oneof=daily weekly"`
+	Hour              int      `json:"hour" validate:"min=0,max=23"`
+	Weekday           int      `json:"weekday" validate:"min=0,max=6"`
+	Timezone          string   `json:"timezone"`
+	Channels          []string `json:"channels" validate:"required,min=1,dive,oneof=email telegram"`
+	IncludeSummary    bool     `json:"include_summary"`
+	IncludeResurfaced bool     `json:"include_resurfaced"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+type CreateFeedRequest struct {
+	Name         string   `json:"name" validate:"max=200"`
+	Platform     string   `json:"platform"`
+	Tags         []string `json:"tags" validate:"max=20,dive,required"`
+	Category     string   `json:"category"`
+	CollectionId *int64   `json:"collection_id"`
+}
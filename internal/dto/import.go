@@ -0,0 +1,18 @@
+package dto
+
+import "mime/multipart"
+
+type CreateImportRequest struct {
+	File    *multipart.FileHeader `form:"file" validate:"required"`
+	Format  string                `form:"format" validate:"required,oneof=netscape pocket raindrop csv lynkbin"`
+	Folders string                `form:"folders" validate:"omitempty,oneof=collections tags"`
+}
+
+type GetImportItemsRequest struct {
+	Status string `form:"status" validate:"omitempty,oneof=pending processing imported duplicate failed cancelled"`
+	Page   int    `form:"page" validate:"min=0"`
+}
+
+type ExportLibraryRequest struct {
+	Format string `form:"format" validate:"omitempty,oneof=markdown json"`
+}
@@ -1,12 +1,21 @@
 package dto
 
-import "github.com/lib/pq"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type CreatePostRequest struct {
 	Url   string   `json:"url" validate:"url"`
 	Notes string   `json:"notes"`
 	IsUrl bool     `json:"is_url"`
 	Tags  []string `json:"tags"`
+	// Title is used as the topic when the post wasn't summarized, as happens
+	// for links to sites the scraper doesn't know.
+	Title string `json:"title"`
+	// Source records where a post saved in the background came from.
+	Source string `json:"-"`
 }
 
 type SummarizePostResponse struct {
@@ -21,12 +30,36 @@ type GetPostsRequest struct {
 	Tags       []string `form:"tags"`
 	Authors    []string `form:"authors"`
 	Categories []string `form:"categories"`
+	// ParentCategory filters by a category path including its subcategories.
+	ParentCategory string   `form:"parent_category"`
+	Query          string   `form:"q"`
+	CollectionId   int64    `form:"collection_id"`
+	Status         []string `form:"status" validate:"dive,oneof=unread reading read archived"`
+	Favorite       *bool    `form:"favorite"`
+	// Snoozed is exclude to hide posts snoozed into the future, include to
+	// show them alongside the rest, or only to list just them. Only the
+	// reading list leaves them out by default.
+	Snoozed string `form:"snoozed" validate:"omitempty,oneof=exclude include only"`
+	Sort    string `form:"sort" validate:"omitempty,oneof=created_at last_opened_at snoozed_until progress"`
+	Order   string `form:"order" validate:"omitempty,oneof=asc desc"`
+}
+
+// UpdatePostStateRequest changes only the fields that are set. A
+// snoozed_until that isn't in the future wakes the post up.
+type UpdatePostStateRequest struct {
+	Status       *string    `json:"status" validate:"omitempty,oneof=unread reading read archived"`
+	Favorite     *bool      `json:"favorite"`
+	SnoozedUntil *time.Time `json:"snoozed_until"`
+	Progress     *int       `json:"progress" validate:"omitempty,min=0,max=100"`
 }
 
 type GetAllTagsAndCategoriesCountResponse struct {
 	TotalPostsCount      int64 `json:"total_posts_count"`
 	TotalTagsCount       int64 `json:"total_tags_count"`
 	TotalCategoriesCount int64 `json:"total_categories_count"`
+	// UnreadPostsCount leaves out snoozed posts.
+	UnreadPostsCount   int64 `json:"unread_posts_count"`
+	FavoritePostsCount int64 `json:"favorite_posts_count"`
 }
 
 type Media struct {
@@ -38,3 +71,10 @@ type MediaData struct {
 	IsMedia bool    `json:"is_media"`
 	Media   []Media `json:"media"`
 }
+
+type AggregateRequest struct {
+	Q        string `form:"q"`
+	Platform string `form:"platform"`
+	Sort     string `form:"sort" validate:"omitempty,oneof=count recent name"`
+	Limit    int    `form:"limit" validate:"omitempty,min=1,max=500"`
+}
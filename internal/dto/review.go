@@ -0,0 +1,9 @@
+package dto
+
+type GetReviewPostsRequest struct {
+	Limit int `form:"limit" validate:"min=0,max=50"`
+}
+
+type ReviewPostRequest struct {
+	Feedback string `json:"feedback" validate:"required,oneof=useful not_useful"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+// CreateShareLinkRequest shares either a post or a collection.
+type CreateShareLinkRequest struct {
+	PostId       *int64     `json:"post_id" validate:"required_without=CollectionId,excluded_with=CollectionId"`
+	CollectionId *int64     `json:"collection_id" validate:"required_without=PostId,excluded_with=PostId"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+type CreateSubscriptionRequest struct {
+	Url       string   `json:"url" validate:"required,url"`
+	Keywords  []string `json:"keywords" validate:"max=50,dive,required"`
+	MatchTags []string `json:"match_tags" validate:"max=50,dive,required"`
+}
+
+type UpdateSubscriptionRequest struct {
+	Keywords  []string `json:"keywords" validate:"max=50,dive,required"`
+	MatchTags []string `json:"match_tags" validate:"max=50,dive,required"`
+	Paused    bool     `json:"paused"`
+}
+
+type GetSubscriptionEntriesRequest struct {
+	Page int `form:"page" validate:"min=0"`
+}
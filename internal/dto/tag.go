@@ -0,0 +1,16 @@
+package dto
+
+type MergeTagsRequest struct {
+	Sources []string `json:"sources" validate:"required,min=1,dive,required"`
+	Target  string   `json:"target" validate:"required"`
+}
+
+type RenameTagRequest struct {
+	From string `json:"from" validate:"required"`
+	To   string `json:"to" validate:"required"`
+}
+
+type AddTagSynonymRequest struct {
+	Alias string `json:"alias" validate:"required"`
+	Tag   string `json:"tag" validate:"required"`
+}
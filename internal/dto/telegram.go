@@ -0,0 +1,7 @@
+package dto
+
+type LinkTelegramChatRequest struct {
+	Code     string `json:"code" validate:"required"`
+	ChatId   int64  `json:"chat_id" validate:"required"`
+	Username string `json:"username"`
+}
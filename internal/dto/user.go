@@ -10,3 +10,45 @@ type LoginUserRequest struct {
 	Email    string `json:"email" required:"true" validate:"email"`
 	Password string `json:"password" required:"true"`
 }
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// CreateApiKeyRequest limits a key to reading or writing, and optionally to
+// a single workspace.
+type CreateApiKeyRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Access      string `json:"access" validate:"required,oneof=read write"`
+	WorkspaceId *int64 `json:"workspace_id" validate:"omitempty,gt=0"`
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type EmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"min=5,max=20"`
+}
+
+// DeleteAccountRequest carries the password to confirm the deletion. Users
+// without a password confirm by having signed in moments ago instead.
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+type WebhookRequest struct {
+	Url         string   `json:"url" validate:"required,url,max=2000"`
+	Description string   `json:"description" validate:"max=500"`
+	Events      []string `json:"events" validate:"required,min=1,dive,oneof=post.created post.categorized post.updated post.deleted"`
+	Disabled    bool     `json:"disabled"`
+}
+
+type GetWebhookDeliveriesRequest struct {
+	Status string `form:"status" validate:"omitempty,oneof=pending delivered failed"`
+	Page   int    `form:"page" validate:"min=0"`
+}
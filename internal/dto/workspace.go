@@ -0,0 +1,18 @@
+package dto
+
+type WorkspaceRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type UpdateWorkspaceMemberRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type CreateWorkspaceInviteRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type AcceptWorkspaceInviteRequest struct {
+	Token string `json:"token" validate:"required"`
+}
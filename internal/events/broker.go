@@ -0,0 +1,78 @@
+package events
+
+import (
+	"sync"
+)
+
+// subscriberBuffer is how many events a slow subscriber can fall behind by
+// before events for it are dropped.
+const subscriberBuffer = 32
+
+// Relay carries events from the instance they were published on to every
+// instance's broker. The in-process relay is enough for a single server;
+// PostgresRelay shares events between several.
+type Relay interface {
+	// Send passes an event on to the brokers of all instances.
+	Send(event Event) error
+}
+
+// Broker fans the events of a library out to the clients streaming them.
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: map[*Subscription]struct{}{}}
+}
+
+// Subscription receives the events matching its filter on Events until it
+// is closed.
+type Subscription struct {
+	Events  chan Event
+	matches func(Event) bool
+	broker  *Broker
+	once    sync.Once
+}
+
+func (b *Broker) Subscribe(matches func(Event) bool) *Subscription {
+	subscription := &Subscription{
+		Events:  make(chan Event, subscriberBuffer),
+		matches: matches,
+		broker:  b,
+	}
+	b.mu.Lock()
+	b.subscribers[subscription] = struct{}{}
+	b.mu.Unlock()
+	return subscription
+}
+
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.broker.mu.Lock()
+		delete(s.broker.subscribers, s)
+		s.broker.mu.Unlock()
+	})
+}
+
+// Deliver hands an event to every matching subscriber without waiting for
+// any of them.
+func (b *Broker) Deliver(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for subscription := range b.subscribers {
+		if !subscription.matches(event) {
+			continue
+		}
+		select {
+		case subscription.Events <- event:
+		default:
+		}
+	}
+}
+
+// Send makes the broker its own relay, for when there is only one server.
+func (b *Broker) Send(event Event) error {
+	b.Deliver(event)
+	return nil
+}
@@ -0,0 +1,89 @@
+// Package events passes what happens to a library on to whoever wants to
+// react to it, such as webhooks.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const (
+	PostCreated     = "post.created"
+	PostCategorized = "post.categorized"
+	// PostUpdated is sent when a post is edited, its reading state changes
+	// or a tag or author merge rewrites it. Author names that follow a
+	// profile refreshed while saving another post are not announced.
+	PostUpdated = "post.updated"
+	PostDeleted = "post.deleted"
+	// PostEnrichment reports how far saving a link has got; it isn't
+	// offered to webhooks.
+	PostEnrichment = "post.enrichment"
+)
+
+// Types are the events that can be subscribed to.
+var Types = []string{PostCreated, PostCategorized, PostUpdated, PostDeleted}
+
+// Event is something that happened in the library of UserId, or of
+// WorkspaceId when it is set.
+type Event struct {
+	Id          string    `json:"id"`
+	Type        string    `json:"type"`
+	UserId      int64     `json:"user_id"`
+	WorkspaceId int64     `json:"workspace_id"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	Data        any       `json:"data"`
+	// Truncated is set when Data was left out to fit the transport.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// NewId returns a random ID for an event, or for anything that has to be
+// correlated across events.
+func NewId() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func New(eventType string, userId int64, workspaceId int64, data any) Event {
+	return Event{
+		Id:          NewId(),
+		Type:        eventType,
+		UserId:      userId,
+		WorkspaceId: workspaceId,
+		OccurredAt:  time.Now().UTC(),
+		Data:        data,
+	}
+}
+
+// Bus hands each published event to every handler, in the publisher's
+// goroutine. Handlers should hand slow work off rather than hold the
+// publisher up.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []func(Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(handler func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish is a no-op on a nil bus, so services work without one.
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
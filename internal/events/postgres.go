@@ -0,0 +1,90 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	notifyChannel = "lynkbin_events"
+	// maxNotifyPayload stays under Postgres' 8000 byte limit on NOTIFY
+	// payloads.
+	maxNotifyPayload = 7900
+	relayRetryDelay  = 5 * time.Second
+)
+
+// PostgresRelay shares events between server instances with LISTEN and
+// NOTIFY, so a client streaming from one instance sees posts saved through
+// another.
+type PostgresRelay struct {
+	DB     *gorm.DB
+	DBURL  string
+	Broker *Broker
+}
+
+func NewPostgresRelay(db *gorm.DB, dbURL string, broker *Broker) *PostgresRelay {
+	return &PostgresRelay{DB: db, DBURL: dbURL, Broker: broker}
+}
+
+// Send notifies every listening instance, this one included. Events too big
+// for a notification go out without their data; clients fetch it instead.
+func (r *PostgresRelay) Send(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		event.Data = nil
+		event.Truncated = true
+		payload, err = json.Marshal(event)
+		if err != nil {
+			return err
+		}
+	}
+	return r.DB.Exec("SELECT pg_notify(?, ?)", notifyChannel, string(payload)).Error
+}
+
+// Run listens for notifications and delivers them to the broker until ctx
+// is done, reconnecting when the connection drops.
+func (r *PostgresRelay) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := r.listen(ctx)
+		if err != nil && ctx.Err() == nil {
+			fmt.Println("Error listening for events: ", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(relayRetryDelay):
+		}
+	}
+}
+
+func (r *PostgresRelay) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, r.DBURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	_, err = conn.Exec(ctx, "LISTEN "+notifyChannel)
+	if err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var event Event
+		err = json.Unmarshal([]byte(notification.Payload), &event)
+		if err != nil {
+			fmt.Println("Error decoding event notification: ", err)
+			continue
+		}
+		r.Broker.Deliver(event)
+	}
+}
@@ -0,0 +1,208 @@
+// Package exporter writes a library out as a Markdown vault for notes tools
+// such as Obsidian, or as JSON that the importer reads back.
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/utilities"
+	"strings"
+	"time"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+
+	// LibraryFormat marks JSON written by Library so the importer can tell
+	// it apart.
+	LibraryFormat  = "lynkbin"
+	LibraryVersion = 1
+)
+
+// File is one file of an export, named by its slash separated path.
+type File struct {
+	Name string
+	Data []byte
+}
+
+// CollectionEntry places a post in a collection.
+type CollectionEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Position    int    `json:"position"`
+	Note        string `json:"note,omitempty"`
+}
+
+// Post is a post as it is exported, without the ids that only mean
+// something inside one library.
+type Post struct {
+	Platform    string            `json:"platform"`
+	Data        string            `json:"data"`
+	Topic       string            `json:"topic"`
+	Description string            `json:"description"`
+	Author      string            `json:"author"`
+	Category    string            `json:"category"`
+	Tags        []string          `json:"tags"`
+	Source      string            `json:"source,omitempty"`
+	Status      string            `json:"status,omitempty"`
+	Favorite    bool              `json:"favorite,omitempty"`
+	Progress    int               `json:"progress,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Collections []CollectionEntry `json:"collections,omitempty"`
+}
+
+type Library struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Posts      []Post    `json:"posts"`
+}
+
+// NewPost copies what is kept of a post, and of the exporting user's
+// reading state of it, into its exported form.
+func NewPost(post models.Post, state models.ReadingState, collections []CollectionEntry) Post {
+	tags := []string(post.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return Post{
+		Platform:    post.Platform,
+		Data:        post.Data,
+		Topic:       post.Topic,
+		Description: post.Description,
+		Author:      post.Author,
+		Category:    post.Category,
+		Tags:        tags,
+		Source:      post.Source,
+		Status:      state.Status,
+		Favorite:    state.Favorite,
+		Progress:    state.Progress,
+		CreatedAt:   post.CreatedAt,
+		Collections: collections,
+	}
+}
+
+// LibraryJSON writes the posts in the format the importer reads back.
+func LibraryJSON(posts []Post) ([]byte, error) {
+	if posts == nil {
+		posts = []Post{}
+	}
+	return json.MarshalIndent(Library{
+		Format:     LibraryFormat,
+		Version:    LibraryVersion,
+		ExportedAt: time.Now().UTC(),
+		Posts:      posts,
+	}, "", "  ")
+}
+
+// MarkdownFiles writes one note per post, in folders following the category
+// tree. Each note starts with YAML front matter for its metadata.
+func MarkdownFiles(posts []Post) []File {
+	files := make([]File, 0, len(posts))
+	used := map[string]int{}
+	for _, post := range posts {
+		folder := categoryFolder(post.Category)
+		name := fileName(post)
+		path := folder + "/" + name
+		// Notes tools key notes by name, so posts sharing a title get a
+		// number instead of overwriting each other.
+		used[strings.ToLower(path)]++
+		if count := used[strings.ToLower(path)]; count > 1 {
+			path = fmt.Sprintf("%s/%s %d", folder, name, count)
+		}
+		files = append(files, File{Name: path + ".md", Data: []byte(Markdown(post))})
+	}
+	return files
+}
+
+// Markdown renders a single post as a note.
+func Markdown(post Post) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	writeYAML(&b, "title", title(post))
+	writeYAML(&b, "platform", post.Platform)
+	writeYAML(&b, "author", post.Author)
+	writeYAML(&b, "category", post.Category)
+	b.WriteString("tags:")
+	if len(post.Tags) == 0 {
+		b.WriteString(" []")
+	}
+	b.WriteString("\n")
+	for _, tag := range post.Tags {
+		b.WriteString("  - " + yamlString(tag) + "\n")
+	}
+	if post.Platform != "notes" {
+		writeYAML(&b, "source", post.Data)
+	}
+	writeYAML(&b, "created_at", post.CreatedAt.UTC().Format(time.RFC3339))
+	b.WriteString("---\n\n")
+
+	fmt.Fprintf(&b, "# %s\n", title(post))
+	if post.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", post.Description)
+	}
+	if post.Platform == "notes" {
+		fmt.Fprintf(&b, "\n%s\n", post.Data)
+	} else {
+		fmt.Fprintf(&b, "\n[Original post](%s)\n", post.Data)
+	}
+	return b.String()
+}
+
+func title(post Post) string {
+	if post.Topic != "" {
+		return post.Topic
+	}
+	if post.Platform == "notes" {
+		return "Note from " + post.CreatedAt.UTC().Format("2006-01-02")
+	}
+	return post.Data
+}
+
+func writeYAML(b *strings.Builder, key string, value string) {
+	b.WriteString(key + ": " + yamlString(value) + "\n")
+}
+
+// yamlString quotes a value as a JSON string, which is also a valid YAML
+// double-quoted scalar whatever the value holds.
+func yamlString(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+func categoryFolder(category string) string {
+	segments := utilities.SplitCategoryPath(category)
+	if len(segments) == 0 {
+		return safeName(utilities.UncategorizedCategory)
+	}
+	for i, segment := range segments {
+		segments[i] = safeName(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func fileName(post Post) string {
+	name := safeName(title(post))
+	if runes := []rune(name); len(runes) > 80 {
+		name = strings.TrimSpace(string(runes[:80]))
+	}
+	return name
+}
+
+// safeName drops the characters file systems or Obsidian links can't have
+// in a name.
+func safeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|#^[]`, r) || r < ' ' {
+			return ' '
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.Join(strings.Fields(name), " "), ". ")
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
@@ -0,0 +1,224 @@
+// Package feedreader parses RSS 2.0, RSS 1.0 and Atom feeds into a common
+// shape.
+package feedreader
+
+import (
+	"encoding/xml"
+	"errors"
+	"html"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrNotAFeed = errors.New("not an RSS or Atom feed")
+
+type Feed struct {
+	Title string
+	Items []Item
+}
+
+// Item is one entry of a feed. Link is resolved against the feed's URL.
+type Item struct {
+	Id          string
+	Link        string
+	Title       string
+	Summary     string
+	Categories  []string
+	PublishedAt *time.Time
+}
+
+// document covers the elements of all three formats that are read. Their
+// names don't clash, so a single pass of the decoder is enough.
+type document struct {
+	XMLName xml.Name
+	// RSS 2.0 wraps its items in a channel, RSS 1.0 puts them next to it.
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"`
+	// Atom
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Guid        string   `xml:"guid"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	About       string   `xml:"about,attr"`
+}
+
+type atomEntry struct {
+	Id      string `xml:"id"`
+	Title   string `xml:"title"`
+	Summary string `xml:"summary"`
+	Content string `xml:"content"`
+	Links   []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Categories []struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr"`
+	} `xml:"category"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+// Parse reads a feed fetched from feedURL.
+func Parse(r io.Reader, feedURL string) (Feed, error) {
+	decoder := xml.NewDecoder(r)
+	// Feeds in the wild declare all sorts of charsets; the characters that
+	// matter here are nearly always ASCII.
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	var doc document
+	err := decoder.Decode(&doc)
+	if err != nil {
+		return Feed{}, ErrNotAFeed
+	}
+	base, _ := url.Parse(feedURL)
+
+	var feed Feed
+	switch strings.ToLower(doc.XMLName.Local) {
+	case "rss", "rdf":
+		feed.Title = doc.Channel.Title
+		for _, item := range append(doc.Channel.Items, doc.Items...) {
+			link := item.Link
+			if link == "" && strings.HasPrefix(item.Guid, "http") {
+				link = item.Guid
+			}
+			if link == "" {
+				link = item.About
+			}
+			id := item.Guid
+			if id == "" {
+				id = link
+			}
+			feed.Items = append(feed.Items, Item{
+				Id:          strings.TrimSpace(id),
+				Link:        resolve(base, link),
+				Title:       clean(item.Title),
+				Summary:     clean(item.Description),
+				Categories:  cleanAll(append(item.Categories, item.Subjects...)),
+				PublishedAt: parseDate(item.PubDate, item.Date),
+			})
+		}
+	case "feed":
+		feed.Title = doc.Title
+		for _, entry := range doc.Entries {
+			link := ""
+			for _, candidate := range entry.Links {
+				if candidate.Rel == "" || candidate.Rel == "alternate" {
+					link = candidate.Href
+					break
+				}
+			}
+			summary := entry.Summary
+			if summary == "" {
+				summary = entry.Content
+			}
+			var categories []string
+			for _, category := range entry.Categories {
+				if category.Term != "" {
+					categories = append(categories, category.Term)
+				} else {
+					categories = append(categories, category.Label)
+				}
+			}
+			id := entry.Id
+			if id == "" {
+				id = link
+			}
+			feed.Items = append(feed.Items, Item{
+				Id:          strings.TrimSpace(id),
+				Link:        resolve(base, link),
+				Title:       clean(entry.Title),
+				Summary:     clean(summary),
+				Categories:  cleanAll(categories),
+				PublishedAt: parseDate(entry.Published, entry.Updated),
+			})
+		}
+	default:
+		return Feed{}, ErrNotAFeed
+	}
+	feed.Title = clean(feed.Title)
+	return feed, nil
+}
+
+func resolve(base *url.URL, link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" || base == nil {
+		return link
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(parsed).String()
+}
+
+// clean collapses whitespace and strips the markup summaries often carry,
+// since posts keep plain text.
+func clean(text string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range text {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+			b.WriteRune(' ')
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(b.String())), " ")
+}
+
+func cleanAll(values []string) []string {
+	cleaned := []string{}
+	for _, value := range values {
+		if value = clean(value); value != "" {
+			cleaned = append(cleaned, value)
+		}
+	}
+	return cleaned
+}
+
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parseDate(values ...string) *time.Time {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		for _, layout := range dateLayouts {
+			date, err := time.Parse(layout, value)
+			if err == nil {
+				return &date
+			}
+		}
+	}
+	return nil
+}
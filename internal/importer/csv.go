@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// csvColumns maps the header names used by Pocket, Raindrop and common
+// spreadsheets to the fields of an Item.
+var csvColumns = map[string][]string{
+	"url":    {"url", "link", "href", "address"},
+	"title":  {"title", "name"},
+	"tags":   {"tags", "tag", "labels"},
+	"folder": {"folder", "collection", "category", "list"},
+	"note":   {"note", "notes", "description", "excerpt", "comment"},
+	"date":   {"created", "created_at", "date", "time_added", "added", "add_date"},
+}
+
+// parseCSV reads a CSV file with a header row. Pocket's export
+// (title,url,time_added,tags,status) and Raindrop's
+// (id,title,note,excerpt,url,folder,tags,created,...) are both covered by
+// the column names above.
+func parseCSV(r io.Reader) ([]Item, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNoItems
+		}
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for field, names := range csvColumns {
+		for i, column := range header {
+			column = strings.ToLower(strings.TrimSpace(column))
+			if slices.Contains(names, column) {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, fmt.Errorf("the CSV header has no url column")
+	}
+
+	var items []Item
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		items = append(items, Item{
+			URL:     value("url"),
+			Title:   value("title"),
+			Tags:    splitTags(value("tags")),
+			Folder:  strings.Trim(value("folder"), "/"),
+			Note:    value("note"),
+			AddedAt: parseDate(value("date")),
+		})
+	}
+}
+
+type raindropItem struct {
+	Link       string   `json:"link"`
+	Title      string   `json:"title"`
+	Excerpt    string   `json:"excerpt"`
+	Note       string   `json:"note"`
+	Tags       []string `json:"tags"`
+	Created    string   `json:"created"`
+	Folder     string   `json:"folder"`
+	Collection struct {
+		Title string `json:"title"`
+	} `json:"collection"`
+}
+
+// parseRaindropJSON reads Raindrop bookmarks as returned by its API and
+// backups, either a bare array or wrapped in {"items": [...]}.
+func parseRaindropJSON(r io.Reader) ([]Item, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var raindrops []raindropItem
+	err = json.Unmarshal(data, &raindrops)
+	if err != nil {
+		var wrapped struct {
+			Items []raindropItem `json:"items"`
+		}
+		if json.Unmarshal(data, &wrapped) != nil {
+			return nil, fmt.Errorf("invalid Raindrop JSON: %w", err)
+		}
+		raindrops = wrapped.Items
+	}
+
+	items := make([]Item, 0, len(raindrops))
+	for _, raindrop := range raindrops {
+		note := raindrop.Note
+		if note == "" {
+			note = raindrop.Excerpt
+		}
+		items = append(items, Item{
+			URL:     raindrop.Link,
+			Title:   raindrop.Title,
+			Tags:    raindrop.Tags,
+			Folder:  strings.Trim(firstNonEmpty(raindrop.Folder, raindrop.Collection.Title), "/"),
+			Note:    note,
+			AddedAt: parseDate(raindrop.Created),
+		})
+	}
+	return items, nil
+}
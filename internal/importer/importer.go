@@ -0,0 +1,164 @@
+// Package importer reads bookmarks exported from other tools into a common
+// shape so they can be saved as posts.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"module/lynkbin/internal/exporter"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatNetscape = "netscape"
+	FormatPocket   = "pocket"
+	FormatRaindrop = "raindrop"
+	FormatCSV      = "csv"
+	// FormatLynkbin is the JSON written by exporter.LibraryJSON.
+	FormatLynkbin = exporter.LibraryFormat
+)
+
+var ErrNoItems = errors.New("no bookmarks found in the file")
+
+// Item is one bookmark. Folder is the path of folders the bookmark was
+// filed under, separated by "/". Post is only set for lynkbin exports, which
+// are restored as they were instead of being saved like a new link.
+type Item struct {
+	URL     string
+	Title   string
+	Tags    []string
+	Folder  string
+	Note    string
+	AddedAt *time.Time
+	Post    *exporter.Post
+}
+
+// Parse reads the bookmarks of an export in the given format. Pocket and
+// Raindrop exports come in several shapes, which are told apart by their
+// content. Bookmarks that are not web links are left out.
+func Parse(format string, r io.Reader) ([]Item, error) {
+	reader := bufio.NewReader(r)
+	first, err := firstByte(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	switch {
+	case format == FormatLynkbin:
+		items, err = parseLibrary(reader)
+	case format == FormatNetscape || (format == FormatPocket && first == '<'):
+		items, err = parseNetscape(reader)
+	case format == FormatRaindrop && (first == '[' || first == '{'):
+		items, err = parseRaindropJSON(reader)
+	case format == FormatPocket || format == FormatRaindrop || format == FormatCSV:
+		items, err = parseCSV(reader)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	valid := items[:0]
+	for _, item := range items {
+		if item.Post == nil && !isWebLink(item.URL) {
+			continue
+		}
+		item.Title = strings.TrimSpace(item.Title)
+		item.Note = strings.TrimSpace(item.Note)
+		item.Tags = cleanTags(item.Tags)
+		valid = append(valid, item)
+	}
+	if len(valid) == 0 {
+		return nil, ErrNoItems
+	}
+	return valid, nil
+}
+
+// firstByte peeks at the first byte after any byte order mark and leading
+// whitespace.
+func firstByte(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, ErrNoItems
+			}
+			return 0, err
+		}
+		if bom, _ := reader.Peek(3); bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+			reader.Discard(3)
+			continue
+		}
+		if b[0] == ' ' || b[0] == '\t' || b[0] == '\r' || b[0] == '\n' {
+			reader.Discard(1)
+			continue
+		}
+		return b[0], nil
+	}
+}
+
+func isWebLink(link string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func cleanTags(tags []string) []string {
+	seen := map[string]bool{}
+	cleaned := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		cleaned = append(cleaned, tag)
+	}
+	return cleaned
+}
+
+// splitTags splits a list of tags on any of the separators the tools use.
+func splitTags(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == '|'
+	})
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseDate reads Unix seconds or one of the common date layouts, returning
+// nil for anything else.
+func parseDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 {
+			return nil
+		}
+		date := time.Unix(seconds, 0).UTC()
+		return &date
+	}
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return &date
+		}
+	}
+	return nil
+}
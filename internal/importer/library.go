@@ -0,0 +1,40 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"module/lynkbin/internal/exporter"
+	"strings"
+)
+
+// parseLibrary reads a lynkbin JSON export, notes included.
+func parseLibrary(r io.Reader) ([]Item, error) {
+	var library exporter.Library
+	err := json.NewDecoder(r).Decode(&library)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lynkbin export: %w", err)
+	}
+	if library.Format != exporter.LibraryFormat {
+		return nil, fmt.Errorf("not a lynkbin export")
+	}
+	if library.Version > exporter.LibraryVersion {
+		return nil, fmt.Errorf("lynkbin export version %d is newer than this server supports", library.Version)
+	}
+
+	items := make([]Item, 0, len(library.Posts))
+	for _, post := range library.Posts {
+		if strings.TrimSpace(post.Data) == "" {
+			continue
+		}
+		createdAt := post.CreatedAt
+		items = append(items, Item{
+			URL:     post.Data,
+			Title:   post.Topic,
+			Tags:    post.Tags,
+			AddedAt: &createdAt,
+			Post:    &post,
+		})
+	}
+	return items, nil
+}
@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// parseNetscape reads the Netscape bookmark file format that browsers
+// export, which Pocket's HTML export follows as well. Folders are <H3>
+// headings followed by a <DL> list of their contents; a <DD> after a link
+// holds its description.
+func parseNetscape(r io.Reader) ([]Item, error) {
+	tokenizer := html.NewTokenizer(r)
+	var items []Item
+	var folders []string
+	pendingFolder := ""
+	// inNote is set after a <DD> while its text runs, up to the next tag.
+	inNote := false
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if errors.Is(tokenizer.Err(), io.EOF) {
+				return items, nil
+			}
+			return nil, tokenizer.Err()
+		}
+		token := tokenizer.Token()
+		if tokenType == html.TextToken {
+			if inNote && len(items) > 0 {
+				items[len(items)-1].Note += token.Data
+			}
+			continue
+		}
+		inNote = false
+		if tokenType == html.EndTagToken && token.Data == "dl" {
+			if len(folders) > 0 {
+				folders = folders[:len(folders)-1]
+			}
+			continue
+		}
+		if tokenType != html.StartTagToken {
+			continue
+		}
+
+		switch token.Data {
+		case "h3":
+			pendingFolder = readText(tokenizer, "h3")
+		case "dl":
+			folders = append(folders, pendingFolder)
+			pendingFolder = ""
+		case "a":
+			item := Item{
+				URL:     attr(token, "href"),
+				Tags:    splitTags(attr(token, "tags")),
+				Folder:  folderPath(folders),
+				AddedAt: parseDate(firstNonEmpty(attr(token, "add_date"), attr(token, "time_added"))),
+			}
+			item.Title = readText(tokenizer, "a")
+			items = append(items, item)
+		case "dd":
+			inNote = true
+		}
+	}
+}
+
+// readText collects the text up to the end of the element named tag.
+func readText(tokenizer *html.Tokenizer, tag string) string {
+	var text strings.Builder
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		if tokenType == html.TextToken {
+			text.Write(tokenizer.Text())
+			continue
+		}
+		name, _ := tokenizer.TagName()
+		if tokenType == html.EndTagToken && string(name) == tag {
+			break
+		}
+	}
+	return strings.Join(strings.Fields(text.String()), " ")
+}
+
+func attr(token html.Token, name string) string {
+	for _, attribute := range token.Attr {
+		if attribute.Key == name {
+			return attribute.Val
+		}
+	}
+	return ""
+}
+
+func folderPath(folders []string) string {
+	parts := []string{}
+	for _, folder := range folders {
+		folder = strings.TrimSpace(strings.ReplaceAll(folder, "/", "-"))
+		if folder != "" {
+			parts = append(parts, folder)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -1,64 +1,288 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/auth"
+	"module/lynkbin/internal/models"
 	"module/lynkbin/internal/repo"
 	"module/lynkbin/internal/utilities"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
 type MiddlewareService struct {
-	userRepo *repo.UserRepo
+	userRepo        *repo.UserRepo
+	sessionRepo     *repo.SessionRepo
+	telegramRepo    *repo.TelegramRepo
+	workspaceRepo   *repo.WorkspaceRepo
+	apiKeyRepo      *repo.ApiKeyRepo
+	tokenManager    *auth.TokenManager
+	botServiceToken string
 }
 
-func NewMiddlewareService(userRepo *repo.UserRepo) *MiddlewareService {
-	return &MiddlewareService{userRepo: userRepo}
+func NewMiddlewareService(userRepo *repo.UserRepo, sessionRepo *repo.SessionRepo, telegramRepo *repo.TelegramRepo, workspaceRepo *repo.WorkspaceRepo, apiKeyRepo *repo.ApiKeyRepo, tokenManager *auth.TokenManager, botServiceToken string) *MiddlewareService {
+	return &MiddlewareService{
+		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
+		telegramRepo:    telegramRepo,
+		workspaceRepo:   workspaceRepo,
+		apiKeyRepo:      apiKeyRepo,
+		tokenManager:    tokenManager,
+		botServiceToken: botServiceToken,
+	}
+}
+
+// isBotService checks the bot's service credential. Bot access is disabled
+// entirely when no credential is configured.
+func (m *MiddlewareService) isBotService(ctx *gin.Context) bool {
+	botToken := ctx.Request.Header.Get("X-Bot-Token")
+	if m.botServiceToken == "" || botToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(botToken), []byte(m.botServiceToken)) == 1
+}
+
+// BotServiceMiddleware only lets the bot itself through, for endpoints that
+// act on behalf of a chat that is not linked yet.
+func (m *MiddlewareService) BotServiceMiddleware(ctx *gin.Context) {
+	if !m.isBotService(ctx) {
+		utilities.Response(ctx, http.StatusUnauthorized, false, nil, "Unauthorized")
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
 }
 
 func (m *MiddlewareService) AuthMiddleware(ctx *gin.Context) {
-	platform := ctx.Request.Header.Get("X-Platform-Id")
-	if platform == "telegram-bot" {
-		email := ctx.Request.Header.Get("X-Email-Id")
-		if email == "" {
-			utilities.Response(ctx, http.StatusUnauthorized, false, nil, "Unauthorized")
-			ctx.Abort()
-			return
-		}
-		userId, err := m.userRepo.GetUserIdByEmail(email)
-		if err != nil {
-			utilities.Response(ctx, http.StatusUnauthorized, false, nil, "Unauthorized")
-		}
-		ctx.Set("user_id", userId)
-		ctx.Next()
+	if ctx.Request.Header.Get("X-Bot-Token") != "" {
+		m.authenticateBot(ctx)
 		return
 	}
 	authToken := ctx.Request.Header.Get("X-Auth-Token")
+	if bearer, ok := strings.CutPrefix(ctx.Request.Header.Get("Authorization"), "Bearer "); ok && authToken == "" {
+		authToken = strings.TrimSpace(bearer)
+	}
+	if strings.HasPrefix(authToken, auth.ApiKeyPrefix) {
+		m.authenticateApiKey(ctx, authToken)
+		return
+	}
 	if authToken == "" {
 		utilities.Response(ctx, http.StatusUnauthorized, false, nil, "Unauthorized")
 		ctx.Abort()
 		return
 	}
 
-	token, err := jwt.ParseWithClaims(authToken, jwt.MapClaims{}, func(t *jwt.Token) (any, error) {
-		return []byte("secret_key"), nil
-	})
-
+	claims, err := m.tokenManager.ParseAccessToken(authToken)
 	if err != nil {
 		utilities.Response(ctx, http.StatusUnauthorized, false, nil, "Unauthorized")
 		ctx.Abort()
 		return
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
+	// Access tokens die with their session on logout, not only on expiry.
+	active, err := m.sessionRepo.IsSessionActive(claims.UserId, claims.SessionId)
+	if err != nil {
+		fmt.Println("Error checking session: ", err)
+		utilities.Response(ctx, http.StatusInternalServerError, false, nil, "Internal server error")
+		ctx.Abort()
+		return
+	}
+	if !active {
+		utilities.Response(ctx, http.StatusUnauthorized, false, nil, "Session has been revoked")
+		ctx.Abort()
+		return
+	}
+
+	ctx.Set("user_id", claims.UserId)
+	ctx.Set("session_id", claims.SessionId)
+	if !m.setWorkspace(ctx, claims.UserId, ctx.Request.Header.Get("X-Workspace-Id")) {
+		return
+	}
+	ctx.Next()
+}
+
+// authenticateApiKey lets scripts act as the key's owner, within the key's
+// access level and workspace.
+func (m *MiddlewareService) authenticateApiKey(ctx *gin.Context, apiKey string) {
+	key, err := m.apiKeyRepo.GetActiveApiKey(auth.HashToken(apiKey))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, http.StatusUnauthorized, false, nil, "Invalid API key")
+			ctx.Abort()
+			return
+		}
+		fmt.Println("Error getting API key: ", err)
+		utilities.Response(ctx, http.StatusInternalServerError, false, nil, "Internal server error")
+		ctx.Abort()
+		return
+	}
+	err = m.apiKeyRepo.TouchApiKey(key.Id, time.Now())
+	if err != nil {
+		// Losing a last-used timestamp is no reason to fail the request.
+		fmt.Println("Error updating API key last used: ", err)
+	}
+
+	workspaceHeader := ctx.Request.Header.Get("X-Workspace-Id")
+	if key.WorkspaceId != nil {
+		keyWorkspace := strconv.FormatInt(*key.WorkspaceId, 10)
+		if workspaceHeader != "" && workspaceHeader != keyWorkspace {
+			utilities.Response(ctx, http.StatusForbidden, false, nil, "This API key is limited to another workspace")
+			ctx.Abort()
+			return
+		}
+		workspaceHeader = keyWorkspace
+	}
+
+	ctx.Set("user_id", key.UserId)
+	ctx.Set("api_key_id", key.Id)
+	ctx.Set("api_key_access", key.Access)
+	if !m.setWorkspace(ctx, key.UserId, workspaceHeader) {
+		return
+	}
+	ctx.Next()
+}
+
+// setWorkspace resolves the library the request works in from the
+// X-Workspace-Id header. Without the header the user works in their
+// personal library, where they are the owner.
+func (m *MiddlewareService) setWorkspace(ctx *gin.Context, userId int64, header string) bool {
+	if header == "" || header == "0" {
+		ctx.Set("workspace_id", int64(0))
+		ctx.Set("workspace_role", models.WorkspaceRoleOwner)
+		return true
+	}
+	workspaceId, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || workspaceId < 0 {
+		utilities.Response(ctx, http.StatusBadRequest, false, nil, "Invalid workspace ID")
+		ctx.Abort()
+		return false
+	}
+	member, err := m.workspaceRepo.GetMembership(workspaceId, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, http.StatusForbidden, false, nil, "You are not a member of this workspace")
+			ctx.Abort()
+			return false
+		}
+		fmt.Println("Error getting workspace membership: ", err)
+		utilities.Response(ctx, http.StatusInternalServerError, false, nil, "Internal server error")
+		ctx.Abort()
+		return false
+	}
+	ctx.Set("workspace_id", workspaceId)
+	ctx.Set("workspace_role", member.Role)
+	return true
+}
+
+// RequireWriteAccess keeps viewers and read-only API keys from changing a
+// library. It must run after AuthMiddleware.
+func (m *MiddlewareService) RequireWriteAccess(ctx *gin.Context) {
+	if ctx.GetString("api_key_access") == models.ApiKeyAccessRead {
+		utilities.Response(ctx, http.StatusForbidden, false, nil, "This API key is read-only")
+		ctx.Abort()
+		return
+	}
+	if ctx.GetString("workspace_role") == models.WorkspaceRoleViewer {
+		utilities.Response(ctx, http.StatusForbidden, false, nil, "Viewers cannot make changes to this workspace")
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
+
+// authenticateBot lets the bot act as the user linked to the chat it is
+// serving. Both the service credential and a linked chat are required.
+func (m *MiddlewareService) authenticateBot(ctx *gin.Context) {
+	if !m.isBotService(ctx) {
 		utilities.Response(ctx, http.StatusUnauthorized, false, nil, "Unauthorized")
 		ctx.Abort()
 		return
 	}
+	chatId, err := strconv.ParseInt(ctx.Request.Header.Get("X-Telegram-Chat-Id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, http.StatusUnauthorized, false, nil, "Unauthorized")
+		ctx.Abort()
+		return
+	}
+	link, err := m.telegramRepo.GetLinkByChatId(chatId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, http.StatusUnauthorized, false, nil, "Telegram chat is not linked")
+			ctx.Abort()
+			return
+		}
+		fmt.Println("Error getting telegram link: ", err)
+		utilities.Response(ctx, http.StatusInternalServerError, false, nil, "Internal server error")
+		ctx.Abort()
+		return
+	}
+	ctx.Set("user_id", link.UserId)
+	ctx.Set("telegram_chat_id", link.ChatId)
+	if !m.setWorkspace(ctx, link.UserId, ctx.Request.Header.Get("X-Workspace-Id")) {
+		return
+	}
+	ctx.Next()
+}
+
+// StillAuthorized checks again that the credentials AuthMiddleware accepted
+// for a request are still good: the session or API key hasn't been revoked,
+// the Telegram chat is still linked and the user is still a member of the
+// workspace. Long-lived requests call it to notice access being taken away.
+func (m *MiddlewareService) StillAuthorized(ctx *gin.Context) (bool, error) {
+	userId := ctx.GetInt64("user_id")
+	if sessionId := ctx.GetString("session_id"); sessionId != "" {
+		active, err := m.sessionRepo.IsSessionActive(userId, sessionId)
+		if err != nil || !active {
+			return false, err
+		}
+	}
+	if apiKeyId := ctx.GetInt64("api_key_id"); apiKeyId != 0 {
+		active, err := m.apiKeyRepo.IsApiKeyActive(apiKeyId)
+		if err != nil || !active {
+			return false, err
+		}
+	}
+	if chatId := ctx.GetInt64("telegram_chat_id"); chatId != 0 {
+		link, err := m.telegramRepo.GetLinkByChatId(chatId)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil || link.UserId != userId {
+			return false, err
+		}
+	}
+	if workspaceId := ctx.GetInt64("workspace_id"); workspaceId != 0 {
+		_, err := m.workspaceRepo.GetMembership(workspaceId, userId)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
 
-	userId := claims["user_id"]
-	ctx.Set("user_id", int64(userId.(float64)))
+// RequireSession rejects API keys and the Telegram bot on account
+// management routes, so a leaked key or bot token can't be used to mint
+// more keys or take over the account.
+func (m *MiddlewareService) RequireSession(ctx *gin.Context) {
+	if ctx.GetInt64("api_key_id") != 0 {
+		utilities.Response(ctx, http.StatusForbidden, false, nil, "API keys cannot be used for this")
+		ctx.Abort()
+		return
+	}
+	if ctx.GetInt64("telegram_chat_id") != 0 {
+		utilities.Response(ctx, http.StatusForbidden, false, nil, "The Telegram bot cannot be used for this")
+		ctx.Abort()
+		return
+	}
 	ctx.Next()
 }
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"module/lynkbin/internal/db/dbtest"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// linkedChatDB answers every query with a Telegram link from chat 42 to
+// user 7.
+func linkedChatDB(t *testing.T) *gorm.DB {
+	return dbtest.New(t, func(tx *gorm.DB) {
+		if link, ok := tx.Statement.Dest.(*models.TelegramLink); ok {
+			*link = models.TelegramLink{ChatId: 42, UserId: 7}
+			tx.RowsAffected = 1
+		}
+	})
+}
+
+func TestRequireSessionRejectsBot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewMiddlewareService(nil, nil, repo.NewTelegramRepo(linkedChatDB(t)), nil, nil, nil, "bot-secret")
+
+	router := gin.New()
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	router.GET("/posts", m.AuthMiddleware, ok)
+	router.POST("/users/api-keys", m.AuthMiddleware, m.RequireSession, ok)
+
+	for _, test := range []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/posts", http.StatusOK},
+		{http.MethodPost, "/users/api-keys", http.StatusForbidden},
+	} {
+		request := httptest.NewRequest(test.method, test.path, nil)
+		request.Header.Set("X-Bot-Token", "bot-secret")
+		request.Header.Set("X-Telegram-Chat-Id", "42")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Errorf("%s %s as the bot = %d, want %d", test.method, test.path, recorder.Code, test.want)
+		}
+	}
+}
+
+func TestStillAuthorizedAfterWorkspaceRemoval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	member := true
+	db := dbtest.New(t, func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*models.WorkspaceMember); ok {
+			if !member {
+				tx.AddError(gorm.ErrRecordNotFound)
+				return
+			}
+			tx.RowsAffected = 1
+		}
+	})
+	m := NewMiddlewareService(nil, nil, nil, repo.NewWorkspaceRepo(db), nil, nil, "")
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Set("user_id", int64(7))
+	ctx.Set("workspace_id", int64(3))
+	for _, test := range []struct {
+		member bool
+		want   bool
+	}{
+		{true, true},
+		{false, false},
+	} {
+		member = test.member
+		got, err := m.StillAuthorized(ctx)
+		if err != nil {
+			t.Fatalf("StillAuthorized: %v", err)
+		}
+		if got != test.want {
+			t.Errorf("StillAuthorized with member = %v is %v, want %v", test.member, got, test.want)
+		}
+	}
+}
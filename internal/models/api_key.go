@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const (
+	ApiKeyAccessRead  = "read"
+	ApiKeyAccessWrite = "write"
+)
+
+// ApiKey is a long-lived credential for scripts and integrations. Only the
+// hash of the key is stored; Prefix is kept so users can tell keys apart.
+// A key with a WorkspaceId only works in that workspace.
+type ApiKey struct {
+	Id          int64      `json:"id" gorm:"primaryKey"`
+	UserId      int64      `json:"user_id" gorm:"not null;index"`
+	User        *User      `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	Name        string     `json:"name" gorm:"not null"`
+	Prefix      string     `json:"prefix" gorm:"not null"`
+	KeyHash     string     `json:"-" gorm:"not null;uniqueIndex"`
+	Access      string     `json:"access" gorm:"not null"`
+	WorkspaceId *int64     `json:"workspace_id"`
+	Workspace   *Workspace `json:"-" gorm:"foreignKey:WorkspaceId;constraint:OnDelete:CASCADE"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (a ApiKey) TableName() string {
+	return "api_keys"
+}
@@ -7,12 +7,34 @@ import (
 )
 
 type UserAuthor struct {
-	UserId    int64          `json:"user_id" gorm:"primaryKey"`
-	Platform  string         `json:"platform" gorm:"primaryKey"`
-	Names     pq.StringArray `json:"names" gorm:"type:text[]"`
-	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UserId      int64          `json:"user_id" gorm:"primaryKey"`
+	WorkspaceId int64          `json:"workspace_id" gorm:"primaryKey;autoIncrement:false;default:0"`
+	Platform    string         `json:"platform" gorm:"primaryKey"`
+	Names       pq.StringArray `json:"names" gorm:"type:text[]"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
 }
 
 func (u UserAuthor) TableName() string {
 	return "user_authors"
 }
+
+// Author is a person whose posts a user has saved. Authors are per user so
+// duplicates can be merged without affecting anyone else; a merged author
+// points at the author it was merged into.
+type Author struct {
+	Id           int64     `json:"id" gorm:"primaryKey"`
+	UserId       int64     `json:"user_id" gorm:"not null;index"`
+	WorkspaceId  int64     `json:"workspace_id" gorm:"not null;default:0;index"`
+	Platform     string    `json:"platform" gorm:"not null"`
+	Handle       string    `json:"handle"`
+	DisplayName  string    `json:"display_name"`
+	ProfileUrl   string    `json:"profile_url"`
+	AvatarUrl    string    `json:"avatar_url"`
+	MergedIntoId *int64    `json:"merged_into_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (a Author) TableName() string {
+	return "authors"
+}
@@ -7,10 +7,11 @@ import (
 )
 
 type UserCategories struct {
-	UserId     int64          `json:"user_id" gorm:"primaryKey"`
-	Platform   string         `json:"platform" gorm:"primaryKey"`
-	Categories pq.StringArray `json:"categories" gorm:"type:text[]"`
-	UpdatedAt  time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	UserId      int64          `json:"user_id" gorm:"primaryKey"`
+	WorkspaceId int64          `json:"workspace_id" gorm:"primaryKey;autoIncrement:false;default:0"`
+	Platform    string         `json:"platform" gorm:"primaryKey"`
+	Categories  pq.StringArray `json:"categories" gorm:"type:text[]"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 func (u UserCategories) TableName() string {
@@ -26,3 +27,22 @@ type AllCategories struct {
 func (a AllCategories) TableName() string {
 	return "all_categories"
 }
+
+// CategoryNode is one node of a user's category tree. Path holds the full
+// "Parent > Child" path so posts, which store the path as their category,
+// can be filtered by subtree with a prefix match.
+type CategoryNode struct {
+	Id          int64     `json:"id" gorm:"primaryKey"`
+	UserId      int64     `json:"user_id" gorm:"not null;uniqueIndex:idx_category_nodes_scope_path"`
+	WorkspaceId int64     `json:"workspace_id" gorm:"not null;default:0;uniqueIndex:idx_category_nodes_scope_path"`
+	ParentId    *int64    `json:"parent_id"`
+	Name        string    `json:"name" gorm:"not null"`
+	Path        string    `json:"path" gorm:"not null;uniqueIndex:idx_category_nodes_scope_path"`
+	Pinned      bool      `json:"pinned" gorm:"default:false"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (c CategoryNode) TableName() string {
+	return "category_nodes"
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Collection is a hand-curated list of posts, as opposed to the AI-assigned
+// tags and categories.
+type Collection struct {
+	Id          int64     `json:"id" gorm:"primaryKey"`
+	UserId      int64     `json:"user_id" gorm:"not null;index"`
+	WorkspaceId int64     `json:"workspace_id" gorm:"not null;default:0;index"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (c Collection) TableName() string {
+	return "collections"
+}
+
+// CollectionItem places a post in a collection. Entries are ordered by
+// Position and can carry a note about why the post is there.
+type CollectionItem struct {
+	CollectionId int64       `json:"collection_id" gorm:"primaryKey;autoIncrement:false"`
+	Collection   *Collection `json:"-" gorm:"foreignKey:CollectionId;constraint:OnDelete:CASCADE"`
+	PostId       int64       `json:"post_id" gorm:"primaryKey;autoIncrement:false;index"`
+	Post         *Post       `json:"post,omitempty" gorm:"foreignKey:PostId;constraint:OnDelete:CASCADE"`
+	Position     int         `json:"position" gorm:"not null"`
+	Note         string      `json:"note"`
+	AddedAt      time.Time   `json:"added_at" gorm:"autoCreateTime"`
+}
+
+func (c CollectionItem) TableName() string {
+	return "collection_items"
+}
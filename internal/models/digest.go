@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DigestSchedule is a user's digest preference. Hour and Weekday are in the
+// schedule's timezone; Weekday (0 = Sunday) only applies to weekly digests.
+type DigestSchedule struct {
+	UserId            int64          `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Enabled           bool           `json:"enabled"`
+	Frequency         string         `json:"frequency" gorm:"not null;default:weekly"`
+	Hour              int            `json:"hour"`
+	Weekday           int            `json:"weekday"`
+	Timezone          string         `json:"timezone" gorm:"not null;default:UTC"`
+	Channels          pq.StringArray `json:"channels" gorm:"type:text[]"`
+	IncludeSummary    bool           `json:"include_summary"`
+	IncludeResurfaced bool           `json:"include_resurfaced"`
+	NextRunAt         time.Time      `json:"next_run_at" gorm:"index"`
+	LastSentAt        *time.Time     `json:"last_sent_at"`
+	CreatedAt         time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (d DigestSchedule) TableName() string {
+	return "digest_schedules"
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Feed publishes the posts matching a filter as RSS, Atom or JSON Feed
+// under a secret token, so it can be read without signing in. Only the
+// hash of the token is stored, so the feed's URLs are shown once, when it
+// is created. An empty filter publishes the whole library.
+type Feed struct {
+	Id          int64  `json:"id" gorm:"primaryKey"`
+	UserId      int64  `json:"user_id" gorm:"not null;index"`
+	WorkspaceId int64  `json:"workspace_id" gorm:"not null;default:0;index"`
+	Name        string `json:"name" gorm:"not null"`
+	TokenHash   string `json:"-" gorm:"not null;uniqueIndex"`
+	Platform    string `json:"platform"`
+	// Tags matches posts with any of the tags.
+	Tags pq.StringArray `json:"tags" gorm:"type:text[]"`
+	// Category matches the category path and all of its descendants.
+	Category     string      `json:"category"`
+	CollectionId *int64      `json:"collection_id"`
+	Collection   *Collection `json:"-" gorm:"foreignKey:CollectionId;constraint:OnDelete:CASCADE"`
+	RevokedAt    *time.Time  `json:"revoked_at"`
+	CreatedAt    time.Time   `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (f Feed) TableName() string {
+	return "feeds"
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// UserIdentity links an account at an external identity provider to a user,
+// so they can sign in through the provider.
+type UserIdentity struct {
+	Id        int64     `json:"id" gorm:"primaryKey"`
+	UserId    int64     `json:"user_id" gorm:"not null;index"`
+	User      *User     `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	Provider  string    `json:"provider" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject   string    `json:"subject" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (u UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OIDCLoginState remembers a sign-in that was started until the provider
+// redirects back. The state is stored hashed; the PKCE verifier and nonce
+// are needed in the clear to finish the exchange.
+type OIDCLoginState struct {
+	StateHash    string    `json:"-" gorm:"primaryKey"`
+	Provider     string    `json:"provider" gorm:"not null"`
+	CodeVerifier string    `json:"-" gorm:"not null"`
+	Nonce        string    `json:"-" gorm:"not null"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (o OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
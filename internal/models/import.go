@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusDone      = "done"
+	ImportStatusCancelled = "cancelled"
+
+	ImportItemPending    = "pending"
+	ImportItemProcessing = "processing"
+	ImportItemImported   = "imported"
+	ImportItemDuplicate  = "duplicate"
+	ImportItemFailed     = "failed"
+	ImportItemCancelled  = "cancelled"
+
+	// ImportFoldersAsCollections files each bookmark into a collection named
+	// after its folder; ImportFoldersAsTags adds the folder names as tags.
+	ImportFoldersAsCollections = "collections"
+	ImportFoldersAsTags        = "tags"
+)
+
+// ImportJob is one uploaded export. Its bookmarks are saved in the
+// background and the counters track how far it got.
+type ImportJob struct {
+	Id          int64      `json:"id" gorm:"primaryKey"`
+	UserId      int64      `json:"user_id" gorm:"not null;index"`
+	WorkspaceId int64      `json:"workspace_id" gorm:"not null;default:0;index"`
+	Format      string     `json:"format" gorm:"not null"`
+	Filename    string     `json:"filename"`
+	Folders     string     `json:"folders" gorm:"not null"`
+	Status      string     `json:"status" gorm:"not null;index"`
+	Total       int        `json:"total"`
+	Imported    int        `json:"imported"`
+	Duplicates  int        `json:"duplicates"`
+	Failed      int        `json:"failed"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+func (i ImportJob) TableName() string {
+	return "import_jobs"
+}
+
+type ImportItem struct {
+	Id       int64          `json:"id" gorm:"primaryKey"`
+	JobId    int64          `json:"job_id" gorm:"not null;index:idx_import_items_job_status"`
+	Job      *ImportJob     `json:"-" gorm:"foreignKey:JobId;constraint:OnDelete:CASCADE"`
+	Position int            `json:"position" gorm:"not null"`
+	Url      string         `json:"url" gorm:"not null"`
+	Title    string         `json:"title"`
+	Tags     pq.StringArray `json:"tags" gorm:"type:text[]"`
+	Folder   string         `json:"folder"`
+	Note     string         `json:"note"`
+	AddedAt  *time.Time     `json:"added_at"`
+	// Snapshot holds the exported post, as JSON, for lynkbin exports.
+	Snapshot  string     `json:"-" gorm:"type:text"`
+	Status    string     `json:"status" gorm:"not null;index:idx_import_items_job_status"`
+	PostId    *int64     `json:"post_id"`
+	Error     string     `json:"error,omitempty"`
+	ClaimedAt *time.Time `json:"-"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (i ImportItem) TableName() string {
+	return "import_items"
+}
@@ -9,14 +9,54 @@ import (
 type Post struct {
 	Id          int64          `json:"id" gorm:"primaryKey"`
 	UserId      int64          `json:"user_id"`
+	WorkspaceId int64          `json:"workspace_id" gorm:"not null;default:0;index"`
 	Data        string         `json:"data"`
 	Platform    string         `json:"platform"`
 	Author      string         `json:"author"`
+	AuthorId    *int64         `json:"author_id"`
 	Category    string         `json:"category"`
 	Topic       string         `json:"topic"`
 	Tags        pq.StringArray `json:"tags" gorm:"type:text[]"`
 	Description string         `json:"description"`
-	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	// Source is the feed a post was saved from, empty for posts saved by
+	// hand.
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+const (
+	PostStatusUnread   = "unread"
+	PostStatusReading  = "reading"
+	PostStatusRead     = "read"
+	PostStatusArchived = "archived"
+)
+
+// ReadingState is where a reader is with a post.
+type ReadingState struct {
+	// Status tracks reading: unread, reading, read or archived.
+	Status   string `json:"status" gorm:"not null;default:unread;index"`
+	Favorite bool   `json:"favorite" gorm:"not null;default:false"`
+	// SnoozedUntil hides the post from the reading list until then.
+	SnoozedUntil *time.Time `json:"snoozed_until"`
+	LastOpenedAt *time.Time `json:"last_opened_at"`
+	// Progress is how far into the post the reader got, in percent.
+	Progress int `json:"progress" gorm:"not null;default:0"`
+}
+
+// PostState is one user's reading state of a post. Workspace members share
+// posts but read them on their own, so the state isn't kept on the post. A
+// post the user has no state for is unread.
+type PostState struct {
+	UserId int64 `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	User   *User `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	PostId int64 `json:"post_id" gorm:"primaryKey;autoIncrement:false;index"`
+	Post   *Post `json:"-" gorm:"foreignKey:PostId;constraint:OnDelete:CASCADE"`
+	ReadingState
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (p PostState) TableName() string {
+	return "post_states"
 }
 
 type CreatePostResponse struct {
@@ -27,3 +67,11 @@ type CreatePostResponse struct {
 func (p Post) TableName() string {
 	return "posts"
 }
+
+// OriginalLink is the URL the post was saved from; notes have none.
+func (p Post) OriginalLink() string {
+	if p.Platform == "notes" {
+		return ""
+	}
+	return p.Data
+}
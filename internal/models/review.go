@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+const (
+	ReviewUseful    = "useful"
+	ReviewNotUseful = "not_useful"
+)
+
+// PostReview is where a post stands in a user's spaced-repetition schedule
+// that brings old saves back for review. Everyone in a workspace has their
+// own. Posts without one have never been reviewed by the user and fall due
+// some time after they were saved.
+type PostReview struct {
+	UserId      int64 `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	User        *User `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	PostId      int64 `json:"post_id" gorm:"primaryKey;autoIncrement:false;index"`
+	Post        *Post `json:"-" gorm:"foreignKey:PostId;constraint:OnDelete:CASCADE"`
+	WorkspaceId int64 `json:"workspace_id" gorm:"not null;default:0;index"`
+	// Repetitions counts the useful reviews in a row and Lapses the not
+	// useful ones.
+	Repetitions    int        `json:"repetitions" gorm:"not null;default:0"`
+	Lapses         int        `json:"lapses" gorm:"not null;default:0"`
+	IntervalDays   int        `json:"interval_days" gorm:"not null;default:0"`
+	EaseFactor     float64    `json:"ease_factor" gorm:"not null;default:2.5"`
+	DueAt          time.Time  `json:"due_at" gorm:"not null;index"`
+	LastFeedback   string     `json:"last_feedback"`
+	LastReviewedAt *time.Time `json:"last_reviewed_at"`
+	// LastSurfacedAt is when the post was last sent out in a digest or chat,
+	// whether or not it got feedback.
+	LastSurfacedAt *time.Time `json:"last_surfaced_at"`
+	// RetiredAt is set once the post has been found not useful often enough
+	// that it is no longer brought back.
+	RetiredAt *time.Time `json:"retired_at"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (r PostReview) TableName() string {
+	return "post_reviews"
+}
+
+// PostReviewFeedback records each answer given in a review.
+type PostReviewFeedback struct {
+	Id          int64  `json:"id" gorm:"primaryKey"`
+	PostId      int64  `json:"post_id" gorm:"not null;index"`
+	Post        *Post  `json:"-" gorm:"foreignKey:PostId;constraint:OnDelete:CASCADE"`
+	UserId      int64  `json:"user_id" gorm:"not null;index"`
+	WorkspaceId int64  `json:"workspace_id" gorm:"not null;default:0"`
+	Feedback    string `json:"feedback" gorm:"not null"`
+	// IntervalDays is the interval the answer scheduled.
+	IntervalDays int       `json:"interval_days"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (f PostReviewFeedback) TableName() string {
+	return "post_review_feedback"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RefreshToken is one link in a session's chain of refresh tokens. Every
+// refresh marks the presented token used and issues the next one in the same
+// session; presenting a used token again revokes the whole session.
+type RefreshToken struct {
+	Id        int64      `json:"id" gorm:"primaryKey"`
+	UserId    int64      `json:"user_id" gorm:"not null;index"`
+	SessionId string     `json:"session_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	UserAgent string     `json:"user_agent"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (r RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
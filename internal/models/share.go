@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ShareLink publishes a post or a collection read-only under an unguessable
+// slug. Exactly one of PostId and CollectionId is set.
+type ShareLink struct {
+	Id           int64       `json:"id" gorm:"primaryKey"`
+	UserId       int64       `json:"user_id" gorm:"not null;index"`
+	WorkspaceId  int64       `json:"workspace_id" gorm:"not null;default:0"`
+	Slug         string      `json:"slug" gorm:"not null;uniqueIndex"`
+	PostId       *int64      `json:"post_id"`
+	Post         *Post       `json:"-" gorm:"foreignKey:PostId;constraint:OnDelete:CASCADE"`
+	CollectionId *int64      `json:"collection_id"`
+	Collection   *Collection `json:"-" gorm:"foreignKey:CollectionId;constraint:OnDelete:CASCADE"`
+	ExpiresAt    *time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time  `json:"revoked_at"`
+	CreatedAt    time.Time   `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (s ShareLink) TableName() string {
+	return "share_links"
+}
+
+func (s ShareLink) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt == nil || s.ExpiresAt.After(now))
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	SubscriptionEntrySaved     = "saved"
+	SubscriptionEntryDuplicate = "duplicate"
+	SubscriptionEntrySkipped   = "skipped"
+	SubscriptionEntryFailed    = "failed"
+)
+
+// Subscription follows an RSS or Atom feed and saves its new items as posts.
+// When Keywords or MatchTags are set only items matching one of them are
+// saved.
+type Subscription struct {
+	Id          int64  `json:"id" gorm:"primaryKey"`
+	UserId      int64  `json:"user_id" gorm:"not null;index"`
+	WorkspaceId int64  `json:"workspace_id" gorm:"not null;default:0;index"`
+	Url         string `json:"url" gorm:"not null"`
+	Title       string `json:"title"`
+	// Keywords match the title or summary of an item, MatchTags the
+	// categories the feed gives it.
+	Keywords  pq.StringArray `json:"keywords" gorm:"type:text[]"`
+	MatchTags pq.StringArray `json:"match_tags" gorm:"type:text[]"`
+	// ETag and LastModified are the validators of the last response, sent
+	// back so an unchanged feed isn't downloaded again.
+	ETag         string    `json:"-"`
+	LastModified string    `json:"-"`
+	NextPollAt   time.Time `json:"next_poll_at" gorm:"not null;index"`
+	// LastPolledAt is when the feed was last read successfully.
+	LastPolledAt *time.Time `json:"last_polled_at"`
+	LastError    string     `json:"last_error"`
+	FailureCount int        `json:"failure_count" gorm:"not null;default:0"`
+	PausedAt     *time.Time `json:"paused_at"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (s Subscription) TableName() string {
+	return "subscriptions"
+}
+
+// SubscriptionEntry remembers an item a subscription has seen, by its
+// canonical URL, so it is only ever saved once.
+type SubscriptionEntry struct {
+	SubscriptionId int64         `json:"subscription_id" gorm:"primaryKey;autoIncrement:false"`
+	Subscription   *Subscription `json:"-" gorm:"foreignKey:SubscriptionId;constraint:OnDelete:CASCADE"`
+	Url            string        `json:"url" gorm:"primaryKey"`
+	Title          string        `json:"title"`
+	Status         string        `json:"status" gorm:"not null"`
+	PostId         *int64        `json:"post_id"`
+	Post           *Post         `json:"-" gorm:"foreignKey:PostId;constraint:OnDelete:SET NULL"`
+	CreatedAt      time.Time     `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+func (s SubscriptionEntry) TableName() string {
+	return "subscription_entries"
+}
@@ -7,11 +7,12 @@ import (
 )
 
 type UserTags struct {
-	UserId    int64          `json:"user_id" gorm:"primaryKey"`
-	Platform  string         `json:"platform" gorm:"primaryKey"`
-	Tags      pq.StringArray `json:"tags" gorm:"type:text[]"`
-	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	UserId      int64          `json:"user_id" gorm:"primaryKey"`
+	WorkspaceId int64          `json:"workspace_id" gorm:"primaryKey;autoIncrement:false;default:0"`
+	Platform    string         `json:"platform" gorm:"primaryKey"`
+	Tags        pq.StringArray `json:"tags" gorm:"type:text[]"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 func (u UserTags) TableName() string {
@@ -27,3 +28,16 @@ type AllTags struct {
 func (a AllTags) TableName() string {
 	return "all_tags"
 }
+
+type TagSynonym struct {
+	UserId      int64     `json:"user_id" gorm:"primaryKey"`
+	WorkspaceId int64     `json:"workspace_id" gorm:"primaryKey;autoIncrement:false;default:0"`
+	Alias       string    `json:"alias" gorm:"primaryKey"`
+	Tag         string    `json:"tag" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (t TagSynonym) TableName() string {
+	return "tag_synonyms"
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// TelegramLink binds a Telegram chat to a user. Requests from the bot are
+// attributed to the user linked to the chat they came from.
+type TelegramLink struct {
+	ChatId    int64     `json:"chat_id" gorm:"primaryKey;autoIncrement:false"`
+	UserId    int64     `json:"user_id" gorm:"not null;index"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (t TelegramLink) TableName() string {
+	return "telegram_links"
+}
+
+// TelegramLinkCode is a short-lived, single-use code a signed-in user sends
+// to the bot to link a chat. Only its hash is stored.
+type TelegramLinkCode struct {
+	Id        int64      `json:"id" gorm:"primaryKey"`
+	UserId    int64      `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (t TelegramLinkCode) TableName() string {
+	return "telegram_link_codes"
+}
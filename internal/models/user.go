@@ -3,17 +3,42 @@ package models
 import "time"
 
 type User struct {
-	Id              *int64    `json:"id" gorm:"primaryKey;autoIncrement;not null"`
-	Name            string    `json:"name"`
-	Email           string    `json:"email" gorm:"unique;not null"`
-	Password        string    `json:"password" gorm:"not null;"`
-	TotalPosts      int64     `json:"total_posts" gorm:"default:0"`
-	TotalCategories int64     `json:"total_categories" gorm:"default:0"`
-	TotalTags       int64     `json:"total_tags" gorm:"default:0"`
-	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	Id              *int64     `json:"id" gorm:"primaryKey;autoIncrement;not null"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email" gorm:"unique;not null"`
+	Password        string     `json:"password" gorm:"not null;"`
+	TotalPosts      int64      `json:"total_posts" gorm:"default:0"`
+	TotalCategories int64      `json:"total_categories" gorm:"default:0"`
+	TotalTags       int64      `json:"total_tags" gorm:"default:0"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// DeletionScheduledAt is set while the user is waiting for their account
+	// to be deleted; signing in again before then cancels it.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at" gorm:"index"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 func (u User) TableName() string {
 	return "users"
 }
+
+const (
+	UserTokenVerifyEmail   = "verify_email"
+	UserTokenResetPassword = "reset_password"
+)
+
+// UserToken records a token emailed to a user, so it can be used only once
+// and so a password reset can void the ones still outstanding.
+type UserToken struct {
+	Id        string     `json:"id" gorm:"primaryKey"`
+	UserId    int64      `json:"user_id" gorm:"not null;index"`
+	User      *User      `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	Purpose   string     `json:"purpose" gorm:"not null"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (u UserToken) TableName() string {
+	return "user_tokens"
+}
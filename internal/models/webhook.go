@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook posts the library's events to a URL. Payloads are signed with
+// Secret so the receiver can check they came from us.
+type Webhook struct {
+	Id          int64          `json:"id" gorm:"primaryKey"`
+	UserId      int64          `json:"user_id" gorm:"not null;index"`
+	WorkspaceId int64          `json:"workspace_id" gorm:"not null;default:0;index"`
+	Url         string         `json:"url" gorm:"not null"`
+	Description string         `json:"description"`
+	Events      pq.StringArray `json:"events" gorm:"type:text[];not null"`
+	Secret      string         `json:"-" gorm:"not null"`
+	DisabledAt  *time.Time     `json:"disabled_at"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (w Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery is one event queued for a webhook. It is retried with
+// backoff until it is delivered or runs out of attempts, and is kept
+// afterwards as the webhook's delivery log.
+type WebhookDelivery struct {
+	Id            int64      `json:"id" gorm:"primaryKey"`
+	WebhookId     int64      `json:"webhook_id" gorm:"not null;index"`
+	Webhook       *Webhook   `json:"-" gorm:"foreignKey:WebhookId;constraint:OnDelete:CASCADE"`
+	EventId       string     `json:"event_id" gorm:"not null"`
+	Event         string     `json:"event" gorm:"not null"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"`
+	Status        string     `json:"status" gorm:"not null"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null"`
+	StatusCode    int        `json:"status_code"`
+	Error         string     `json:"error"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (w WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
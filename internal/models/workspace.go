@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+const (
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleEditor = "editor"
+	WorkspaceRoleViewer = "viewer"
+)
+
+// Workspace is a library shared by its members. Posts, tags, categories and
+// authors saved in a workspace carry its id; a user's personal library uses
+// workspace id 0.
+type Workspace struct {
+	Id        int64     `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (w Workspace) TableName() string {
+	return "workspaces"
+}
+
+type WorkspaceMember struct {
+	WorkspaceId int64      `json:"workspace_id" gorm:"primaryKey;autoIncrement:false"`
+	Workspace   *Workspace `json:"-" gorm:"foreignKey:WorkspaceId;constraint:OnDelete:CASCADE"`
+	UserId      int64      `json:"user_id" gorm:"primaryKey;autoIncrement:false;index"`
+	User        *User      `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	Role        string     `json:"role" gorm:"not null"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (w WorkspaceMember) TableName() string {
+	return "workspace_members"
+}
+
+// WorkspaceInvite lets the holder of the token join the workspace with the
+// given role. The token is handed over out of band and only its hash is
+// stored.
+type WorkspaceInvite struct {
+	Id          int64      `json:"id" gorm:"primaryKey"`
+	WorkspaceId int64      `json:"workspace_id" gorm:"not null;index"`
+	Workspace   *Workspace `json:"-" gorm:"foreignKey:WorkspaceId;constraint:OnDelete:CASCADE"`
+	Email       string     `json:"email" gorm:"not null"`
+	Role        string     `json:"role" gorm:"not null"`
+	TokenHash   string     `json:"-" gorm:"not null;uniqueIndex"`
+	InvitedBy   int64      `json:"invited_by"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (w WorkspaceInvite) TableName() string {
+	return "workspace_invites"
+}
@@ -0,0 +1,402 @@
+package repo
+
+import (
+	"errors"
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AccountRepo struct {
+	DB *gorm.DB
+}
+
+func NewAccountRepo(db *gorm.DB) *AccountRepo {
+	return &AccountRepo{DB: db}
+}
+
+// AccountExport is everything a user keeps in their personal library, plus
+// the posts they saved to workspaces.
+type AccountExport struct {
+	User          models.User
+	Posts         []models.Post
+	PostStates    []models.PostState
+	Tags          []models.UserTags
+	TagSynonyms   []models.TagSynonym
+	Categories    []models.UserCategories
+	CategoryNodes []models.CategoryNode
+	UserAuthors   []models.UserAuthor
+	Authors       []models.Author
+	Collections   []CollectionExport
+	Identities    []models.UserIdentity
+	Imports       []ImportExport
+	Feeds         []models.Feed
+	Subscriptions []SubscriptionExport
+	Webhooks      []WebhookExport
+	Reviews       []models.PostReview
+	ReviewAnswers []models.PostReviewFeedback
+}
+
+type CollectionExport struct {
+	models.Collection
+	Items []models.CollectionItem `json:"items"`
+}
+
+type SubscriptionExport struct {
+	models.Subscription
+	Entries []models.SubscriptionEntry `json:"entries"`
+}
+
+type WebhookExport struct {
+	models.Webhook
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+}
+
+type ImportExport struct {
+	models.ImportJob
+	Items []models.ImportItem `json:"items"`
+}
+
+func (r *AccountRepo) GetAccountExport(userId int64) (AccountExport, error) {
+	var export AccountExport
+	err := r.DB.Where("id = ?", userId).First(&export.User).Error
+	if err != nil {
+		return export, err
+	}
+	err = r.DB.Where("user_id = ?", userId).Order("created_at, id").Find(&export.Posts).Error
+	if err != nil {
+		return export, err
+	}
+	err = r.DB.Where("user_id = ?", userId).Order("post_id").Find(&export.PostStates).Error
+	if err != nil {
+		return export, err
+	}
+
+	personal := PersonalScope(userId)
+	for _, query := range []struct {
+		dest  any
+		order string
+	}{
+		{&export.Tags, "tag"},
+		{&export.TagSynonyms, "alias"},
+		{&export.Categories, "category"},
+		{&export.CategoryNodes, "path"},
+		{&export.UserAuthors, "author"},
+		{&export.Authors, "id"},
+	} {
+		err = personal.Apply(r.DB, "").Order(query.order).Find(query.dest).Error
+		if err != nil {
+			return export, err
+		}
+	}
+
+	var collections []models.Collection
+	err = r.DB.Where("user_id = ?", userId).Order("created_at, id").Find(&collections).Error
+	if err != nil {
+		return export, err
+	}
+	collectionIds := make([]int64, 0, len(collections))
+	for _, collection := range collections {
+		collectionIds = append(collectionIds, collection.Id)
+	}
+	var items []models.CollectionItem
+	if len(collectionIds) > 0 {
+		err = r.DB.Where("collection_id IN ?", collectionIds).Order("collection_id, position").Find(&items).Error
+		if err != nil {
+			return export, err
+		}
+	}
+	itemsByCollection := map[int64][]models.CollectionItem{}
+	for _, item := range items {
+		itemsByCollection[item.CollectionId] = append(itemsByCollection[item.CollectionId], item)
+	}
+	for _, collection := range collections {
+		export.Collections = append(export.Collections, CollectionExport{
+			Collection: collection,
+			Items:      itemsByCollection[collection.Id],
+		})
+	}
+
+	err = r.DB.Where("user_id = ?", userId).Order("created_at").Find(&export.Identities).Error
+	if err != nil {
+		return export, err
+	}
+
+	export.Imports, err = r.getImportExports(userId)
+	if err != nil {
+		return export, err
+	}
+
+	// Feed tokens aren't exported; they are secrets, not data.
+	err = r.DB.Where("user_id = ?", userId).Order("created_at, id").Find(&export.Feeds).Error
+	if err != nil {
+		return export, err
+	}
+
+	export.Subscriptions, err = r.getSubscriptionExports(userId)
+	if err != nil {
+		return export, err
+	}
+
+	export.Webhooks, err = r.getWebhookExports(userId)
+	if err != nil {
+		return export, err
+	}
+
+	err = r.DB.Where("user_id = ?", userId).Order("post_id").Find(&export.Reviews).Error
+	if err != nil {
+		return export, err
+	}
+	err = r.DB.Where("user_id = ?", userId).Order("created_at, id").Find(&export.ReviewAnswers).Error
+	return export, err
+}
+
+func (r *AccountRepo) getImportExports(userId int64) ([]ImportExport, error) {
+	var jobs []models.ImportJob
+	err := r.DB.Where("user_id = ?", userId).Order("created_at, id").Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	jobIds := make([]int64, 0, len(jobs))
+	for _, job := range jobs {
+		jobIds = append(jobIds, job.Id)
+	}
+	var items []models.ImportItem
+	if len(jobIds) > 0 {
+		err = r.DB.Where("job_id IN ?", jobIds).Order("job_id, position").Find(&items).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	itemsByJob := map[int64][]models.ImportItem{}
+	for _, item := range items {
+		itemsByJob[item.JobId] = append(itemsByJob[item.JobId], item)
+	}
+	imports := make([]ImportExport, 0, len(jobs))
+	for _, job := range jobs {
+		imports = append(imports, ImportExport{ImportJob: job, Items: itemsByJob[job.Id]})
+	}
+	return imports, nil
+}
+
+func (r *AccountRepo) getSubscriptionExports(userId int64) ([]SubscriptionExport, error) {
+	var subscriptions []models.Subscription
+	err := r.DB.Where("user_id = ?", userId).Order("created_at, id").Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	subscriptionIds := make([]int64, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionIds = append(subscriptionIds, subscription.Id)
+	}
+	var entries []models.SubscriptionEntry
+	if len(subscriptionIds) > 0 {
+		err = r.DB.Where("subscription_id IN ?", subscriptionIds).Order("subscription_id, created_at").Find(&entries).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	entriesBySubscription := map[int64][]models.SubscriptionEntry{}
+	for _, entry := range entries {
+		entriesBySubscription[entry.SubscriptionId] = append(entriesBySubscription[entry.SubscriptionId], entry)
+	}
+	exports := make([]SubscriptionExport, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		exports = append(exports, SubscriptionExport{Subscription: subscription, Entries: entriesBySubscription[subscription.Id]})
+	}
+	return exports, nil
+}
+
+// getWebhookExports returns the user's webhooks with their delivery logs.
+// Signing secrets are left out like feed tokens.
+func (r *AccountRepo) getWebhookExports(userId int64) ([]WebhookExport, error) {
+	var webhooks []models.Webhook
+	err := r.DB.Where("user_id = ?", userId).Order("created_at, id").Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+	webhookIds := make([]int64, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		webhookIds = append(webhookIds, webhook.Id)
+	}
+	var deliveries []models.WebhookDelivery
+	if len(webhookIds) > 0 {
+		err = r.DB.Where("webhook_id IN ?", webhookIds).Order("webhook_id, created_at, id").Find(&deliveries).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	deliveriesByWebhook := map[int64][]models.WebhookDelivery{}
+	for _, delivery := range deliveries {
+		deliveriesByWebhook[delivery.WebhookId] = append(deliveriesByWebhook[delivery.WebhookId], delivery)
+	}
+	exports := make([]WebhookExport, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		exports = append(exports, WebhookExport{Webhook: webhook, Deliveries: deliveriesByWebhook[webhook.Id]})
+	}
+	return exports, nil
+}
+
+// ScheduleDeletion marks the account for deletion at the given time and
+// signs it out everywhere. API keys are revoked for good.
+func (r *AccountRepo) ScheduleDeletion(userId int64, at time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", userId).Update("deletion_scheduled_at", at).Error
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		err = tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userId).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.ApiKey{}).
+			Where("user_id = ? AND revoked_at IS NULL", userId).
+			Update("revoked_at", now).Error
+	})
+}
+
+// CancelDeletion keeps an account that was scheduled for deletion. It
+// reports whether there was anything to cancel.
+func (r *AccountRepo) CancelDeletion(userId int64) (bool, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL", userId).
+		Update("deletion_scheduled_at", nil)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *AccountRepo) GetAccountsDueForDeletion(now time.Time, limit int) ([]int64, error) {
+	var userIds []int64
+	err := r.DB.Model(&models.User{}).
+		Where("deletion_scheduled_at <= ?", now).
+		Order("deletion_scheduled_at").Limit(limit).
+		Pluck("id", &userIds).Error
+	if err != nil {
+		return nil, err
+	}
+	return userIds, nil
+}
+
+// libraryTables hold what a user adds to a library. The rows in their
+// personal library go with them; the ones in a workspace stay with the team
+// without their user id.
+var libraryTables = []any{
+	&models.Post{},
+	&models.Collection{},
+	&models.ShareLink{},
+	&models.Feed{},
+	&models.Subscription{},
+	&models.Webhook{},
+	&models.ImportJob{},
+}
+
+// personalTables hold rows keyed by user_id that only ever belong to the
+// user, such as their reading state and review schedule, including those of
+// workspace posts.
+// Workspace rows of the library-wide tables (authors, tags, categories) are
+// stored with user_id 0 and aren't touched. Identities, API keys, tokens and
+// workspace memberships are cleared by the database through their foreign
+// key to users.
+var personalTables = []any{
+	&models.PostState{},
+	&models.PostReview{},
+	&models.PostReviewFeedback{},
+	&models.UserAuthor{},
+	&models.Author{},
+	&models.UserTags{},
+	&models.TagSynonym{},
+	&models.UserCategories{},
+	&models.CategoryNode{},
+	&models.RefreshToken{},
+	&models.TelegramLink{},
+	&models.TelegramLinkCode{},
+	&models.DigestSchedule{},
+}
+
+// DeleteAccount removes a user whose deletion is due, with everything they
+// own, in one transaction. Workspaces only they belong to are deleted; in
+// shared ones the longest-standing member takes over if the user was the
+// last owner, and what they added there stays with the team.
+func (r *AccountRepo) DeleteAccount(userId int64, now time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the user so a login can't cancel the deletion halfway.
+		var user models.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deletion_scheduled_at <= ?", userId, now).
+			First(&user).Error
+		if err != nil {
+			return err
+		}
+
+		var memberships []models.WorkspaceMember
+		err = tx.Where("user_id = ?", userId).Find(&memberships).Error
+		if err != nil {
+			return err
+		}
+		for _, membership := range memberships {
+			err = handOverWorkspace(tx, membership)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, table := range libraryTables {
+			err = tx.Model(table).
+				Where("user_id = ? AND workspace_id <> 0", userId).
+				Update("user_id", 0).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Where("user_id = ? AND workspace_id = 0", userId).Delete(table).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, table := range personalTables {
+			err = tx.Where("user_id = ?", userId).Delete(table).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&user).Error
+	})
+}
+
+// handOverWorkspace makes sure a workspace outlives a member who is leaving
+// with an owner, or deletes it when nobody else is left.
+func handOverWorkspace(tx *gorm.DB, membership models.WorkspaceMember) error {
+	var next models.WorkspaceMember
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("workspace_id = ? AND user_id <> ?", membership.WorkspaceId, membership.UserId).
+		Order("created_at").First(&next).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		for _, table := range workspaceTables {
+			err = tx.Where("workspace_id = ?", membership.WorkspaceId).Delete(table).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", membership.WorkspaceId).Delete(&models.Workspace{}).Error
+	}
+	if err != nil {
+		return err
+	}
+	if membership.Role != models.WorkspaceRoleOwner {
+		return nil
+	}
+
+	var owners int64
+	err = tx.Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND user_id <> ? AND role = ?", membership.WorkspaceId, membership.UserId, models.WorkspaceRoleOwner).
+		Count(&owners).Error
+	if err != nil || owners > 0 {
+		return err
+	}
+	return tx.Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND user_id = ?", next.WorkspaceId, next.UserId).
+		Update("role", models.WorkspaceRoleOwner).Error
+}
@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PlatformCount struct {
+	Platform  string `json:"platform"`
+	PostCount int64  `json:"post_count"`
+}
+
+// PostAggregate summarises how a tag, category or author is used across all
+// of a library's posts regardless of platform.
+type PostAggregate struct {
+	Name        string          `json:"name"`
+	PostCount   int64           `json:"post_count"`
+	FirstUsedAt time.Time       `json:"first_used_at"`
+	LastUsedAt  time.Time       `json:"last_used_at"`
+	Platforms   []PlatformCount `json:"platforms"`
+}
+
+type AggregateQuery struct {
+	Scope    Scope
+	Platform string
+	// Search matches names case-insensitively, prefix matches sort first.
+	Search string
+	// Sort is one of "count" (default), "recent" or "name".
+	Sort  string
+	Limit int
+}
+
+type aggregateRow struct {
+	Name        string
+	Platform    string
+	PostCount   int64
+	FirstUsedAt time.Time
+	LastUsedAt  time.Time
+}
+
+// aggregatePosts groups the scope's posts by nameExpr (optionally over a
+// lateral join such as unnest(tags)) and folds the per-platform rows into one
+// aggregate per name.
+func aggregatePosts(db *gorm.DB, nameExpr string, join string, query AggregateQuery) ([]PostAggregate, error) {
+	condition, args := query.Scope.Condition("posts")
+	sql := fmt.Sprintf(`SELECT %[1]s AS name, posts.platform AS platform, COUNT(*) AS post_count,
+		MIN(posts.created_at) AS first_used_at, MAX(posts.created_at) AS last_used_at
+		FROM posts %[2]s
+		WHERE %[3]s AND %[1]s <> ''`, nameExpr, join, condition)
+	if query.Platform != "" {
+		sql += " AND posts.platform = ?"
+		args = append(args, query.Platform)
+	}
+	if query.Search != "" {
+		sql += fmt.Sprintf(" AND %s ILIKE ?", nameExpr)
+		args = append(args, "%"+escapeLike(query.Search)+"%")
+	}
+	sql += " GROUP BY 1, 2"
+
+	var rows []aggregateRow
+	err := db.Raw(sql, args...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]*PostAggregate{}
+	aggregates := []*PostAggregate{}
+	for _, row := range rows {
+		aggregate, ok := byName[row.Name]
+		if !ok {
+			aggregate = &PostAggregate{Name: row.Name, FirstUsedAt: row.FirstUsedAt, Platforms: []PlatformCount{}}
+			byName[row.Name] = aggregate
+			aggregates = append(aggregates, aggregate)
+		}
+		aggregate.PostCount += row.PostCount
+		aggregate.Platforms = append(aggregate.Platforms, PlatformCount{Platform: row.Platform, PostCount: row.PostCount})
+		if row.FirstUsedAt.Before(aggregate.FirstUsedAt) {
+			aggregate.FirstUsedAt = row.FirstUsedAt
+		}
+		if row.LastUsedAt.After(aggregate.LastUsedAt) {
+			aggregate.LastUsedAt = row.LastUsedAt
+		}
+	}
+
+	result := make([]PostAggregate, 0, len(aggregates))
+	for _, aggregate := range aggregates {
+		sort.Slice(aggregate.Platforms, func(i, j int) bool {
+			return aggregate.Platforms[i].PostCount > aggregate.Platforms[j].PostCount
+		})
+		result = append(result, *aggregate)
+	}
+	sortAggregates(result, query)
+
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+func sortAggregates(aggregates []PostAggregate, query AggregateQuery) {
+	search := strings.ToLower(query.Search)
+	sort.SliceStable(aggregates, func(i, j int) bool {
+		a, b := aggregates[i], aggregates[j]
+		// For autocomplete, names starting with the search term come first.
+		if search != "" {
+			aPrefix := strings.HasPrefix(strings.ToLower(a.Name), search)
+			bPrefix := strings.HasPrefix(strings.ToLower(b.Name), search)
+			if aPrefix != bPrefix {
+				return aPrefix
+			}
+		}
+		switch query.Sort {
+		case "recent":
+			if !a.LastUsedAt.Equal(b.LastUsedAt) {
+				return a.LastUsedAt.After(b.LastUsedAt)
+			}
+		case "name":
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		default:
+			if a.PostCount != b.PostCount {
+				return a.PostCount > b.PostCount
+			}
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyTouchInterval limits how often last_used_at is written, so a busy
+// script doesn't turn every request into an update.
+const apiKeyTouchInterval = time.Minute
+
+type ApiKeyRepo struct {
+	DB *gorm.DB
+}
+
+func NewApiKeyRepo(db *gorm.DB) *ApiKeyRepo {
+	return &ApiKeyRepo{DB: db}
+}
+
+func (r *ApiKeyRepo) CreateApiKey(key *models.ApiKey) error {
+	return r.DB.Create(key).Error
+}
+
+func (r *ApiKeyRepo) GetApiKeys(userId int64) ([]models.ApiKey, error) {
+	var keys []models.ApiKey
+	err := r.DB.Where("user_id = ?", userId).Order("created_at DESC").Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *ApiKeyRepo) GetActiveApiKey(keyHash string) (models.ApiKey, error) {
+	var key models.ApiKey
+	err := r.DB.Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&key).Error
+	return key, err
+}
+
+// IsApiKeyActive reports whether the key hasn't been revoked since it was
+// used to authenticate.
+func (r *ApiKeyRepo) IsApiKeyActive(id int64) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.ApiKey{}).Where("id = ? AND revoked_at IS NULL", id).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *ApiKeyRepo) TouchApiKey(id int64, usedAt time.Time) error {
+	return r.DB.Model(&models.ApiKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, usedAt.Add(-apiKeyTouchInterval)).
+		Update("last_used_at", usedAt).Error
+}
+
+func (r *ApiKeyRepo) RevokeApiKey(userId int64, id int64) (bool, error) {
+	result := r.DB.Model(&models.ApiKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,268 @@
+package repo
+
+import (
+	"errors"
+	"module/lynkbin/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AuthorRepo struct {
+	DB *gorm.DB
+}
+
+func NewAuthorRepo(db *gorm.DB) *AuthorRepo {
+	return &AuthorRepo{DB: db}
+}
+
+type AuthorProfile struct {
+	models.Author
+	PostCount int64 `json:"post_count"`
+}
+
+type NameCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+func (r *AuthorRepo) GetAuthorAggregates(query AggregateQuery) ([]PostAggregate, error) {
+	return aggregatePosts(r.DB, "posts.author", "", query)
+}
+
+func (r *AuthorRepo) GetAuthor(scope Scope, id int64) (models.Author, error) {
+	var author models.Author
+	err := scope.Apply(r.DB, "").Where("id = ?", id).First(&author).Error
+	return author, err
+}
+
+// GetCanonicalAuthor follows merges so callers always land on the author the
+// duplicates were merged into.
+func (r *AuthorRepo) GetCanonicalAuthor(scope Scope, id int64) (models.Author, error) {
+	author, err := r.GetAuthor(scope, id)
+	for hops := 0; err == nil && author.MergedIntoId != nil && hops < 10; hops++ {
+		author, err = r.GetAuthor(scope, *author.MergedIntoId)
+	}
+	return author, err
+}
+
+// ResolveAuthor finds the author matching the scraped candidate or creates
+// it. Handles are matched first since they are stable; display names are
+// only used for authors we never saw a handle for. Known fields are refreshed
+// with the latest scrape.
+func (r *AuthorRepo) ResolveAuthor(candidate models.Author) (models.Author, error) {
+	if candidate.Handle == "" && candidate.DisplayName == "" {
+		return models.Author{}, errors.New("author has no handle or display name")
+	}
+
+	var author models.Author
+	err := gorm.ErrRecordNotFound
+	if candidate.Handle != "" {
+		err = r.DB.Where("user_id = ? AND workspace_id = ? AND platform = ? AND LOWER(handle) = LOWER(?)", candidate.UserId, candidate.WorkspaceId, candidate.Platform, candidate.Handle).
+			Order("merged_into_id NULLS FIRST").First(&author).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Author{}, err
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) && candidate.DisplayName != "" {
+		query := r.DB.Where("user_id = ? AND workspace_id = ? AND platform = ? AND LOWER(display_name) = LOWER(?)", candidate.UserId, candidate.WorkspaceId, candidate.Platform, candidate.DisplayName)
+		if candidate.Handle != "" {
+			query = query.Where("handle = ''")
+		}
+		err = query.Order("merged_into_id NULLS FIRST").First(&author).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Author{}, err
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = r.DB.Create(&candidate).Error
+		return candidate, err
+	}
+
+	if author.MergedIntoId != nil {
+		return r.GetCanonicalAuthor(Scope{UserId: author.UserId, WorkspaceId: author.WorkspaceId}, *author.MergedIntoId)
+	}
+
+	updates := map[string]any{}
+	if candidate.Handle != "" && author.Handle == "" {
+		author.Handle = candidate.Handle
+		updates["handle"] = candidate.Handle
+	}
+	if candidate.DisplayName != "" && candidate.DisplayName != author.DisplayName {
+		author.DisplayName = candidate.DisplayName
+		updates["display_name"] = candidate.DisplayName
+	}
+	if candidate.ProfileUrl != "" && candidate.ProfileUrl != author.ProfileUrl {
+		author.ProfileUrl = candidate.ProfileUrl
+		updates["profile_url"] = candidate.ProfileUrl
+	}
+	if candidate.AvatarUrl != "" && candidate.AvatarUrl != author.AvatarUrl {
+		author.AvatarUrl = candidate.AvatarUrl
+		updates["avatar_url"] = candidate.AvatarUrl
+	}
+	if len(updates) > 0 {
+		err = r.DB.Model(&author).Updates(updates).Error
+		if err != nil {
+			return models.Author{}, err
+		}
+	}
+	// Keep the denormalized author string on posts in step with renames.
+	if _, renamed := updates["display_name"]; renamed {
+		err = r.DB.Model(&models.Post{}).Where("author_id = ?", author.Id).Update("author", author.DisplayName).Error
+		if err != nil {
+			return models.Author{}, err
+		}
+	}
+	return author, nil
+}
+
+// BackfillAuthors links posts saved before authors were entities to an
+// author resolved from their author string.
+func (r *AuthorRepo) BackfillAuthors(scope Scope) error {
+	var rows []struct {
+		Platform string
+		Author   string
+	}
+	err := scope.Apply(r.DB.Model(&models.Post{}), "").
+		Distinct("platform", "author").
+		Where("author_id IS NULL AND author <> ''").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		author, err := r.ResolveAuthor(models.Author{
+			UserId:      scope.OwnerId(),
+			WorkspaceId: scope.WorkspaceId,
+			Platform:    row.Platform,
+			DisplayName: row.Author,
+		})
+		if err != nil {
+			return err
+		}
+		err = scope.Apply(r.DB.Model(&models.Post{}), "").
+			Where("platform = ? AND author = ? AND author_id IS NULL", row.Platform, row.Author).
+			Update("author_id", author.Id).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *AuthorRepo) GetAuthorProfiles(scope Scope, platform string) ([]AuthorProfile, error) {
+	var profiles []AuthorProfile
+	query := r.DB.Model(&models.Author{}).
+		Select("authors.*, COUNT(posts.id) AS post_count").
+		Joins("LEFT JOIN posts ON posts.author_id = authors.id").
+		Where("authors.merged_into_id IS NULL")
+	query = scope.Apply(query, "authors")
+	if platform != "" {
+		query = query.Where("authors.platform = ?", platform)
+	}
+	err := query.Group("authors.id").Order("post_count DESC, authors.display_name").Scan(&profiles).Error
+	if err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (r *AuthorRepo) GetMergedAuthors(scope Scope, id int64) ([]models.Author, error) {
+	var authors []models.Author
+	err := scope.Apply(r.DB, "").Where("merged_into_id = ?", id).Find(&authors).Error
+	if err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
+func (r *AuthorRepo) GetAuthorPosts(scope Scope, authorId int64) ([]models.Post, error) {
+	var posts []models.Post
+	err := scope.Apply(r.DB, "").Where("author_id = ?", authorId).Order("created_at DESC").Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r *AuthorRepo) GetAuthorTagStats(scope Scope, authorId int64) ([]NameCount, error) {
+	var stats []NameCount
+	condition, args := scope.Condition("posts")
+	err := r.DB.Raw(`SELECT t.tag AS name, COUNT(*) AS count
+		FROM posts CROSS JOIN LATERAL unnest(posts.tags) AS t(tag)
+		WHERE `+condition+` AND posts.author_id = ?
+		GROUP BY t.tag
+		ORDER BY count DESC, t.tag`, append(args, authorId)...).Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (r *AuthorRepo) GetAuthorCategoryStats(scope Scope, authorId int64) ([]NameCount, error) {
+	var stats []NameCount
+	err := scope.Apply(r.DB.Model(&models.Post{}), "").
+		Select("category AS name, COUNT(*) AS count").
+		Where("author_id = ? AND category <> ''", authorId).
+		Group("category").
+		Order("count DESC, category").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// MergeAuthors folds the source authors into the target: their posts move to
+// the target and the sources (and anything already merged into them) point
+// at the target from now on, so future scrapes resolve to it too. The moved
+// posts are returned.
+func (r *AuthorRepo) MergeAuthors(scope Scope, sourceIds []int64, target models.Author) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var sources []models.Author
+		err := scope.Apply(tx, "").Where("id IN ?", sourceIds).Find(&sources).Error
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]any{}
+		for _, source := range sources {
+			if target.Handle == "" && source.Handle != "" {
+				target.Handle = source.Handle
+				updates["handle"] = source.Handle
+			}
+			if target.ProfileUrl == "" && source.ProfileUrl != "" {
+				target.ProfileUrl = source.ProfileUrl
+				updates["profile_url"] = source.ProfileUrl
+			}
+			if target.AvatarUrl == "" && source.AvatarUrl != "" {
+				target.AvatarUrl = source.AvatarUrl
+				updates["avatar_url"] = source.AvatarUrl
+			}
+		}
+		if len(updates) > 0 {
+			err = tx.Model(&target).Updates(updates).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err = scope.Apply(tx.Model(&models.Author{}), "").
+			Where("id IN ? OR merged_into_id IN ?", sourceIds, sourceIds).
+			Update("merged_into_id", target.Id).Error
+		if err != nil {
+			return err
+		}
+
+		return scope.Apply(tx.Model(&posts), "").
+			Clauses(clause.Returning{}).
+			Where("author_id IN ?", sourceIds).
+			Updates(map[string]any{"author_id": target.Id, "author": target.DisplayName}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
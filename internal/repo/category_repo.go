@@ -0,0 +1,131 @@
+package repo
+
+import (
+	"errors"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/utilities"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type CategoryRepo struct {
+	DB *gorm.DB
+}
+
+func NewCategoryRepo(db *gorm.DB) *CategoryRepo {
+	return &CategoryRepo{DB: db}
+}
+
+type CategoryPostCount struct {
+	Category string `json:"category"`
+	Count    int64  `json:"count"`
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// categorySubtreeCondition matches a category path and all of its descendants.
+func categorySubtreeCondition(column string, path string) (string, []any) {
+	return column + " = ? OR " + column + " LIKE ?", []any{path, escapeLike(path+utilities.CategorySeparator) + "%"}
+}
+
+func (r *CategoryRepo) GetCategoryNodes(scope Scope) ([]models.CategoryNode, error) {
+	var nodes []models.CategoryNode
+	err := scope.Apply(r.DB, "").Order("path").Find(&nodes).Error
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func (r *CategoryRepo) GetCategoryNode(scope Scope, id int64) (models.CategoryNode, error) {
+	var node models.CategoryNode
+	err := scope.Apply(r.DB, "").Where("id = ?", id).First(&node).Error
+	return node, err
+}
+
+func (r *CategoryRepo) GetPinnedCategoryPaths(scope Scope) ([]string, error) {
+	var paths []string
+	err := scope.Apply(r.DB.Model(&models.CategoryNode{}), "").Where("pinned = ?", true).Order("path").Pluck("path", &paths).Error
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// EnsureCategoryPath creates any missing nodes along the path and returns the
+// leaf. Nodes that already exist keep their pinned flag unless pinned is true.
+func (r *CategoryRepo) EnsureCategoryPath(scope Scope, path string, pinned bool) (models.CategoryNode, error) {
+	segments := utilities.SplitCategoryPath(path)
+	if len(segments) == 0 {
+		return models.CategoryNode{}, errors.New("empty category path")
+	}
+
+	var leaf models.CategoryNode
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var parentId *int64
+		for i := range segments {
+			nodePath := utilities.JoinCategoryPath(segments[:i+1])
+			var node models.CategoryNode
+			err := scope.Apply(tx, "").Where("LOWER(path) = LOWER(?)", nodePath).First(&node).Error
+			if err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				node = models.CategoryNode{
+					UserId:      scope.OwnerId(),
+					WorkspaceId: scope.WorkspaceId,
+					ParentId:    parentId,
+					Name:        segments[i],
+					Path:        nodePath,
+					Pinned:      pinned && i == len(segments)-1,
+				}
+				err = tx.Create(&node).Error
+				if err != nil {
+					return err
+				}
+			} else if pinned && i == len(segments)-1 && !node.Pinned {
+				node.Pinned = true
+				err = tx.Save(&node).Error
+				if err != nil {
+					return err
+				}
+			}
+			id := node.Id
+			parentId = &id
+			leaf = node
+		}
+		return nil
+	})
+	return leaf, err
+}
+
+func (r *CategoryRepo) SetCategoryPinned(scope Scope, id int64, pinned bool) error {
+	return scope.Apply(r.DB.Model(&models.CategoryNode{}), "").Where("id = ?", id).Update("pinned", pinned).Error
+}
+
+// DeleteCategoryNode removes the node and its whole subtree. Posts keep their
+// category string so nothing is lost; the node is recreated on the next save.
+func (r *CategoryRepo) DeleteCategoryNode(scope Scope, node models.CategoryNode) error {
+	condition, args := categorySubtreeCondition("path", node.Path)
+	return scope.Apply(r.DB, "").Where(condition, args...).Delete(&models.CategoryNode{}).Error
+}
+
+func (r *CategoryRepo) GetCategoryPostCounts(scope Scope) ([]CategoryPostCount, error) {
+	var counts []CategoryPostCount
+	err := scope.Apply(r.DB.Model(&models.Post{}), "").
+		Select("category, COUNT(*) AS count").
+		Where("category <> ''").
+		Group("category").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *CategoryRepo) GetCategoryAggregates(query AggregateQuery) ([]PostAggregate, error) {
+	return aggregatePosts(r.DB, "posts.category", "", query)
+}
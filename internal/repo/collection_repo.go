@@ -0,0 +1,211 @@
+package repo
+
+import (
+	"errors"
+	"module/lynkbin/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPostNotFound         = errors.New("post not found")
+	ErrAlreadyInCollection  = errors.New("post is already in the collection")
+	ErrInvalidPostOrder     = errors.New("post order must list every post in the collection exactly once")
+	ErrCollectionItemAbsent = errors.New("post is not in the collection")
+)
+
+type CollectionRepo struct {
+	DB *gorm.DB
+}
+
+func NewCollectionRepo(db *gorm.DB) *CollectionRepo {
+	return &CollectionRepo{DB: db}
+}
+
+type CollectionSummary struct {
+	models.Collection
+	PostCount int64 `json:"post_count"`
+}
+
+func (r *CollectionRepo) GetCollections(scope Scope) ([]CollectionSummary, error) {
+	var collections []CollectionSummary
+	query := r.DB.Model(&models.Collection{}).
+		Select("collections.*, COUNT(collection_items.post_id) AS post_count").
+		Joins("LEFT JOIN collection_items ON collection_items.collection_id = collections.id")
+	err := scope.Apply(query, "collections").
+		Group("collections.id").
+		Order("collections.name").
+		Scan(&collections).Error
+	if err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+func (r *CollectionRepo) GetCollection(scope Scope, id int64) (models.Collection, error) {
+	var collection models.Collection
+	err := scope.Apply(r.DB, "").Where("id = ?", id).First(&collection).Error
+	return collection, err
+}
+
+func (r *CollectionRepo) GetCollectionByName(scope Scope, name string) (models.Collection, error) {
+	var collection models.Collection
+	err := scope.Apply(r.DB, "").Where("LOWER(name) = LOWER(?)", name).Order("id").First(&collection).Error
+	return collection, err
+}
+
+func (r *CollectionRepo) CollectionNameExists(scope Scope, name string, excludeId int64) (bool, error) {
+	var count int64
+	err := scope.Apply(r.DB.Model(&models.Collection{}), "").
+		Where("LOWER(name) = LOWER(?) AND id <> ?", name, excludeId).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *CollectionRepo) CreateCollection(collection *models.Collection) error {
+	return r.DB.Create(collection).Error
+}
+
+func (r *CollectionRepo) UpdateCollection(collection *models.Collection) error {
+	return r.DB.Model(collection).Updates(map[string]any{
+		"name":        collection.Name,
+		"description": collection.Description,
+	}).Error
+}
+
+func (r *CollectionRepo) DeleteCollection(scope Scope, id int64) (bool, error) {
+	deleted := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := scope.Apply(tx, "").Where("id = ?", id).Delete(&models.Collection{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected > 0
+		if !deleted {
+			return nil
+		}
+		return tx.Where("collection_id = ?", id).Delete(&models.CollectionItem{}).Error
+	})
+	return deleted, err
+}
+
+func (r *CollectionRepo) GetCollectionItems(collectionId int64) ([]models.CollectionItem, error) {
+	var items []models.CollectionItem
+	err := r.DB.Preload("Post").Where("collection_id = ?", collectionId).Order("position").Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// PostCollection places a post in one of the scope's collections.
+type PostCollection struct {
+	PostId      int64
+	Name        string
+	Description string
+	Position    int
+	Note        string
+}
+
+// GetPostCollections lists which collections each of the scope's posts is
+// in.
+func (r *CollectionRepo) GetPostCollections(scope Scope) ([]PostCollection, error) {
+	var entries []PostCollection
+	query := r.DB.Model(&models.CollectionItem{}).
+		Select("collection_items.post_id, collections.name, collections.description, collection_items.position, collection_items.note").
+		Joins("JOIN collections ON collections.id = collection_items.collection_id")
+	err := scope.Apply(query, "collections").
+		Order("collections.name, collection_items.position").
+		Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// AddCollectionItem appends one of the scope's posts to the end of the
+// collection.
+func (r *CollectionRepo) AddCollectionItem(scope Scope, collectionId int64, postId int64, note string) (models.CollectionItem, error) {
+	item := models.CollectionItem{CollectionId: collectionId, PostId: postId, Note: note}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := scope.Apply(tx.Model(&models.Post{}), "").Where("id = ?", postId).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrPostNotFound
+		}
+
+		err = tx.Model(&models.CollectionItem{}).Where("collection_id = ? AND post_id = ?", collectionId, postId).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAlreadyInCollection
+		}
+
+		err = tx.Model(&models.CollectionItem{}).
+			Select("COALESCE(MAX(position) + 1, 0)").
+			Where("collection_id = ?", collectionId).
+			Scan(&item.Position).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&item).Error
+	})
+	return item, err
+}
+
+func (r *CollectionRepo) UpdateCollectionItemNote(collectionId int64, postId int64, note string) error {
+	result := r.DB.Model(&models.CollectionItem{}).
+		Where("collection_id = ? AND post_id = ?", collectionId, postId).
+		Update("note", note)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCollectionItemAbsent
+	}
+	return nil
+}
+
+func (r *CollectionRepo) RemoveCollectionItem(collectionId int64, postId int64) (bool, error) {
+	result := r.DB.Where("collection_id = ? AND post_id = ?", collectionId, postId).Delete(&models.CollectionItem{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReorderCollection sets the order of the collection to postIds, which must
+// list every post in it.
+func (r *CollectionRepo) ReorderCollection(collectionId int64, postIds []int64) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var current []int64
+		err := tx.Model(&models.CollectionItem{}).Where("collection_id = ?", collectionId).Pluck("post_id", &current).Error
+		if err != nil {
+			return err
+		}
+		if len(current) != len(postIds) {
+			return ErrInvalidPostOrder
+		}
+		remaining := map[int64]bool{}
+		for _, id := range current {
+			remaining[id] = true
+		}
+		for _, id := range postIds {
+			if !remaining[id] {
+				return ErrInvalidPostOrder
+			}
+			delete(remaining, id)
+		}
+
+		for position, postId := range postIds {
+			err = tx.Model(&models.CollectionItem{}).
+				Where("collection_id = ? AND post_id = ?", collectionId, postId).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DigestRepo struct {
+	DB *gorm.DB
+}
+
+func NewDigestRepo(db *gorm.DB) *DigestRepo {
+	return &DigestRepo{DB: db}
+}
+
+func (r *DigestRepo) GetSchedule(userId int64) (models.DigestSchedule, error) {
+	var schedule models.DigestSchedule
+	err := r.DB.Where("user_id = ?", userId).First(&schedule).Error
+	return schedule, err
+}
+
+func (r *DigestRepo) SaveSchedule(schedule *models.DigestSchedule) error {
+	return r.DB.Save(schedule).Error
+}
+
+func (r *DigestRepo) GetDueSchedules(now time.Time, limit int) ([]models.DigestSchedule, error) {
+	var schedules []models.DigestSchedule
+	err := r.DB.Where("enabled = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at").Limit(limit).Find(&schedules).Error
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// ClaimSchedule moves a due schedule on to its next run. Only one caller can
+// claim a given run, so several scheduler instances never send the same
+// digest twice.
+func (r *DigestRepo) ClaimSchedule(schedule models.DigestSchedule, nextRunAt time.Time) (bool, error) {
+	result := r.DB.Model(&models.DigestSchedule{}).
+		Where("user_id = ? AND next_run_at = ?", schedule.UserId, schedule.NextRunAt).
+		Update("next_run_at", nextRunAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *DigestRepo) MarkDigestSent(userId int64, sentAt time.Time) error {
+	return r.DB.Model(&models.DigestSchedule{}).Where("user_id = ?", userId).Update("last_sent_at", sentAt).Error
+}
+
+func (r *DigestRepo) GetPostsCreatedBetween(scope Scope, from time.Time, to time.Time) ([]models.Post, error) {
+	var posts []models.Post
+	err := scope.Apply(r.DB, "").Where("created_at >= ? AND created_at < ?", from, to).
+		Order("category, created_at DESC").Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FeedRepo struct {
+	DB *gorm.DB
+}
+
+func NewFeedRepo(db *gorm.DB) *FeedRepo {
+	return &FeedRepo{DB: db}
+}
+
+func (r *FeedRepo) CreateFeed(feed *models.Feed) error {
+	return r.DB.Create(feed).Error
+}
+
+func (r *FeedRepo) GetFeeds(scope Scope) ([]models.Feed, error) {
+	var feeds []models.Feed
+	err := scope.Apply(r.DB, "").Where("revoked_at IS NULL").Order("created_at DESC").Find(&feeds).Error
+	if err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
+
+func (r *FeedRepo) GetFeedByTokenHash(tokenHash string) (models.Feed, error) {
+	var feed models.Feed
+	err := r.DB.Where("token_hash = ? AND revoked_at IS NULL", tokenHash).First(&feed).Error
+	return feed, err
+}
+
+func (r *FeedRepo) RevokeFeed(scope Scope, id int64) (bool, error) {
+	result := scope.Apply(r.DB.Model(&models.Feed{}), "").
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
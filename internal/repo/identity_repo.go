@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"errors"
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidLoginState = errors.New("login state is invalid or expired")
+
+type IdentityRepo struct {
+	DB *gorm.DB
+}
+
+func NewIdentityRepo(db *gorm.DB) *IdentityRepo {
+	return &IdentityRepo{DB: db}
+}
+
+func (r *IdentityRepo) CreateLoginState(state *models.OIDCLoginState) error {
+	// Abandoned sign-ins would otherwise pile up.
+	err := r.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{}).Error
+	if err != nil {
+		return err
+	}
+	return r.DB.Create(state).Error
+}
+
+// ConsumeLoginState returns the state and deletes it, so each sign-in can
+// only be finished once.
+func (r *IdentityRepo) ConsumeLoginState(stateHash string, provider string) (models.OIDCLoginState, error) {
+	var state models.OIDCLoginState
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("state_hash = ? AND provider = ? AND expires_at > ?", stateHash, provider, time.Now()).
+			First(&state).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidLoginState
+			}
+			return err
+		}
+		return tx.Delete(&state).Error
+	})
+	return state, err
+}
+
+func (r *IdentityRepo) GetIdentity(provider string, subject string) (models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.DB.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	return identity, err
+}
+
+func (r *IdentityRepo) GetUserIdentities(userId int64) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.DB.Where("user_id = ?", userId).Order("created_at").Find(&identities).Error
+	if err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *IdentityRepo) CreateIdentity(identity *models.UserIdentity) error {
+	return r.DB.Create(identity).Error
+}
+
+// CreateUserWithIdentity signs up a user who first arrives through an
+// identity provider.
+func (r *IdentityRepo) CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(user).Error
+		if err != nil {
+			return err
+		}
+		identity.UserId = *user.Id
+		return tx.Create(identity).Error
+	})
+}
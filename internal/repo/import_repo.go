@@ -0,0 +1,157 @@
+package repo
+
+import (
+	"errors"
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ImportRepo struct {
+	DB *gorm.DB
+}
+
+func NewImportRepo(db *gorm.DB) *ImportRepo {
+	return &ImportRepo{DB: db}
+}
+
+func (r *ImportRepo) CreateImportJob(job *models.ImportJob, items []models.ImportItem) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(job).Error
+		if err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].JobId = job.Id
+		}
+		return tx.CreateInBatches(items, 500).Error
+	})
+}
+
+func (r *ImportRepo) GetImportJobs(scope Scope) ([]models.ImportJob, error) {
+	var jobs []models.ImportJob
+	err := scope.Apply(r.DB, "").Order("created_at DESC").Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *ImportRepo) GetImportJob(scope Scope, id int64) (models.ImportJob, error) {
+	var job models.ImportJob
+	err := scope.Apply(r.DB, "").Where("id = ?", id).First(&job).Error
+	return job, err
+}
+
+// GetImportItems lists a job's bookmarks in file order, optionally only
+// those with the given status.
+func (r *ImportRepo) GetImportItems(jobId int64, status string, limit int, offset int) ([]models.ImportItem, error) {
+	var items []models.ImportItem
+	query := r.DB.Where("job_id = ?", jobId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("position").Limit(limit).Offset(offset).Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CancelImportJob stops a job; bookmarks already saved are kept. It reports
+// false when the job had already finished.
+func (r *ImportRepo) CancelImportJob(jobId int64) (bool, error) {
+	cancelled := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.ImportJob{}).
+			Where("id = ? AND status IN ?", jobId, []string{models.ImportStatusPending, models.ImportStatusRunning}).
+			Updates(map[string]any{"status": models.ImportStatusCancelled, "finished_at": now})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		cancelled = true
+		return tx.Model(&models.ImportItem{}).
+			Where("job_id = ? AND status = ?", jobId, models.ImportItemPending).
+			Update("status", models.ImportItemCancelled).Error
+	})
+	return cancelled, err
+}
+
+// ClaimNextImportItem takes the next bookmark to save. Jobs take turns, the
+// one that progressed longest ago going first, so one big import doesn't
+// hold up everyone else's. Items claimed before staleBefore are taken over,
+// as whoever claimed them has stopped.
+func (r *ImportRepo) ClaimNextImportItem(staleBefore time.Time) (models.ImportItem, models.ImportJob, bool, error) {
+	var item models.ImportItem
+	var job models.ImportJob
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.ImportItem{}).
+			Select("import_items.*").
+			Joins("JOIN import_jobs ON import_jobs.id = import_items.job_id").
+			Where("import_jobs.status IN ?", []string{models.ImportStatusPending, models.ImportStatusRunning}).
+			Where("import_items.status = ? OR (import_items.status = ? AND import_items.claimed_at < ?)",
+				models.ImportItemPending, models.ImportItemProcessing, staleBefore).
+			Order("import_jobs.updated_at, import_items.position").
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "import_items"}, Options: "SKIP LOCKED"}).
+			Take(&item).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err = tx.Model(&item).Updates(map[string]any{"status": models.ImportItemProcessing, "claimed_at": now}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.ImportJob{}).Where("id = ?", item.JobId).
+			Updates(map[string]any{"status": models.ImportStatusRunning, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ?", item.JobId).First(&job).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return item, job, false, nil
+	}
+	return item, job, err == nil, err
+}
+
+var importItemCounters = map[string]string{
+	models.ImportItemImported:  "imported",
+	models.ImportItemDuplicate: "duplicates",
+	models.ImportItemFailed:    "failed",
+}
+
+// FinishImportItem records how saving a bookmark went and marks the job done
+// when it was the last one.
+func (r *ImportRepo) FinishImportItem(item models.ImportItem) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ImportItem{}).
+			Where("id = ? AND status = ?", item.Id, models.ImportItemProcessing).
+			Updates(map[string]any{"status": item.Status, "post_id": item.PostId, "error": item.Error})
+		if result.Error != nil || result.RowsAffected == 0 {
+			// Someone else took the item over and finished it.
+			return result.Error
+		}
+		counter := importItemCounters[item.Status]
+		err := tx.Model(&models.ImportJob{}).Where("id = ?", item.JobId).
+			Update(counter, gorm.Expr(counter+" + 1")).Error
+		if err != nil {
+			return err
+		}
+
+		var remaining int64
+		err = tx.Model(&models.ImportItem{}).
+			Where("job_id = ? AND status IN ?", item.JobId, []string{models.ImportItemPending, models.ImportItemProcessing}).
+			Count(&remaining).Error
+		if err != nil || remaining > 0 {
+			return err
+		}
+		return tx.Model(&models.ImportJob{}).
+			Where("id = ? AND status = ?", item.JobId, models.ImportStatusRunning).
+			Updates(map[string]any{"status": models.ImportStatusDone, "finished_at": time.Now()}).Error
+	})
+}
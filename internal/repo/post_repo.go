@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"module/lynkbin/internal/models"
 	"slices"
+	"time"
 
 	"github.com/lib/pq"
 	"gorm.io/gorm"
@@ -23,11 +24,11 @@ func (r *PostRepo) CreatePost(post *models.Post) error {
 	return r.DB.Create(post).Error
 }
 
-func (r *PostRepo) CheckUserAuthorExists(userId int64, author string, platform string) (bool, error) {
+func (r *PostRepo) CheckUserAuthorExists(scope Scope, author string, platform string) (bool, error) {
 	if author == "" {
 		return false, nil
 	}
-	err := r.DB.Where("names @> ?", pq.StringArray{author}).Where("user_id = ? AND  platform= ?", userId, platform).First(&models.UserAuthor{}).Error
+	err := scope.Apply(r.DB, "").Where("names @> ?", pq.StringArray{author}).Where("platform = ?", platform).First(&models.UserAuthor{}).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return false, nil
@@ -37,18 +38,19 @@ func (r *PostRepo) CheckUserAuthorExists(userId int64, author string, platform s
 	return true, nil
 }
 
-func (r *PostRepo) AddUserAuthor(userId int64, author string, platform string) error {
+func (r *PostRepo) AddUserAuthor(scope Scope, author string, platform string) error {
 	if author == "" {
 		return nil
 	}
 	var userAuthor models.UserAuthor
-	err := r.DB.Where("user_id = ? AND  platform= ?", userId, platform).First(&userAuthor).Error
+	err := scope.Apply(r.DB, "").Where("platform = ?", platform).First(&userAuthor).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return r.DB.Create(&models.UserAuthor{
-				UserId:   userId,
-				Platform: platform,
-				Names:    pq.StringArray{author},
+				UserId:      scope.OwnerId(),
+				WorkspaceId: scope.WorkspaceId,
+				Platform:    platform,
+				Names:       pq.StringArray{author},
 			}).Error
 		}
 		return err
@@ -57,24 +59,25 @@ func (r *PostRepo) AddUserAuthor(userId int64, author string, platform string) e
 	return r.DB.Save(&userAuthor).Error
 }
 
-func (r *PostRepo) UpdateUserTags(userId int64, platform string, tags pq.StringArray) error {
+func (r *PostRepo) UpdateUserTags(scope Scope, platform string, tags pq.StringArray) error {
 	if len(tags) == 0 {
 		return nil
 	}
 	var userTags models.UserTags
-	err := r.DB.Where("tags @> ?", tags).Where("user_id = ? AND  platform= ?", userId, platform).First(&userTags).Error
+	err := scope.Apply(r.DB, "").Where("tags @> ?", tags).Where("platform = ?", platform).First(&userTags).Error
 
 	if err == nil {
 		return nil
 	}
 
-	err = r.DB.Where("user_id = ? AND  platform= ?", userId, platform).First(&userTags).Error
+	err = scope.Apply(r.DB, "").Where("platform = ?", platform).First(&userTags).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			err = r.DB.Create(&models.UserTags{
-				UserId:   userId,
-				Platform: platform,
-				Tags:     tags,
+				UserId:      scope.OwnerId(),
+				WorkspaceId: scope.WorkspaceId,
+				Platform:    platform,
+				Tags:        tags,
 			}).Error
 
 			if err != nil {
@@ -122,18 +125,19 @@ func (r *PostRepo) UpdateAllTags(tags pq.StringArray) error {
 	return nil
 }
 
-func (r *PostRepo) UpdateUserCategory(userId int64, platform string, category string) error {
+func (r *PostRepo) UpdateUserCategory(scope Scope, platform string, category string) error {
 	if category == "" {
 		return nil
 	}
 	var userCategories models.UserCategories
-	err := r.DB.Where("user_id = ? AND  platform= ?", userId, platform).First(&userCategories).Error
+	err := scope.Apply(r.DB, "").Where("platform = ?", platform).First(&userCategories).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			err = r.DB.Create(&models.UserCategories{
-				UserId:     userId,
-				Platform:   platform,
-				Categories: pq.StringArray{category},
+				UserId:      scope.OwnerId(),
+				WorkspaceId: scope.WorkspaceId,
+				Platform:    platform,
+				Categories:  pq.StringArray{category},
 			}).Error
 
 			if err != nil {
@@ -188,107 +192,311 @@ func (r *PostRepo) GetAllCategories() ([]models.AllCategories, error) {
 	return categories, nil
 }
 
-func (r *PostRepo) GetPosts(userId int64, platform string, tags []string, authors []string, categories []string) ([]models.Post, error) {
-	var posts []models.Post
-	query := r.DB.Where("user_id = ?", userId)
+type PostFilter struct {
+	Scope      Scope
+	Platform   string
+	Tags       []string
+	Authors    []string
+	Categories []string
+	// ParentCategory matches the category path and all of its descendants.
+	ParentCategory string
+	// Author is matched case-insensitively against the author's name or
+	// handle.
+	Author string
+	// Query is a case-insensitive keyword matched against the post's text,
+	// author, category and tags.
+	Query string
+	// CollectionId limits the posts to one of the user's collections, in the
+	// collection's order.
+	CollectionId int64
+	// Statuses limits the posts to these reading states of the scope's
+	// user.
+	Statuses []string
+	Favorite *bool
+	// Snoozed is "exclude" to hide posts snoozed into the future or "only"
+	// to list just those; anything else leaves snoozed posts in.
+	Snoozed string
+	// Sort is one of postSortColumns, newest saved first when empty. Order
+	// is asc or desc.
+	Sort   string
+	Order  string
+	Limit  int
+	Offset int
+}
+
+var postSortColumns = map[string]string{
+	"created_at":     "posts.created_at",
+	"last_opened_at": "post_states.last_opened_at",
+	"snoozed_until":  "post_states.snoozed_until",
+	"progress":       "COALESCE(post_states.progress, 0)",
+}
+
+// PostWithState is a post along with the reading state of the user it was
+// fetched for.
+type PostWithState struct {
+	models.Post
+	models.ReadingState
+}
+
+// PlainPosts drops the reading state from posts.
+func PlainPosts(posts []PostWithState) []models.Post {
+	plain := make([]models.Post, 0, len(posts))
+	for _, post := range posts {
+		plain = append(plain, post.Post)
+	}
+	return plain
+}
 
-	if platform != "" {
-		query = query.Where("platform = ?", platform)
+// postStateColumns read the state joined by withPostState, as unread when
+// the user has none.
+const postStateColumns = "COALESCE(post_states.status, '" + models.PostStatusUnread + "') AS status, COALESCE(post_states.favorite, false) AS favorite, " +
+	"post_states.snoozed_until, post_states.last_opened_at, COALESCE(post_states.progress, 0) AS progress"
+
+// withPostState joins the reading state of the scope's user to the scope's
+// posts.
+func withPostState(db *gorm.DB, scope Scope) *gorm.DB {
+	return scope.Apply(db.Model(&models.Post{}), "posts").
+		Joins("LEFT JOIN post_states ON post_states.post_id = posts.id AND post_states.user_id = ?", scope.UserId)
+}
+
+// GetPosts lists the posts matching the filter, with the reading state of
+// the scope's user.
+func (r *PostRepo) GetPosts(filter PostFilter) ([]PostWithState, error) {
+	var posts []PostWithState
+	query := withPostState(r.DB, filter.Scope).Select("posts.*, " + postStateColumns)
+
+	if filter.Platform != "" {
+		query = query.Where("platform = ?", filter.Platform)
 	}
-	if len(tags) > 0 {
-		query = query.Where("tags && ?", pq.StringArray(tags))
+	if len(filter.Tags) > 0 {
+		query = query.Where("tags && ?", pq.StringArray(filter.Tags))
 	}
 
-	if len(authors) > 0 {
-		query = query.Where("author in ?", authors)
+	if len(filter.Authors) > 0 {
+		query = query.Where("author in ?", filter.Authors)
 	}
-	if len(categories) > 0 {
-		query = query.Where("category in ?", categories)
+	if len(filter.Categories) > 0 {
+		query = query.Where("category in ?", filter.Categories)
+	}
+	if filter.ParentCategory != "" {
+		condition, args := categorySubtreeCondition("category", filter.ParentCategory)
+		query = query.Where(condition, args...)
 	}
 
-	err := query.Order("created_at DESC").Find(&posts).Error
+	if filter.Author != "" {
+		pattern := "%" + escapeLike(filter.Author) + "%"
+		query = query.Where(
+			"(author ILIKE ? OR author_id IN (SELECT id FROM authors WHERE user_id = ? AND workspace_id = ? AND handle ILIKE ?))",
+			pattern, filter.Scope.OwnerId(), filter.Scope.WorkspaceId, pattern,
+		)
+	}
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		query = query.Where(
+			"(topic ILIKE ? OR description ILIKE ? OR data ILIKE ? OR author ILIKE ? OR category ILIKE ? OR EXISTS (SELECT 1 FROM unnest(tags) AS t(tag) WHERE t.tag ILIKE ?))",
+			pattern, pattern, pattern, pattern, pattern, pattern,
+		)
+	}
+	if filter.CollectionId > 0 {
+		condition, args := filter.Scope.Condition("collections")
+		query = query.Where(
+			"id IN (SELECT collection_items.post_id FROM collection_items JOIN collections ON collections.id = collection_items.collection_id WHERE collections.id = ? AND "+condition+")",
+			append([]any{filter.CollectionId}, args...)...,
+		)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("COALESCE(post_states.status, ?) IN ?", models.PostStatusUnread, filter.Statuses)
+	}
+	if filter.Favorite != nil {
+		query = query.Where("COALESCE(post_states.favorite, false) = ?", *filter.Favorite)
+	}
+	if filter.Snoozed == "exclude" {
+		query = query.Where("(post_states.snoozed_until IS NULL OR post_states.snoozed_until <= ?)", time.Now())
+	} else if filter.Snoozed == "only" {
+		query = query.Where("post_states.snoozed_until > ?", time.Now())
+	}
+
+	direction := "DESC"
+	if filter.Order == "asc" {
+		direction = "ASC"
+	}
+	if column, ok := postSortColumns[filter.Sort]; ok && filter.Sort != "created_at" {
+		query = query.Order(column + " " + direction + " NULLS LAST")
+		// Ties on the chosen column fall back to the newest first.
+		direction = "DESC"
+	}
+	if filter.CollectionId > 0 && filter.Sort == "" {
+		query = query.Order(fmt.Sprintf("(SELECT position FROM collection_items WHERE collection_items.post_id = posts.id AND collection_items.collection_id = %d)", filter.CollectionId))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	err := query.Order("posts.created_at " + direction).Find(&posts).Error
 	if err != nil {
 		return nil, err
 	}
 	return posts, nil
 }
 
-func (r *PostRepo) GetUserAuthors(userId int64, platform string) ([]models.UserAuthor, error) {
+func (r *PostRepo) GetUserAuthors(scope Scope, platform string) ([]models.UserAuthor, error) {
 	var authors []models.UserAuthor
-	err := r.DB.Where("user_id = ? AND platform = ?", userId, platform).Find(&authors).Error
+	err := scope.Apply(r.DB, "").Where("platform = ?", platform).Find(&authors).Error
 	if err != nil {
 		return nil, err
 	}
 	return authors, nil
 }
 
-func (r *PostRepo) GetUserCategories(userId int64, platform string) ([]models.UserCategories, error) {
+func (r *PostRepo) GetUserCategories(scope Scope, platform string) ([]models.UserCategories, error) {
 	var categories []models.UserCategories
-	err := r.DB.Where("user_id = ? AND platform = ?", userId, platform).Find(&categories).Error
+	err := scope.Apply(r.DB, "").Where("platform = ?", platform).Find(&categories).Error
 	if err != nil {
 		return nil, err
 	}
 	return categories, nil
 }
 
-func (r *PostRepo) GetUserTags(userId int64, platform string) ([]models.UserTags, error) {
+func (r *PostRepo) GetUserTags(scope Scope, platform string) ([]models.UserTags, error) {
 	var tags []models.UserTags
-	err := r.DB.Where("user_id = ? AND platform = ?", userId, platform).Find(&tags).Error
+	err := scope.Apply(r.DB, "").Where("platform = ?", platform).Find(&tags).Error
 	if err != nil {
 		return nil, err
 	}
 	return tags, nil
 }
 
-func (r *PostRepo) GetAllUserPostsCount(userId int64) (int64, error) {
+func (r *PostRepo) GetAllUserPostsCount(scope Scope) (int64, error) {
 	var count int64
-	err := r.DB.Model(&models.Post{}).Where("user_id = ?", userId).Count(&count).Error
+	err := scope.Apply(r.DB.Model(&models.Post{}), "").Count(&count).Error
 	if err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
-func (r *PostRepo) GetAllTagsCount(userId int64) (int64, error) {
+func (r *PostRepo) GetAllTagsCount(scope Scope) (int64, error) {
 	var count int64
-	err := r.DB.Raw("SELECT SUM(CARDINALITY(tags)) FROM user_tags WHERE user_id = ?", userId).Scan(&count).Error
+	err := r.DB.Raw("SELECT SUM(CARDINALITY(tags)) FROM user_tags WHERE user_id = ? AND workspace_id = ?", scope.OwnerId(), scope.WorkspaceId).Scan(&count).Error
 	if err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
-func (r *PostRepo) GetAllCategoriesCount(userId int64) (int64, error) {
+func (r *PostRepo) GetAllCategoriesCount(scope Scope) (int64, error) {
 	var count int64
-	err := r.DB.Raw("SELECT SUM(CARDINALITY(categories)) FROM user_categories WHERE user_id = ?", userId).Scan(&count).Error
+	err := r.DB.Raw("SELECT SUM(CARDINALITY(categories)) FROM user_categories WHERE user_id = ? AND workspace_id = ?", scope.OwnerId(), scope.WorkspaceId).Scan(&count).Error
 	if err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
-func (r *PostRepo) DeletePost(userId int64, postId int64) error {
+func (r *PostRepo) DeletePost(scope Scope, postId int64) (models.Post, error) {
 	// First verify the post belongs to the user
 	var post models.Post
-	err := r.DB.Where("id = ? AND user_id = ?", postId, userId).First(&post).Error
+	err := scope.Apply(r.DB, "").Where("id = ?", postId).First(&post).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("post not found or you don't have permission to delete it")
+			return post, ErrPostNotFound
 		}
-		return err
+		return post, err
 	}
 
 	// Delete the post
-	err = r.DB.Where("id = ? AND user_id = ?", postId, userId).Delete(&models.Post{}).Error
+	err = r.DB.Where("id = ?", post.Id).Delete(&models.Post{}).Error
 	if err != nil {
-		return err
+		return post, err
 	}
-	return nil
+	return post, nil
+}
+
+// GetUnreadPostsCount counts the posts the scope's user hasn't started
+// that aren't snoozed.
+func (r *PostRepo) GetUnreadPostsCount(scope Scope) (int64, error) {
+	var count int64
+	err := withPostState(r.DB, scope).
+		Where("COALESCE(post_states.status, ?) = ?", models.PostStatusUnread, models.PostStatusUnread).
+		Where("(post_states.snoozed_until IS NULL OR post_states.snoozed_until <= ?)", time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *PostRepo) GetFavoritePostsCount(scope Scope) (int64, error) {
+	var count int64
+	err := withPostState(r.DB, scope).Where("post_states.favorite").Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetPostWithState returns one of the scope's posts with the reading state
+// of the scope's user.
+func (r *PostRepo) GetPostWithState(scope Scope, postId int64) (PostWithState, error) {
+	var post PostWithState
+	err := withPostState(r.DB, scope).Select("posts.*, "+postStateColumns).Where("posts.id = ?", postId).Take(&post).Error
+	return post, err
+}
+
+// SavePostState stores a user's reading state of a post.
+func (r *PostRepo) SavePostState(state *models.PostState) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "favorite", "snoozed_until", "last_opened_at", "progress", "updated_at"}),
+	}).Create(state).Error
+}
+
+// postDocument is the text a post is searched by.
+const postDocument = `to_tsvector('english', coalesce(topic, '') || ' ' || coalesce(description, '') || ' ' || coalesce(author, '') || ' ' || coalesce(category, '') || ' ' || array_to_string(tags, ' ') || ' ' || coalesce(data, ''))`
+
+type RankedPost struct {
+	models.Post
+	Rank float64 `json:"rank"`
+}
+
+// SearchPosts ranks the user's posts against a natural-language question
+// with Postgres full-text search. Terms are OR-ed rather than AND-ed since a
+// question rarely shares every word with the posts that answer it.
+func (r *PostRepo) SearchPosts(scope Scope, question string, limit int) ([]RankedPost, error) {
+	var posts []RankedPost
+	condition, args := scope.Condition("posts")
+	err := r.DB.Raw(`SELECT posts.*, ts_rank_cd(`+postDocument+`, q) AS rank
+		FROM posts, to_tsquery('english', replace(plainto_tsquery('english', ?)::text, ' & ', ' | ')) AS q
+		WHERE `+condition+` AND `+postDocument+` @@ q
+		ORDER BY rank DESC, posts.created_at DESC
+		LIMIT ?`, append(append([]any{question}, args...), limit)...).Scan(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r *PostRepo) GetPost(scope Scope, postId int64) (models.Post, error) {
+	var post models.Post
+	err := scope.Apply(r.DB, "").Where("id = ?", postId).First(&post).Error
+	return post, err
+}
+
+// GetPostByData finds a post saved from the same link or note.
+func (r *PostRepo) GetPostByData(scope Scope, data string) (models.Post, error) {
+	var post models.Post
+	err := scope.Apply(r.DB, "").Where("data = ?", data).Order("id").First(&post).Error
+	return post, err
 }
 
-func (r *PostRepo) GetRecentPosts(userId int64) ([]models.Post, error) {
-	var posts []models.Post
-	err := r.DB.Where("user_id = ?", userId).Order("created_at DESC").Limit(5).Find(&posts).Error
+// GetAllPosts returns every post in the scope, oldest first, with the
+// reading state of the scope's user.
+func (r *PostRepo) GetAllPosts(scope Scope) ([]PostWithState, error) {
+	var posts []PostWithState
+	err := withPostState(r.DB, scope).Select("posts.*, " + postStateColumns).Order("posts.created_at, posts.id").Find(&posts).Error
 	if err != nil {
 		return nil, err
 	}
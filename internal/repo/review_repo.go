@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReviewRepo struct {
+	DB *gorm.DB
+}
+
+func NewReviewRepo(db *gorm.DB) *ReviewRepo {
+	return &ReviewRepo{DB: db}
+}
+
+// GetDuePosts returns the scope's posts that are due for the user's review,
+// their favorites first and then the longest overdue. Posts the user never
+// reviewed are due once they were saved before newBefore. Posts the user
+// archived or snoozed are left out, as are retired ones.
+func (r *ReviewRepo) GetDuePosts(scope Scope, now time.Time, newBefore time.Time, limit int) ([]PostWithState, error) {
+	var posts []PostWithState
+	err := withPostState(r.DB, scope).
+		Select("posts.*, "+postStateColumns).
+		Joins("LEFT JOIN post_reviews ON post_reviews.post_id = posts.id AND post_reviews.user_id = ?", scope.UserId).
+		Where("COALESCE(post_states.status, ?) <> ?", models.PostStatusUnread, models.PostStatusArchived).
+		Where("(post_states.snoozed_until IS NULL OR post_states.snoozed_until <= ?)", now).
+		Where("post_reviews.retired_at IS NULL").
+		Where("((post_reviews.post_id IS NULL AND posts.created_at <= ?) OR post_reviews.due_at <= ?)", newBefore, now).
+		Order("COALESCE(post_states.favorite, false) DESC, COALESCE(post_reviews.due_at, posts.created_at), posts.id").
+		Limit(limit).Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r *ReviewRepo) GetReviews(userId int64, postIds []int64) ([]models.PostReview, error) {
+	var reviews []models.PostReview
+	if len(postIds) == 0 {
+		return reviews, nil
+	}
+	err := r.DB.Where("user_id = ? AND post_id IN ?", userId, postIds).Find(&reviews).Error
+	if err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *ReviewRepo) GetReview(userId int64, postId int64) (models.PostReview, error) {
+	var review models.PostReview
+	err := r.DB.Where("user_id = ? AND post_id = ?", userId, postId).First(&review).Error
+	return review, err
+}
+
+// MarkSurfaced holds the posts back until dueAt after they were sent out, so
+// a post that gets no feedback isn't sent again in every digest.
+func (r *ReviewRepo) MarkSurfaced(scope Scope, postIds []int64, surfacedAt time.Time, dueAt time.Time) error {
+	if len(postIds) == 0 {
+		return nil
+	}
+	reviews := make([]models.PostReview, 0, len(postIds))
+	for _, postId := range postIds {
+		reviews = append(reviews, models.PostReview{
+			PostId:         postId,
+			UserId:         scope.UserId,
+			WorkspaceId:    scope.WorkspaceId,
+			EaseFactor:     2.5,
+			DueAt:          dueAt,
+			LastSurfacedAt: &surfacedAt,
+		})
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"due_at", "last_surfaced_at", "updated_at"}),
+	}).Create(&reviews).Error
+}
+
+// SaveFeedback stores the rescheduled review along with the answer that
+// rescheduled it.
+func (r *ReviewRepo) SaveFeedback(review *models.PostReview, feedback *models.PostReviewFeedback) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Save(review).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(feedback).Error
+	})
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"module/lynkbin/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Scope is the library a request works in: the user's personal library or a
+// workspace they are a member of. Personal rows have workspace_id 0; library
+// wide rows of a workspace (tags, categories, authors) have user_id 0 since
+// they belong to no single member.
+type Scope struct {
+	UserId      int64
+	WorkspaceId int64
+}
+
+func PersonalScope(userId int64) Scope {
+	return Scope{UserId: userId}
+}
+
+// PostScope is the scope a post was saved in.
+func PostScope(post models.Post) Scope {
+	return Scope{UserId: post.UserId, WorkspaceId: post.WorkspaceId}
+}
+
+// ScopeFromContext reads the scope AuthMiddleware put on the request.
+func ScopeFromContext(ctx interface{ GetInt64(key any) int64 }) Scope {
+	return Scope{UserId: ctx.GetInt64("user_id"), WorkspaceId: ctx.GetInt64("workspace_id")}
+}
+
+func (s Scope) IsWorkspace() bool {
+	return s.WorkspaceId != 0
+}
+
+// OwnerId is the user_id stored on library-wide rows of the scope.
+func (s Scope) OwnerId() int64 {
+	if s.IsWorkspace() {
+		return 0
+	}
+	return s.UserId
+}
+
+// Condition matches the rows of the scope in table, or in the current model
+// when table is empty.
+func (s Scope) Condition(table string) (string, []any) {
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	if s.IsWorkspace() {
+		return prefix + "workspace_id = ?", []any{s.WorkspaceId}
+	}
+	return prefix + "user_id = ? AND " + prefix + "workspace_id = 0", []any{s.UserId}
+}
+
+func (s Scope) Apply(db *gorm.DB, table string) *gorm.DB {
+	condition, args := s.Condition(table)
+	return db.Where(condition, args...)
+}
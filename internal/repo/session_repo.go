@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepo struct {
+	DB *gorm.DB
+}
+
+func NewSessionRepo(db *gorm.DB) *SessionRepo {
+	return &SessionRepo{DB: db}
+}
+
+func (r *SessionRepo) CreateRefreshToken(token *models.RefreshToken) error {
+	return r.DB.Create(token).Error
+}
+
+func (r *SessionRepo) GetRefreshTokenByHash(tokenHash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.DB.Where("token_hash = ?", tokenHash).First(&token).Error
+	return token, err
+}
+
+// RotateRefreshToken marks the presented token used and stores its
+// successor. The used_at guard makes concurrent refreshes with the same token
+// fail instead of both succeeding.
+func (r *SessionRepo) RotateRefreshToken(current models.RefreshToken, next *models.RefreshToken) (bool, error) {
+	rotated := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", current.Id).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		rotated = true
+		return tx.Create(next).Error
+	})
+	return rotated, err
+}
+
+func (r *SessionRepo) RevokeSession(userId int64, sessionId string) error {
+	return r.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND session_id = ? AND revoked_at IS NULL", userId, sessionId).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *SessionRepo) RevokeAllUserSessions(userId int64) error {
+	return r.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", time.Now()).Error
+}
+
+// IsSessionActive reports whether the session still has an unrevoked token,
+// which is what keeps its access tokens valid.
+func (r *SessionRepo) IsSessionActive(userId int64, sessionId string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND session_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, sessionId, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *SessionRepo) GetActiveSessions(userId int64) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	err := r.DB.Where("user_id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+		Order("created_at DESC").Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// GetSessionStartedAt returns when the user signed in to start the session.
+func (r *SessionRepo) GetSessionStartedAt(userId int64, sessionId string) (time.Time, error) {
+	var token models.RefreshToken
+	err := r.DB.Where("user_id = ? AND session_id = ?", userId, sessionId).
+		Order("created_at").First(&token).Error
+	return token.CreatedAt, err
+}
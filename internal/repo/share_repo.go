@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ShareRepo struct {
+	DB *gorm.DB
+}
+
+func NewShareRepo(db *gorm.DB) *ShareRepo {
+	return &ShareRepo{DB: db}
+}
+
+func (r *ShareRepo) CreateShareLink(link *models.ShareLink) error {
+	return r.DB.Create(link).Error
+}
+
+func (r *ShareRepo) GetShareLinks(scope Scope) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+	err := scope.Apply(r.DB, "").Order("created_at DESC").Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *ShareRepo) GetShareLinkBySlug(slug string) (models.ShareLink, error) {
+	var link models.ShareLink
+	err := r.DB.Where("slug = ?", slug).First(&link).Error
+	return link, err
+}
+
+func (r *ShareRepo) RevokeShareLink(scope Scope, id int64) (bool, error) {
+	result := scope.Apply(r.DB.Model(&models.ShareLink{}), "").
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
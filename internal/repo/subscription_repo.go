@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"errors"
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SubscriptionRepo struct {
+	DB *gorm.DB
+}
+
+func NewSubscriptionRepo(db *gorm.DB) *SubscriptionRepo {
+	return &SubscriptionRepo{DB: db}
+}
+
+func (r *SubscriptionRepo) CreateSubscription(subscription *models.Subscription) error {
+	return r.DB.Create(subscription).Error
+}
+
+func (r *SubscriptionRepo) SubscriptionUrlExists(scope Scope, url string) (bool, error) {
+	var count int64
+	err := scope.Apply(r.DB.Model(&models.Subscription{}), "").Where("url = ?", url).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *SubscriptionRepo) GetSubscriptions(scope Scope) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	err := scope.Apply(r.DB, "").Order("created_at DESC").Find(&subscriptions).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *SubscriptionRepo) GetSubscription(scope Scope, id int64) (models.Subscription, error) {
+	var subscription models.Subscription
+	err := scope.Apply(r.DB, "").Where("id = ?", id).First(&subscription).Error
+	return subscription, err
+}
+
+// UpdateSubscriptionSettings saves the filters and whether the subscription
+// is paused. Resuming it polls it right away.
+func (r *SubscriptionRepo) UpdateSubscriptionSettings(subscription models.Subscription) error {
+	return r.DB.Model(&models.Subscription{}).Where("id = ?", subscription.Id).Updates(map[string]any{
+		"keywords":     subscription.Keywords,
+		"match_tags":   subscription.MatchTags,
+		"paused_at":    subscription.PausedAt,
+		"next_poll_at": subscription.NextPollAt,
+	}).Error
+}
+
+func (r *SubscriptionRepo) DeleteSubscription(scope Scope, id int64) (bool, error) {
+	result := scope.Apply(r.DB, "").Where("id = ?", id).Delete(&models.Subscription{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ClaimDueSubscription takes the subscription that has been due the
+// longest and pushes its next poll back by lease, so nobody else polls it
+// meanwhile and it is picked up again if this poll never finishes.
+func (r *SubscriptionRepo) ClaimDueSubscription(now time.Time, lease time.Duration) (models.Subscription, bool, error) {
+	var subscription models.Subscription
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("paused_at IS NULL AND next_poll_at <= ?", now).
+			Order("next_poll_at").
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Take(&subscription).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&subscription).Update("next_poll_at", now.Add(lease)).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return subscription, false, nil
+	}
+	return subscription, err == nil, err
+}
+
+// FinishPoll records the outcome of a poll.
+func (r *SubscriptionRepo) FinishPoll(subscription models.Subscription) error {
+	return r.DB.Model(&models.Subscription{}).Where("id = ?", subscription.Id).Updates(map[string]any{
+		"title":          subscription.Title,
+		"e_tag":          subscription.ETag,
+		"last_modified":  subscription.LastModified,
+		"next_poll_at":   subscription.NextPollAt,
+		"last_polled_at": subscription.LastPolledAt,
+		"last_error":     subscription.LastError,
+		"failure_count":  subscription.FailureCount,
+		"paused_at":      subscription.PausedAt,
+	}).Error
+}
+
+// GetSeenUrls returns which of urls the subscription has already seen.
+func (r *SubscriptionRepo) GetSeenUrls(subscriptionId int64, urls []string) (map[string]bool, error) {
+	seen := map[string]bool{}
+	if len(urls) == 0 {
+		return seen, nil
+	}
+	var found []string
+	err := r.DB.Model(&models.SubscriptionEntry{}).
+		Where("subscription_id = ? AND url IN ?", subscriptionId, urls).
+		Pluck("url", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, url := range found {
+		seen[url] = true
+	}
+	return seen, nil
+}
+
+func (r *SubscriptionRepo) CreateSubscriptionEntry(entry *models.SubscriptionEntry) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(entry).Error
+}
+
+func (r *SubscriptionRepo) GetSubscriptionEntries(subscriptionId int64, limit int, offset int) ([]models.SubscriptionEntry, error) {
+	var entries []models.SubscriptionEntry
+	err := r.DB.Where("subscription_id = ?", subscriptionId).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
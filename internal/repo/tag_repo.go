@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/utilities"
+	"slices"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepo struct {
+	DB *gorm.DB
+}
+
+func NewTagRepo(db *gorm.DB) *TagRepo {
+	return &TagRepo{DB: db}
+}
+
+// replaceTagsExpr rewrites a text[] column, swapping any of the source tags for
+// the target and dropping the duplicates that creates while keeping tag order.
+const replaceTagsExpr = `ARRAY(
+	SELECT s.tag FROM (
+		SELECT CASE WHEN u.tag = ANY(?::text[]) THEN ?::text ELSE u.tag END AS tag, u.idx
+		FROM unnest(tags) WITH ORDINALITY AS u(tag, idx)
+	) s
+	GROUP BY s.tag
+	ORDER BY MIN(s.idx)
+)`
+
+func (r *TagRepo) GetUserTagNames(scope Scope) ([]string, error) {
+	var userTags []models.UserTags
+	err := scope.Apply(r.DB, "").Find(&userTags).Error
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, userTag := range userTags {
+		for _, tag := range userTag.Tags {
+			if !slices.Contains(names, tag) {
+				names = append(names, tag)
+			}
+		}
+	}
+	return names, nil
+}
+
+func (r *TagRepo) GetTagSynonyms(scope Scope) ([]models.TagSynonym, error) {
+	var synonyms []models.TagSynonym
+	err := scope.Apply(r.DB, "").Order("alias").Find(&synonyms).Error
+	if err != nil {
+		return nil, err
+	}
+	return synonyms, nil
+}
+
+func (r *TagRepo) GetTagSynonymMap(scope Scope) (map[string]string, error) {
+	synonyms, err := r.GetTagSynonyms(scope)
+	if err != nil {
+		return nil, err
+	}
+	synonymMap := make(map[string]string, len(synonyms))
+	for _, synonym := range synonyms {
+		synonymMap[synonym.Alias] = synonym.Tag
+	}
+	return synonymMap, nil
+}
+
+func (r *TagRepo) AddTagSynonym(scope Scope, alias string, tag string) error {
+	return addTagSynonym(r.DB, scope, alias, tag)
+}
+
+func addTagSynonym(db *gorm.DB, scope Scope, alias string, tag string) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "workspace_id"}, {Name: "alias"}},
+		DoUpdates: clause.AssignmentColumns([]string{"tag", "updated_at"}),
+	}).Create(&models.TagSynonym{
+		UserId:      scope.OwnerId(),
+		WorkspaceId: scope.WorkspaceId,
+		Alias:       utilities.TagKey(alias),
+		Tag:         tag,
+	}).Error
+}
+
+// MergeTags rewrites every post and user_tags row of the scope so the source
+// tags become the target, and records the sources as synonyms of the target
+// so future saves land on the same tag. Everything runs in one transaction,
+// and the rewritten posts are returned.
+func (r *TagRepo) MergeTags(scope Scope, sources []string, target string) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		sourceArray := pq.StringArray(sources)
+
+		err := scope.Apply(tx.Model(&posts), "").
+			Clauses(clause.Returning{}).
+			Where("tags && ?", sourceArray).
+			Update("tags", gorm.Expr(replaceTagsExpr, sourceArray, target)).Error
+		if err != nil {
+			return err
+		}
+
+		err = scope.Apply(tx.Model(&models.UserTags{}), "").
+			Where("tags && ?", sourceArray).
+			Update("tags", gorm.Expr(replaceTagsExpr, sourceArray, target)).Error
+		if err != nil {
+			return err
+		}
+
+		err = scope.Apply(tx.Model(&models.TagSynonym{}), "").
+			Where("tag IN ?", sources).
+			Update("tag", target).Error
+		if err != nil {
+			return err
+		}
+
+		targetKey := utilities.TagKey(target)
+		for _, source := range sources {
+			if utilities.TagKey(source) == targetKey {
+				continue
+			}
+			err = addTagSynonym(tx, scope, source, target)
+			if err != nil {
+				return err
+			}
+		}
+		// The target may have been a synonym of something else before.
+		err = scope.Apply(tx, "").Where("alias = ?", targetKey).Delete(&models.TagSynonym{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.AllTags{Tag: target}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r *TagRepo) GetTagAggregates(query AggregateQuery) ([]PostAggregate, error) {
+	return aggregatePosts(r.DB, "t.tag", "CROSS JOIN LATERAL unnest(posts.tags) AS t(tag)", query)
+}
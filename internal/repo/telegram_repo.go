@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"errors"
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidLinkCode = errors.New("link code is invalid or expired")
+
+type TelegramRepo struct {
+	DB *gorm.DB
+}
+
+func NewTelegramRepo(db *gorm.DB) *TelegramRepo {
+	return &TelegramRepo{DB: db}
+}
+
+func (r *TelegramRepo) CreateLinkCode(code *models.TelegramLinkCode) error {
+	return r.DB.Create(code).Error
+}
+
+// RedeemLinkCode consumes the code and links the chat to the code's owner in
+// one transaction. A chat that was linked to someone else is moved over.
+func (r *TelegramRepo) RedeemLinkCode(codeHash string, chatId int64, username string) (models.TelegramLink, error) {
+	var link models.TelegramLink
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var code models.TelegramLinkCode
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("code_hash = ? AND used_at IS NULL AND expires_at > ?", codeHash, time.Now()).
+			First(&code).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidLinkCode
+			}
+			return err
+		}
+		err = tx.Model(&code).Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		link = models.TelegramLink{
+			ChatId:   chatId,
+			UserId:   code.UserId,
+			Username: username,
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "chat_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_id", "username", "created_at"}),
+		}).Create(&link).Error
+	})
+	return link, err
+}
+
+func (r *TelegramRepo) GetLinkByChatId(chatId int64) (models.TelegramLink, error) {
+	var link models.TelegramLink
+	err := r.DB.Where("chat_id = ?", chatId).First(&link).Error
+	return link, err
+}
+
+func (r *TelegramRepo) GetUserLinks(userId int64) ([]models.TelegramLink, error) {
+	var links []models.TelegramLink
+	err := r.DB.Where("user_id = ?", userId).Order("created_at DESC").Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *TelegramRepo) DeleteLink(userId int64, chatId int64) (bool, error) {
+	result := r.DB.Where("user_id = ? AND chat_id = ?", userId, chatId).Delete(&models.TelegramLink{})
+	return result.RowsAffected > 0, result.Error
+}
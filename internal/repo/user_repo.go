@@ -2,6 +2,7 @@ package repo
 
 import (
 	"module/lynkbin/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -32,10 +33,79 @@ func (r *UserRepo) CreateUser(user *models.User) error {
 	return r.DB.Table(user.TableName()).Create(user).Error
 }
 
-func (r *UserRepo) GetUserIdByEmail(email string) (int64, error) {
-	var user models.User
-	if err := r.DB.Table(user.TableName()).Where("email=?", email).Select("id").First(&user).Error; err != nil {
-		return 0, err
+func (r *UserRepo) CreateUserToken(token *models.UserToken) error {
+	// Tokens nobody used would otherwise pile up.
+	err := r.DB.Where("user_id = ? AND expires_at < ?", token.UserId, time.Now()).Delete(&models.UserToken{}).Error
+	if err != nil {
+		return err
 	}
-	return *user.Id, nil
+	return r.DB.Create(token).Error
+}
+
+// HasRecentUserToken reports whether a token for purpose was issued after
+// since, to keep the same inbox from being flooded.
+func (r *UserRepo) HasRecentUserToken(userId int64, purpose string, since time.Time) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userId, purpose, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// consumeUserToken marks a token used, reporting false when it is unknown,
+// expired or was used already.
+func consumeUserToken(tx *gorm.DB, tokenId string, userId int64, purpose string) (bool, error) {
+	now := time.Now()
+	result := tx.Model(&models.UserToken{}).
+		Where("id = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenId, userId, purpose, now).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *UserRepo) VerifyEmail(tokenId string, userId int64) (bool, error) {
+	verified := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		consumed, err := consumeUserToken(tx, tokenId, userId, models.UserTokenVerifyEmail)
+		if err != nil || !consumed {
+			return err
+		}
+		verified = true
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", userId).
+			Update("email_verified_at", time.Now()).Error
+	})
+	return verified, err
+}
+
+// ResetPassword sets a new password with a reset token. Other reset tokens
+// still out there are voided and every session is signed out, so whoever
+// had access to the account before the reset loses it. Receiving the token
+// also proves the user owns the address.
+func (r *UserRepo) ResetPassword(tokenId string, userId int64, passwordHash string) (bool, error) {
+	reset := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		consumed, err := consumeUserToken(tx, tokenId, userId, models.UserTokenResetPassword)
+		if err != nil || !consumed {
+			return err
+		}
+		reset = true
+		now := time.Now()
+		err = tx.Model(&models.User{}).Where("id = ?", userId).Updates(map[string]any{
+			"password":          passwordHash,
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", now),
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userId, models.UserTokenResetPassword).
+			Update("used_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userId).
+			Update("revoked_at", now).Error
+	})
+	return reset, err
 }
@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"errors"
+	"module/lynkbin/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepo struct {
+	DB *gorm.DB
+}
+
+func NewWebhookRepo(db *gorm.DB) *WebhookRepo {
+	return &WebhookRepo{DB: db}
+}
+
+func (r *WebhookRepo) CreateWebhook(webhook *models.Webhook) error {
+	return r.DB.Create(webhook).Error
+}
+
+func (r *WebhookRepo) GetWebhooks(scope Scope) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := scope.Apply(r.DB, "").Order("created_at DESC").Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *WebhookRepo) GetWebhook(scope Scope, id int64) (models.Webhook, error) {
+	var webhook models.Webhook
+	err := scope.Apply(r.DB, "").Where("id = ?", id).First(&webhook).Error
+	return webhook, err
+}
+
+func (r *WebhookRepo) UpdateWebhook(webhook models.Webhook) error {
+	return r.DB.Model(&models.Webhook{}).Where("id = ?", webhook.Id).Updates(map[string]any{
+		"url":         webhook.Url,
+		"description": webhook.Description,
+		"events":      webhook.Events,
+		"disabled_at": webhook.DisabledAt,
+	}).Error
+}
+
+func (r *WebhookRepo) DeleteWebhook(scope Scope, id int64) (bool, error) {
+	result := scope.Apply(r.DB, "").Where("id = ?", id).Delete(&models.Webhook{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// QueueEvent queues a delivery of the event to every enabled webhook of the
+// scope subscribed to it.
+func (r *WebhookRepo) QueueEvent(scope Scope, eventId string, event string, payload string) error {
+	var webhookIds []int64
+	err := scope.Apply(r.DB.Model(&models.Webhook{}), "").
+		Where("disabled_at IS NULL AND ? = ANY(events)", event).
+		Pluck("id", &webhookIds).Error
+	if err != nil || len(webhookIds) == 0 {
+		return err
+	}
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(webhookIds))
+	for _, webhookId := range webhookIds {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookId:     webhookId,
+			EventId:       eventId,
+			Event:         event,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	return r.DB.Create(&deliveries).Error
+}
+
+func (r *WebhookRepo) CreateDelivery(delivery *models.WebhookDelivery) error {
+	return r.DB.Create(delivery).Error
+}
+
+func (r *WebhookRepo) GetDeliveries(webhookId int64, status string, limit int, offset int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := r.DB.Where("webhook_id = ?", webhookId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepo) GetDelivery(webhookId int64, id int64) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.DB.Where("webhook_id = ? AND id = ?", webhookId, id).First(&delivery).Error
+	return delivery, err
+}
+
+// ClaimDueDelivery takes the delivery that has waited longest and pushes
+// its next attempt back by lease, so it is retried if this attempt never
+// finishes. Deliveries of disabled webhooks wait until they are enabled.
+func (r *WebhookRepo) ClaimDueDelivery(now time.Time, lease time.Duration) (models.WebhookDelivery, models.Webhook, bool, error) {
+	var delivery models.WebhookDelivery
+	var webhook models.Webhook
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.WebhookDelivery{}).
+			Select("webhook_deliveries.*").
+			Joins("JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id").
+			Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ? AND webhooks.disabled_at IS NULL", models.WebhookDeliveryPending, now).
+			Order("webhook_deliveries.next_attempt_at").
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "webhook_deliveries"}, Options: "SKIP LOCKED"}).
+			Take(&delivery).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&delivery).Update("next_attempt_at", now.Add(lease)).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ?", delivery.WebhookId).First(&webhook).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return delivery, webhook, false, nil
+	}
+	return delivery, webhook, err == nil, err
+}
+
+// FinishDeliveryAttempt records how an attempt went.
+func (r *WebhookRepo) FinishDeliveryAttempt(delivery models.WebhookDelivery) error {
+	return r.DB.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.Id).Updates(map[string]any{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"status_code":     delivery.StatusCode,
+		"error":           delivery.Error,
+		"delivered_at":    delivery.DeliveredAt,
+	}).Error
+}
@@ -0,0 +1,247 @@
+package repo
+
+import (
+	"errors"
+	"module/lynkbin/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrLastOwner           = errors.New("a workspace needs at least one owner")
+	ErrInvalidInvite       = errors.New("invite is invalid or expired")
+	ErrInviteEmailMismatch = errors.New("invite was sent to a different email")
+	ErrAlreadyMember       = errors.New("user is already a member of the workspace")
+)
+
+type WorkspaceRepo struct {
+	DB *gorm.DB
+}
+
+func NewWorkspaceRepo(db *gorm.DB) *WorkspaceRepo {
+	return &WorkspaceRepo{DB: db}
+}
+
+type WorkspaceSummary struct {
+	models.Workspace
+	Role        string `json:"role"`
+	MemberCount int64  `json:"member_count"`
+}
+
+type WorkspaceMemberProfile struct {
+	models.WorkspaceMember
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// workspaceTables hold library rows keyed by workspace_id and have to be
+// cleared when a workspace is deleted.
+var workspaceTables = []any{
+	&models.Webhook{},
+	&models.Subscription{},
+	&models.Feed{},
+	&models.ImportJob{},
+	&models.ShareLink{},
+	&models.Collection{},
+	&models.Post{},
+	&models.Author{},
+	&models.UserAuthor{},
+	&models.UserTags{},
+	&models.TagSynonym{},
+	&models.UserCategories{},
+	&models.CategoryNode{},
+}
+
+// CreateWorkspace stores the workspace and makes its creator the owner.
+func (r *WorkspaceRepo) CreateWorkspace(workspace *models.Workspace) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(workspace).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&models.WorkspaceMember{
+			WorkspaceId: workspace.Id,
+			UserId:      workspace.CreatedBy,
+			Role:        models.WorkspaceRoleOwner,
+		}).Error
+	})
+}
+
+func (r *WorkspaceRepo) GetWorkspaces(userId int64) ([]WorkspaceSummary, error) {
+	var workspaces []WorkspaceSummary
+	err := r.DB.Model(&models.Workspace{}).
+		Select("workspaces.*, me.role AS role, (SELECT COUNT(*) FROM workspace_members m WHERE m.workspace_id = workspaces.id) AS member_count").
+		Joins("JOIN workspace_members me ON me.workspace_id = workspaces.id AND me.user_id = ?", userId).
+		Order("workspaces.name").
+		Scan(&workspaces).Error
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+func (r *WorkspaceRepo) GetWorkspace(id int64) (models.Workspace, error) {
+	var workspace models.Workspace
+	err := r.DB.Where("id = ?", id).First(&workspace).Error
+	return workspace, err
+}
+
+func (r *WorkspaceRepo) UpdateWorkspace(workspace *models.Workspace) error {
+	return r.DB.Model(workspace).Update("name", workspace.Name).Error
+}
+
+// DeleteWorkspace removes the workspace together with everything saved in
+// it. Members and invites go with the workspace through their foreign keys.
+func (r *WorkspaceRepo) DeleteWorkspace(id int64) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, table := range workspaceTables {
+			err := tx.Where("workspace_id = ?", id).Delete(table).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", id).Delete(&models.Workspace{}).Error
+	})
+}
+
+func (r *WorkspaceRepo) GetMembership(workspaceId int64, userId int64) (models.WorkspaceMember, error) {
+	var member models.WorkspaceMember
+	err := r.DB.Where("workspace_id = ? AND user_id = ?", workspaceId, userId).First(&member).Error
+	return member, err
+}
+
+func (r *WorkspaceRepo) GetMembers(workspaceId int64) ([]WorkspaceMemberProfile, error) {
+	var members []WorkspaceMemberProfile
+	err := r.DB.Model(&models.WorkspaceMember{}).
+		Select("workspace_members.*, users.name AS name, users.email AS email").
+		Joins("JOIN users ON users.id = workspace_members.user_id").
+		Where("workspace_members.workspace_id = ?", workspaceId).
+		Order("workspace_members.created_at").
+		Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// lockMember loads a membership for update and fails with ErrLastOwner if
+// it is the workspace's only owner, since the caller is about to demote or
+// remove it.
+func lockMember(tx *gorm.DB, workspaceId int64, userId int64) (models.WorkspaceMember, error) {
+	var members []models.WorkspaceMember
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("workspace_id = ?", workspaceId).
+		Find(&members).Error
+	if err != nil {
+		return models.WorkspaceMember{}, err
+	}
+	owners := 0
+	var target *models.WorkspaceMember
+	for i := range members {
+		if members[i].Role == models.WorkspaceRoleOwner {
+			owners++
+		}
+		if members[i].UserId == userId {
+			target = &members[i]
+		}
+	}
+	if target == nil {
+		return models.WorkspaceMember{}, gorm.ErrRecordNotFound
+	}
+	if target.Role == models.WorkspaceRoleOwner && owners == 1 {
+		return *target, ErrLastOwner
+	}
+	return *target, nil
+}
+
+func (r *WorkspaceRepo) UpdateMemberRole(workspaceId int64, userId int64, role string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		member, err := lockMember(tx, workspaceId, userId)
+		if errors.Is(err, ErrLastOwner) && role == models.WorkspaceRoleOwner {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&member).Update("role", role).Error
+	})
+}
+
+func (r *WorkspaceRepo) RemoveMember(workspaceId int64, userId int64) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		member, err := lockMember(tx, workspaceId, userId)
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&member).Error
+	})
+}
+
+func (r *WorkspaceRepo) CreateInvite(invite *models.WorkspaceInvite) error {
+	return r.DB.Create(invite).Error
+}
+
+// GetPendingInvites lists the invites that can still be accepted.
+func (r *WorkspaceRepo) GetPendingInvites(workspaceId int64) ([]models.WorkspaceInvite, error) {
+	var invites []models.WorkspaceInvite
+	err := r.DB.Where("workspace_id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", workspaceId, time.Now()).
+		Order("created_at DESC").Find(&invites).Error
+	if err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+func (r *WorkspaceRepo) RevokeInvite(workspaceId int64, id int64) (bool, error) {
+	result := r.DB.Model(&models.WorkspaceInvite{}).
+		Where("id = ? AND workspace_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id, workspaceId).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// AcceptInvite consumes the invite and adds the user to the workspace in one
+// transaction. Invites are bound to the email they were sent to.
+func (r *WorkspaceRepo) AcceptInvite(tokenHash string, user models.User) (models.WorkspaceMember, error) {
+	var member models.WorkspaceMember
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var invite models.WorkspaceInvite
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+			First(&invite).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidInvite
+			}
+			return err
+		}
+		if !strings.EqualFold(strings.TrimSpace(invite.Email), strings.TrimSpace(user.Email)) {
+			return ErrInviteEmailMismatch
+		}
+
+		var count int64
+		err = tx.Model(&models.WorkspaceMember{}).
+			Where("workspace_id = ? AND user_id = ?", invite.WorkspaceId, *user.Id).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAlreadyMember
+		}
+
+		err = tx.Model(&invite).Update("accepted_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		member = models.WorkspaceMember{
+			WorkspaceId: invite.WorkspaceId,
+			UserId:      *user.Id,
+			Role:        invite.Role,
+		}
+		return tx.Create(&member).Error
+	})
+	return member, err
+}
@@ -0,0 +1,119 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// AuthorProfile identifies who wrote a post. The handle is the stable part
+// (taken from URLs where possible); the display name may change over time.
+type AuthorProfile struct {
+	Handle      string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	ProfileURL  string `json:"profile_url"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+// extractXHandle reads the handle from a status URL such as
+// https://x.com/<handle>/status/<id>.
+func extractXHandle(postURL string) string {
+	u, err := url.Parse(postURL)
+	if err != nil {
+		return ""
+	}
+	pathParts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(pathParts) < 2 || pathParts[1] != "status" || pathParts[0] == "i" {
+		return ""
+	}
+	return pathParts[0]
+}
+
+func extractXProfile(page playwright.Page, postURL string, displayName string) AuthorProfile {
+	handle := extractXHandle(postURL)
+	if handle == "" {
+		handle = extractXHandle(page.URL())
+	}
+	profile := AuthorProfile{
+		Handle:      handle,
+		DisplayName: displayName,
+	}
+	if handle != "" {
+		profile.ProfileURL = fmt.Sprintf("https://x.com/%s", handle)
+	}
+
+	avatarLocator := page.Locator("article[data-testid='tweet'] img[src*='profile_images']").First()
+	if count, _ := avatarLocator.Count(); count > 0 {
+		profile.AvatarURL, _ = avatarLocator.GetAttribute("src")
+	}
+	return profile
+}
+
+// extractLinkedInHandle reads the vanity name from a post URL such as
+// https://www.linkedin.com/posts/<handle>_<slug>-activity-<id>.
+func extractLinkedInHandle(postURL string) string {
+	u, err := url.Parse(postURL)
+	if err != nil {
+		return ""
+	}
+	parts := strings.SplitN(u.Path, "/posts/", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	handle, _, found := strings.Cut(parts[1], "_")
+	if !found {
+		return ""
+	}
+	return handle
+}
+
+func extractLinkedInProfile(page playwright.Page, postURL string, displayName string) AuthorProfile {
+	handle := extractLinkedInHandle(postURL)
+	profile := AuthorProfile{
+		Handle:      handle,
+		DisplayName: displayName,
+	}
+	if handle != "" {
+		profile.ProfileURL = fmt.Sprintf("https://www.linkedin.com/in/%s", handle)
+	}
+
+	avatarLocator := page.Locator("img.update-components-actor__avatar-image").First()
+	if count, _ := avatarLocator.Count(); count > 0 {
+		profile.AvatarURL, _ = avatarLocator.GetAttribute("src")
+	}
+	return profile
+}
+
+func redditProfile(author string) AuthorProfile {
+	if author == "" {
+		return AuthorProfile{}
+	}
+	return AuthorProfile{
+		Handle:      author,
+		DisplayName: author,
+		ProfileURL:  fmt.Sprintf("https://www.reddit.com/user/%s", author),
+	}
+}
+
+func extractInstagramProfile(htmlStr string, displayName string) AuthorProfile {
+	profile := AuthorProfile{DisplayName: displayName}
+
+	usernamePattern := regexp.MustCompile(`"username":"([^"]+)"`)
+	if matches := usernamePattern.FindStringSubmatch(htmlStr); len(matches) > 1 {
+		profile.Handle = matches[1]
+		profile.ProfileURL = fmt.Sprintf("https://www.instagram.com/%s/", profile.Handle)
+	}
+
+	avatarPattern := regexp.MustCompile(`"profile_pic_url":"([^"]+)"`)
+	if matches := avatarPattern.FindStringSubmatch(htmlStr); len(matches) > 1 {
+		profile.AvatarURL = strings.ReplaceAll(matches[1], `\/`, `/`)
+	}
+
+	if profile.DisplayName == "" || profile.DisplayName == "Unknown" {
+		profile.DisplayName = profile.Handle
+	}
+	return profile
+}
@@ -20,14 +20,16 @@ import (
 )
 
 type InstagramScrapedPost struct {
-	Author string      `json:"author"`
-	Data   []dto.Media `json:"data"`
+	Author  string        `json:"author"`
+	Profile AuthorProfile `json:"profile"`
+	Data    []dto.Media   `json:"data"`
 }
 
 type ScrapedPost struct {
-	Author  string `json:"author"`
-	Content string `json:"content"`
-	Topic   string `json:"topic"`
+	Author  string        `json:"author"`
+	Profile AuthorProfile `json:"profile"`
+	Content string        `json:"content"`
+	Topic   string        `json:"topic"`
 }
 
 func extractAuthorFromInstagramURL(link string) string {
@@ -212,6 +214,7 @@ func ScrapeLinkedInPost(url string, proxy string) (ScrapedPost, error) {
 	fmt.Printf("author is %s", author)
 	post := ScrapedPost{
 		Author:  clean(author),
+		Profile: extractLinkedInProfile(page, url, clean(author)),
 		Content: clean(description),
 	}
 
@@ -361,6 +364,7 @@ func ScrapeXPost(url string, proxy string) (ScrapedPost, error) {
 	post := ScrapedPost{
 		Content: strings.TrimSpace(tweetText),
 		Author:  author,
+		Profile: extractXProfile(page, url, author),
 	}
 
 	fmt.Printf("Author of tweet is %s: ", author)
@@ -535,6 +539,7 @@ func ScrapeRedditPost(url string, proxy string) (ScrapedPost, error) {
 	post := ScrapedPost{
 		Content: clean(fullContent),
 		Author:  clean(author),
+		Profile: redditProfile(clean(author)),
 	}
 
 	fmt.Println("Reddit scraping completed successfully!")
@@ -621,6 +626,7 @@ func ScrapeInstagramPost(postURL string, config *InstagramScraperConfig) (Instag
 	if err != nil {
 		return InstagramScrapedPost{}, err
 	}
+	scrapedPost.Profile = extractInstagramProfile(html, scrapedPost.Author)
 
 	fmt.Printf("Successfully scraped Instagram content\n")
 	return scrapedPost, nil
@@ -0,0 +1,111 @@
+package apikeys
+
+import (
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/auth"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type ApiKeyService struct {
+	apiKeyRepo    *repo.ApiKeyRepo
+	workspaceRepo *repo.WorkspaceRepo
+}
+
+func NewApiKeyService(apiKeyRepo *repo.ApiKeyRepo, workspaceRepo *repo.WorkspaceRepo) *ApiKeyService {
+	return &ApiKeyService{apiKeyRepo: apiKeyRepo, workspaceRepo: workspaceRepo}
+}
+
+// CreateApiKey returns the key once; only its hash is kept.
+func (s *ApiKeyService) CreateApiKey(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	var request dto.CreateApiKeyRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	if request.WorkspaceId != nil {
+		_, err = s.workspaceRepo.GetMembership(*request.WorkspaceId, userId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utilities.Response(ctx, 404, false, nil, "Workspace not found")
+				return
+			}
+			fmt.Println("Error getting workspace membership: ", err)
+			utilities.Response(ctx, 500, false, nil, "Failed to create API key")
+			return
+		}
+	}
+
+	key, prefix, err := auth.NewApiKey()
+	if err != nil {
+		fmt.Println("Error generating API key: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create API key")
+		return
+	}
+	apiKey := models.ApiKey{
+		UserId:      userId,
+		Name:        request.Name,
+		Prefix:      prefix,
+		KeyHash:     auth.HashToken(key),
+		Access:      request.Access,
+		WorkspaceId: request.WorkspaceId,
+	}
+	err = s.apiKeyRepo.CreateApiKey(&apiKey)
+	if err != nil {
+		fmt.Println("Error creating API key: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create API key")
+		return
+	}
+	utilities.Response(ctx, 201, true, gin.H{
+		"api_key": apiKey,
+		"key":     key,
+	}, "API key created successfully")
+}
+
+func (s *ApiKeyService) GetApiKeys(ctx *gin.Context) {
+	keys, err := s.apiKeyRepo.GetApiKeys(ctx.GetInt64("user_id"))
+	if err != nil {
+		fmt.Println("Error getting API keys: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get API keys")
+		return
+	}
+	utilities.Response(ctx, 200, true, keys, "API keys fetched successfully")
+}
+
+func (s *ApiKeyService) RevokeApiKey(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid API key ID")
+		return
+	}
+	revoked, err := s.apiKeyRepo.RevokeApiKey(ctx.GetInt64("user_id"), id)
+	if err != nil {
+		fmt.Println("Error revoking API key: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to revoke API key")
+		return
+	}
+	if !revoked {
+		utilities.Response(ctx, 404, false, nil, "API key not found")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "API key revoked successfully")
+}
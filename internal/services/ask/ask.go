@@ -0,0 +1,164 @@
+package ask
+
+import (
+	"fmt"
+	"module/lynkbin/internal/clients/gemini"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	defaultSourceLimit = 8
+	// sourceContentLimit caps how much of each post's content goes into the
+	// prompt.
+	sourceContentLimit = 1500
+	noSourcesAnswer    = "I couldn't find any saved posts about that."
+)
+
+var citationPattern = regexp.MustCompile(`\[#(\d+)\]`)
+
+type AskSource struct {
+	Id        int64     `json:"id"`
+	Topic     string    `json:"topic"`
+	Link      string    `json:"link"`
+	Platform  string    `json:"platform"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AskService struct {
+	postRepo     *repo.PostRepo
+	geminiClient *gemini.GeminiClient
+}
+
+func NewAskService(postRepo *repo.PostRepo, geminiClient *gemini.GeminiClient) *AskService {
+	return &AskService{postRepo: postRepo, geminiClient: geminiClient}
+}
+
+func formatSources(posts []repo.RankedPost) string {
+	var b strings.Builder
+	for _, post := range posts {
+		fmt.Fprintf(&b, "[#%d] %s\n", post.Id, post.Topic)
+		if post.Author != "" {
+			fmt.Fprintf(&b, "Author: %s (%s)\n", post.Author, post.Platform)
+		}
+		if post.Category != "" {
+			fmt.Fprintf(&b, "Category: %s\n", post.Category)
+		}
+		if len(post.Tags) > 0 {
+			fmt.Fprintf(&b, "Tags: %s\n", strings.Join(post.Tags, ", "))
+		}
+		if post.Description != "" {
+			fmt.Fprintf(&b, "Summary: %s\n", post.Description)
+		}
+		if post.Platform == "notes" {
+			content := []rune(post.Data)
+			if len(content) > sourceContentLimit {
+				content = content[:sourceContentLimit]
+			}
+			fmt.Fprintf(&b, "Content: %s\n", string(content))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// citedPostIds returns the sources the answer cites, in order of first
+// citation, ignoring ids the model made up.
+func citedPostIds(answer string, posts []repo.RankedPost) []int64 {
+	retrieved := map[int64]bool{}
+	for _, post := range posts {
+		retrieved[post.Id] = true
+	}
+	seen := map[int64]bool{}
+	cited := []int64{}
+	for _, match := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		id, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || !retrieved[id] || seen[id] {
+			continue
+		}
+		seen[id] = true
+		cited = append(cited, id)
+	}
+	return cited
+}
+
+// Ask answers a question from the user's saved posts. The reply is an SSE
+// stream: a "sources" event with the retrieved posts, "answer" events with
+// pieces of the answer as it is generated, then "done" with the full answer
+// and the ids of the posts it cites (or "error" if generation failed).
+func (s *AskService) Ask(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.AskRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultSourceLimit
+	}
+
+	posts, err := s.postRepo.SearchPosts(scope, request.Question, request.Limit)
+	if err != nil {
+		fmt.Println("Error searching posts: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to search posts")
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(200)
+
+	sources := make([]AskSource, 0, len(posts))
+	for _, post := range posts {
+		sources = append(sources, AskSource{
+			Id:        post.Id,
+			Topic:     post.Topic,
+			Link:      post.OriginalLink(),
+			Platform:  post.Platform,
+			Author:    post.Author,
+			CreatedAt: post.CreatedAt,
+		})
+	}
+	send := func(event string, data any) error {
+		ctx.SSEvent(event, data)
+		ctx.Writer.Flush()
+		return ctx.Request.Context().Err()
+	}
+	send("sources", gin.H{"sources": sources})
+
+	if len(posts) == 0 {
+		send("answer", gin.H{"text": noSourcesAnswer})
+		send("done", gin.H{"answer": noSourcesAnswer, "citations": []int64{}})
+		return
+	}
+
+	prompt := utilities.GenerateAskPrompt(request.Question, formatSources(posts))
+	answer, err := s.geminiClient.GenerateContentStream(ctx.Request.Context(), prompt, func(chunk string) error {
+		return send("answer", gin.H{"text": chunk})
+	})
+	if err != nil {
+		if ctx.Request.Context().Err() == nil {
+			fmt.Println("Error generating answer: ", err)
+			send("error", gin.H{"message": "Failed to generate an answer"})
+		}
+		return
+	}
+	send("done", gin.H{"answer": answer, "citations": citedPostIds(answer, posts)})
+}
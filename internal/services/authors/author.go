@@ -0,0 +1,191 @@
+package authors
+
+import (
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/events"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/services/posts"
+	"module/lynkbin/internal/utilities"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type AuthorService struct {
+	authorRepo *repo.AuthorRepo
+	eventBus   *events.Bus
+}
+
+func NewAuthorService(authorRepo *repo.AuthorRepo, eventBus *events.Bus) *AuthorService {
+	return &AuthorService{authorRepo: authorRepo, eventBus: eventBus}
+}
+
+func (s *AuthorService) GetAuthors(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.AggregateRequest
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		fmt.Println("Error binding query parameters: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request")
+		return
+	}
+
+	authors, err := s.authorRepo.GetAuthorAggregates(repo.AggregateQuery{
+		Scope:    scope,
+		Platform: request.Platform,
+		Search:   strings.TrimSpace(request.Q),
+		Sort:     request.Sort,
+		Limit:    request.Limit,
+	})
+	if err != nil {
+		fmt.Println("Error getting authors: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get authors")
+		return
+	}
+	utilities.Response(ctx, 200, true, authors, "Authors fetched successfully")
+}
+
+func (s *AuthorService) GetAuthorProfiles(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	err := s.authorRepo.BackfillAuthors(scope)
+	if err != nil {
+		fmt.Println("Error backfilling authors: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get authors")
+		return
+	}
+	profiles, err := s.authorRepo.GetAuthorProfiles(scope, ctx.Query("platform"))
+	if err != nil {
+		fmt.Println("Error getting author profiles: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get authors")
+		return
+	}
+	utilities.Response(ctx, 200, true, profiles, "Authors fetched successfully")
+}
+
+func (s *AuthorService) GetAuthor(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid author ID")
+		return
+	}
+
+	author, err := s.authorRepo.GetCanonicalAuthor(scope, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Author not found")
+			return
+		}
+		fmt.Println("Error getting author: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get author")
+		return
+	}
+	aliases, err := s.authorRepo.GetMergedAuthors(scope, author.Id)
+	if err != nil {
+		fmt.Println("Error getting merged authors: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get author")
+		return
+	}
+	posts, err := s.authorRepo.GetAuthorPosts(scope, author.Id)
+	if err != nil {
+		fmt.Println("Error getting author posts: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get author")
+		return
+	}
+	tagStats, err := s.authorRepo.GetAuthorTagStats(scope, author.Id)
+	if err != nil {
+		fmt.Println("Error getting author tag stats: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get author")
+		return
+	}
+	categoryStats, err := s.authorRepo.GetAuthorCategoryStats(scope, author.Id)
+	if err != nil {
+		fmt.Println("Error getting author category stats: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get author")
+		return
+	}
+
+	utilities.Response(ctx, 200, true, gin.H{
+		"author":         author,
+		"aliases":        aliases,
+		"post_count":     len(posts),
+		"posts":          posts,
+		"tag_stats":      tagStats,
+		"category_stats": categoryStats,
+	}, "Author fetched successfully")
+}
+
+func (s *AuthorService) MergeAuthors(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.MergeAuthorsRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	target, err := s.authorRepo.GetCanonicalAuthor(scope, request.TargetId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Target author not found")
+			return
+		}
+		fmt.Println("Error getting target author: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to merge authors")
+		return
+	}
+
+	sourceIds := []int64{}
+	for _, sourceId := range request.SourceIds {
+		source, err := s.authorRepo.GetAuthor(scope, sourceId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utilities.Response(ctx, 404, false, nil, fmt.Sprintf("Author %d not found", sourceId))
+				return
+			}
+			fmt.Println("Error getting source author: ", err)
+			utilities.Response(ctx, 500, false, nil, "Failed to merge authors")
+			return
+		}
+		if source.Id == target.Id || slices.Contains(sourceIds, source.Id) {
+			continue
+		}
+		if source.Platform != target.Platform {
+			utilities.Response(ctx, 400, false, nil, "Authors from different platforms cannot be merged")
+			return
+		}
+		sourceIds = append(sourceIds, source.Id)
+	}
+	if len(sourceIds) == 0 {
+		utilities.Response(ctx, 400, false, nil, "Nothing to merge")
+		return
+	}
+
+	moved, err := s.authorRepo.MergeAuthors(scope, sourceIds, target)
+	if err != nil {
+		fmt.Println("Error merging authors: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to merge authors")
+		return
+	}
+	for _, post := range moved {
+		s.eventBus.Publish(events.New(events.PostUpdated, scope.UserId, post.WorkspaceId, posts.PostEvent{Post: post}))
+	}
+	utilities.Response(ctx, 200, true, gin.H{"source_ids": sourceIds, "target_id": target.Id}, "Authors merged successfully")
+}
@@ -1,4 +1,506 @@
 package bot
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/services/posts"
+	"module/lynkbin/internal/services/reviews"
+	"module/lynkbin/internal/services/telegram"
+	"module/lynkbin/internal/utilities"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+	"gorm.io/gorm"
+)
+
+const (
+	recentPostsLimit = 5
+	searchPostsLimit = 10
+	topTagsLimit     = 15
+	undoHistoryLimit = 20
+)
+
+// BotConfig holds the Telegram settings. The bot long polls unless a webhook
+// URL is set, which then needs WebhookSecret to tell Telegram's updates from
+// forged ones. APIURL points the bot at another Bot API server, e.g. a local
+// one or a fake in tests.
+type BotConfig struct {
+	Token         string
+	APIURL        string
+	WebhookURL    string
+	WebhookSecret string
+	ProxyURL      string
+}
+
+func BotConfigFromEnv() BotConfig {
+	return BotConfig{
+		Token:         os.Getenv("TELEGRAM_BOTFATHER_TOKEN"),
+		APIURL:        os.Getenv("TELEGRAM_API_URL"),
+		WebhookURL:    os.Getenv("TELEGRAM_WEBHOOK_URL"),
+		WebhookSecret: os.Getenv("TELEGRAM_WEBHOOK_SECRET"),
+		ProxyURL:      os.Getenv("TELEGRAM_PROXY_URL"),
+	}
+}
+
 type BotService struct {
+	bot           *bot.Bot
+	config        BotConfig
+	postService   *posts.PostService
+	reviewService *reviews.ReviewService
+	postRepo      *repo.PostRepo
+	tagRepo       *repo.TagRepo
+	telegramRepo  *repo.TelegramRepo
+
+	// savedPosts remembers what each chat saved recently so /undo can remove
+	// it again.
+	savedPostsMu sync.Mutex
+	savedPosts   map[int64][]int64
+}
+
+func NewBotService(config BotConfig, postService *posts.PostService, reviewService *reviews.ReviewService, postRepo *repo.PostRepo, tagRepo *repo.TagRepo, telegramRepo *repo.TelegramRepo) (*BotService, error) {
+	// Without a secret anyone could post forged updates to the webhook
+	// route and act as any linked chat.
+	if config.WebhookURL != "" && config.WebhookSecret == "" {
+		return nil, errors.New("TELEGRAM_WEBHOOK_SECRET is required when TELEGRAM_WEBHOOK_URL is set")
+	}
+	s := &BotService{
+		config:        config,
+		postService:   postService,
+		reviewService: reviewService,
+		postRepo:      postRepo,
+		tagRepo:       tagRepo,
+		telegramRepo:  telegramRepo,
+		savedPosts:    map[int64][]int64{},
+	}
+
+	opts := []bot.Option{
+		bot.WithDefaultHandler(s.handleUpdate),
+		bot.WithCheckInitTimeout(30 * time.Second),
+		bot.WithHTTPClient(30*time.Second, utilities.CreateHTTPClientWithProxy(config.ProxyURL)),
+	}
+	if config.APIURL != "" {
+		opts = append(opts, bot.WithServerURL(config.APIURL))
+	}
+	if config.WebhookSecret != "" {
+		opts = append(opts, bot.WithWebhookSecretToken(config.WebhookSecret))
+	}
+
+	b, err := bot.New(config.Token, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.bot = b
+	return s, nil
+}
+
+func (s *BotService) IsWebhookMode() bool {
+	return s.config.WebhookURL != ""
+}
+
+// WebhookHandler receives updates pushed by Telegram in webhook mode.
+func (s *BotService) WebhookHandler() http.HandlerFunc {
+	return s.bot.WebhookHandler()
+}
+
+// Start processes updates until ctx is done, either from the webhook handler
+// or by long polling.
+func (s *BotService) Start(ctx context.Context) {
+	if s.IsWebhookMode() {
+		_, err := s.bot.SetWebhook(ctx, &bot.SetWebhookParams{
+			URL:         s.config.WebhookURL,
+			SecretToken: s.config.WebhookSecret,
+		})
+		if err != nil {
+			fmt.Println("Error setting telegram webhook: ", err)
+			return
+		}
+		s.bot.StartWebhook(ctx)
+		return
+	}
+
+	// Telegram refuses getUpdates while a webhook is set.
+	_, err := s.bot.DeleteWebhook(ctx, &bot.DeleteWebhookParams{})
+	if err != nil {
+		fmt.Println("Error deleting telegram webhook: ", err)
+	}
+	s.bot.Start(ctx)
+}
+
+func (s *BotService) handleUpdate(ctx context.Context, b *bot.Bot, update *tgmodels.Update) {
+	if update.InlineQuery != nil {
+		s.handleInlineQuery(ctx, update.InlineQuery)
+		return
+	}
+	if update.CallbackQuery != nil {
+		s.handleReviewAnswer(ctx, update.CallbackQuery)
+		return
+	}
+
+	message := update.Message
+	if message == nil {
+		return
+	}
+	chatId := message.Chat.ID
+
+	text := message.Text
+	entities := message.Entities
+	if text == "" {
+		text = message.Caption
+		entities = message.CaptionEntities
+	}
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+
+	command, args := parseCommand(text)
+	// A chat stands in for the account linked to it, so only private chats
+	// are served; in a group every member could act as whoever linked it.
+	if message.Chat.Type != tgmodels.ChatTypePrivate {
+		if command != "" {
+			s.reply(ctx, chatId, privateOnlyText)
+		}
+		return
+	}
+	switch command {
+	case "start", "link":
+		s.handleLink(ctx, message, args)
+		return
+	case "help":
+		s.reply(ctx, chatId, helpText)
+		return
+	}
+
+	link, err := s.telegramRepo.GetLinkByChatId(chatId)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Println("Error getting telegram link: ", err)
+			s.reply(ctx, chatId, "Something went wrong, please try again.")
+			return
+		}
+		s.reply(ctx, chatId, notLinkedText)
+		return
+	}
+	userId := link.UserId
+
+	switch command {
+	case "":
+		s.handleSave(ctx, chatId, userId, text, entities)
+	case "recent":
+		s.handleRecent(ctx, chatId, userId)
+	case "search":
+		s.handleSearch(ctx, chatId, userId, args)
+	case "tags":
+		s.handleTags(ctx, chatId, userId)
+	case "review":
+		s.handleReview(ctx, chatId, userId)
+	case "delete":
+		s.handleDelete(ctx, chatId, userId, args)
+	case "undo":
+		s.handleUndo(ctx, chatId, userId)
+	case "unlink":
+		s.handleUnlink(ctx, chatId, userId)
+	default:
+		s.reply(ctx, chatId, "Unknown command.\n\n"+helpText)
+	}
+}
+
+const helpText = `Send me a link or some text and I'll save it to Lynkbin.
+Type @ followed by my name in any chat to search and share your posts, e.g. "#go @author generics".
+
+/recent - your latest posts
+/search <words> - search your posts
+/tags - your most used tags
+/review - old posts due for another look
+/delete <id> - delete a post
+/undo - delete the post you just saved
+/unlink - disconnect this chat`
+
+const notLinkedText = `This chat isn't linked to a Lynkbin account yet.
+
+Create a link code from the Lynkbin dashboard and send it here as /link <code>.`
+
+const privateOnlyText = `I only work in private chats, so nobody else can use your Lynkbin account. Message me directly to save and browse your posts.`
+
+func (s *BotService) handleLink(ctx context.Context, message *tgmodels.Message, code string) {
+	chatId := message.Chat.ID
+	if code == "" || code == inlineStartParameter {
+		if _, err := s.telegramRepo.GetLinkByChatId(chatId); err == nil {
+			s.reply(ctx, chatId, helpText)
+			return
+		}
+		s.reply(ctx, chatId, notLinkedText)
+		return
+	}
+
+	username := ""
+	if message.From != nil {
+		username = message.From.Username
+	}
+	_, err := s.telegramRepo.RedeemLinkCode(telegram.HashLinkCode(code), chatId, username)
+	if err != nil {
+		if errors.Is(err, repo.ErrInvalidLinkCode) {
+			s.reply(ctx, chatId, "That link code is invalid or expired. Create a new one from the dashboard.")
+			return
+		}
+		fmt.Println("Error linking telegram chat: ", err)
+		s.reply(ctx, chatId, "Failed to link this chat, please try again.")
+		return
+	}
+	s.reply(ctx, chatId, "This chat is now linked to your Lynkbin account.\n\n"+helpText)
+}
+
+func (s *BotService) handleSave(ctx context.Context, chatId int64, userId int64, text string, entities []tgmodels.MessageEntity) {
+	request := dto.CreatePostRequest{Notes: strings.TrimSpace(text)}
+	if link := firstLink(text, entities); link != "" {
+		request = dto.CreatePostRequest{Url: link, IsUrl: true}
+	}
+
+	progress, err := s.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatId, Text: "Saving…"})
+	if err != nil {
+		fmt.Println("Error sending telegram message: ", err)
+	}
+
+	response, err := s.postService.SavePost(repo.PersonalScope(userId), request)
+	if err != nil {
+		fmt.Println("Error saving post from telegram: ", err)
+		text := "Failed to save this post, please try again."
+		var saveErr *posts.SavePostError
+		if errors.As(err, &saveErr) && saveErr.StatusCode < 500 {
+			text = "Couldn't save this post: " + saveErr.Message
+		}
+		s.replyOrEdit(ctx, chatId, progress, text)
+		return
+	}
+	s.rememberSavedPost(chatId, response.Id)
+
+	var reply strings.Builder
+	fmt.Fprintf(&reply, "Saved #%d", response.Id)
+	if response.Topic != "" {
+		fmt.Fprintf(&reply, ": %s", response.Topic)
+	}
+	if response.Category != "" {
+		fmt.Fprintf(&reply, "\nCategory: %s", response.Category)
+	}
+	if len(response.Tags) > 0 {
+		fmt.Fprintf(&reply, "\nTags: %s", strings.Join(response.Tags, ", "))
+	}
+	fmt.Fprintf(&reply, "\n%s\n\n/undo to remove it", response.PostLink)
+	s.replyOrEdit(ctx, chatId, progress, reply.String())
+}
+
+func (s *BotService) handleRecent(ctx context.Context, chatId int64, userId int64) {
+	posts, err := s.postRepo.GetPosts(repo.PostFilter{Scope: repo.PersonalScope(userId), Limit: recentPostsLimit})
+	if err != nil {
+		fmt.Println("Error getting recent posts: ", err)
+		s.reply(ctx, chatId, "Failed to get your recent posts.")
+		return
+	}
+	if len(posts) == 0 {
+		s.reply(ctx, chatId, "You haven't saved anything yet. Send me a link to get started.")
+		return
+	}
+	s.reply(ctx, chatId, "Your latest posts:\n\n"+formatPosts(repo.PlainPosts(posts)))
+}
+
+func (s *BotService) handleSearch(ctx context.Context, chatId int64, userId int64, query string) {
+	if query == "" {
+		s.reply(ctx, chatId, "Usage: /search <words>")
+		return
+	}
+	posts, err := s.postRepo.GetPosts(repo.PostFilter{Scope: repo.PersonalScope(userId), Query: query, Limit: searchPostsLimit})
+	if err != nil {
+		fmt.Println("Error searching posts: ", err)
+		s.reply(ctx, chatId, "Failed to search your posts.")
+		return
+	}
+	if len(posts) == 0 {
+		s.reply(ctx, chatId, fmt.Sprintf("No posts found for %q.", query))
+		return
+	}
+	s.reply(ctx, chatId, fmt.Sprintf("Posts matching %q:\n\n%s", query, formatPosts(repo.PlainPosts(posts))))
+}
+
+func (s *BotService) handleTags(ctx context.Context, chatId int64, userId int64) {
+	tags, err := s.tagRepo.GetTagAggregates(repo.AggregateQuery{Scope: repo.PersonalScope(userId), Limit: topTagsLimit})
+	if err != nil {
+		fmt.Println("Error getting tags: ", err)
+		s.reply(ctx, chatId, "Failed to get your tags.")
+		return
+	}
+	if len(tags) == 0 {
+		s.reply(ctx, chatId, "You don't have any tags yet.")
+		return
+	}
+	var reply strings.Builder
+	reply.WriteString("Your top tags:\n")
+	for _, tag := range tags {
+		fmt.Fprintf(&reply, "\n%s (%d)", tag.Name, tag.PostCount)
+	}
+	s.reply(ctx, chatId, reply.String())
+}
+
+func (s *BotService) handleDelete(ctx context.Context, chatId int64, userId int64, args string) {
+	postId, err := strconv.ParseInt(strings.TrimPrefix(args, "#"), 10, 64)
+	if err != nil {
+		s.reply(ctx, chatId, "Usage: /delete <id>")
+		return
+	}
+	s.deletePost(ctx, chatId, userId, postId)
+}
+
+func (s *BotService) handleUndo(ctx context.Context, chatId int64, userId int64) {
+	postId, ok := s.popSavedPost(chatId)
+	if !ok {
+		s.reply(ctx, chatId, "Nothing to undo.")
+		return
+	}
+	s.deletePost(ctx, chatId, userId, postId)
+}
+
+func (s *BotService) deletePost(ctx context.Context, chatId int64, userId int64, postId int64) {
+	_, err := s.postService.RemovePost(repo.PersonalScope(userId), postId)
+	if err != nil {
+		fmt.Println("Error deleting post: ", err)
+		if errors.Is(err, repo.ErrPostNotFound) {
+			s.reply(ctx, chatId, fmt.Sprintf("Post #%d not found.", postId))
+			return
+		}
+		s.reply(ctx, chatId, "Failed to delete the post.")
+		return
+	}
+	s.forgetSavedPost(chatId, postId)
+	s.reply(ctx, chatId, fmt.Sprintf("Deleted post #%d.", postId))
+}
+
+func (s *BotService) handleUnlink(ctx context.Context, chatId int64, userId int64) {
+	_, err := s.telegramRepo.DeleteLink(userId, chatId)
+	if err != nil {
+		fmt.Println("Error deleting telegram link: ", err)
+		s.reply(ctx, chatId, "Failed to unlink this chat.")
+		return
+	}
+	s.reply(ctx, chatId, "This chat is no longer linked to your Lynkbin account.")
+}
+
+// SendText sends a plain message to a chat, e.g. a digest.
+func (s *BotService) SendText(ctx context.Context, chatId int64, text string) error {
+	_, err := s.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatId, Text: text})
+	return err
+}
+
+func (s *BotService) reply(ctx context.Context, chatId int64, text string) {
+	_, err := s.bot.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatId, Text: text})
+	if err != nil {
+		fmt.Println("Error sending telegram message: ", err)
+	}
+}
+
+// replyOrEdit replaces the progress message with the final text, falling back
+// to a new message if there is nothing to edit.
+func (s *BotService) replyOrEdit(ctx context.Context, chatId int64, progress *tgmodels.Message, text string) {
+	if progress != nil {
+		_, err := s.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatId,
+			MessageID: progress.ID,
+			Text:      text,
+		})
+		if err == nil {
+			return
+		}
+		fmt.Println("Error editing telegram message: ", err)
+	}
+	s.reply(ctx, chatId, text)
+}
+
+func (s *BotService) rememberSavedPost(chatId int64, postId int64) {
+	s.savedPostsMu.Lock()
+	defer s.savedPostsMu.Unlock()
+	saved := append(s.savedPosts[chatId], postId)
+	if len(saved) > undoHistoryLimit {
+		saved = saved[len(saved)-undoHistoryLimit:]
+	}
+	s.savedPosts[chatId] = saved
+}
+
+func (s *BotService) popSavedPost(chatId int64) (int64, bool) {
+	s.savedPostsMu.Lock()
+	defer s.savedPostsMu.Unlock()
+	saved := s.savedPosts[chatId]
+	if len(saved) == 0 {
+		return 0, false
+	}
+	s.savedPosts[chatId] = saved[:len(saved)-1]
+	return saved[len(saved)-1], true
+}
+
+func (s *BotService) forgetSavedPost(chatId int64, postId int64) {
+	s.savedPostsMu.Lock()
+	defer s.savedPostsMu.Unlock()
+	saved := s.savedPosts[chatId]
+	for i, id := range saved {
+		if id == postId {
+			s.savedPosts[chatId] = append(saved[:i], saved[i+1:]...)
+			return
+		}
+	}
+}
+
+// parseCommand splits "/search@lynkbin_bot go generics" into "search" and
+// "go generics". Messages that are not commands return an empty command.
+func parseCommand(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", ""
+	}
+	command, args, _ := strings.Cut(text[1:], " ")
+	command, _, _ = strings.Cut(command, "@")
+	return strings.ToLower(command), strings.TrimSpace(args)
+}
+
+// firstLink returns the first URL in a message, whether typed out or hidden
+// behind link text. Entity offsets are in UTF-16 code units.
+func firstLink(text string, entities []tgmodels.MessageEntity) string {
+	encoded := utf16.Encode([]rune(text))
+	for _, entity := range entities {
+		switch entity.Type {
+		case tgmodels.MessageEntityTypeTextLink:
+			return entity.URL
+		case tgmodels.MessageEntityTypeURL:
+			if entity.Offset < 0 || entity.Offset+entity.Length > len(encoded) {
+				continue
+			}
+			link := string(utf16.Decode(encoded[entity.Offset : entity.Offset+entity.Length]))
+			if !strings.Contains(link, "://") {
+				link = "https://" + link
+			}
+			return link
+		}
+	}
+	return ""
+}
+
+func formatPosts(posts []models.Post) string {
+	lines := make([]string, 0, len(posts))
+	for _, post := range posts {
+		line := fmt.Sprintf("#%d %s (%s)", post.Id, post.Topic, post.Platform)
+		if post.Category != "" {
+			line += "\n" + post.Category
+		}
+		if len(post.Tags) > 0 {
+			line += "\nTags: " + strings.Join(post.Tags, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n\n")
 }
@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"module/lynkbin/internal/db/dbtest"
+	"module/lynkbin/internal/repo"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	tgmodels "github.com/go-telegram/bot/models"
+	"gorm.io/gorm"
+)
+
+type sentMessage struct {
+	ChatId string
+	Text   string
+}
+
+// fakeTelegram stands in for the Bot API server and records the messages
+// the bot sends.
+type fakeTelegram struct {
+	mu       sync.Mutex
+	messages []sentMessage
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	if method == "getMe" {
+		fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Lynkbin","username":"lynkbin_bot"}}`)
+		return
+	}
+	if method != "sendMessage" {
+		fmt.Fprint(w, `{"ok":true,"result":true}`)
+		return
+	}
+	err := r.ParseMultipartForm(1 << 20)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.messages = append(f.messages, sentMessage{ChatId: r.FormValue("chat_id"), Text: r.FormValue("text")})
+	f.mu.Unlock()
+	fmt.Fprintf(w, `{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":%s,"type":"private"}}}`, r.FormValue("chat_id"))
+}
+
+func (f *fakeTelegram) sent() []sentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentMessage(nil), f.messages...)
+}
+
+func newTestBot(t *testing.T) (*BotService, *fakeTelegram) {
+	t.Helper()
+	fake := &fakeTelegram{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s, err := NewBotService(BotConfig{Token: "123:test", APIURL: server.URL}, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewBotService: %v", err)
+	}
+	return s, fake
+}
+
+func TestHelpCommandReplies(t *testing.T) {
+	s, fake := newTestBot(t)
+
+	s.handleUpdate(context.Background(), s.bot, &tgmodels.Update{
+		Message: &tgmodels.Message{Chat: tgmodels.Chat{ID: 42, Type: tgmodels.ChatTypePrivate}, Text: "/help@lynkbin_bot"},
+	})
+
+	sent := fake.sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	if sent[0].ChatId != "42" || sent[0].Text != helpText {
+		t.Errorf("sent %+v, want the help text to chat 42", sent[0])
+	}
+}
+
+func TestGroupChatsAreRefused(t *testing.T) {
+	s, fake := newTestBot(t)
+	s.telegramRepo = repo.NewTelegramRepo(dbtest.New(t, func(tx *gorm.DB) {
+		t.Errorf("group message ran a query: %s", tx.Statement.SQL.String())
+	}))
+
+	for _, text := range []string{"/link 123456", "/recent", "https://example.com/article"} {
+		s.handleUpdate(context.Background(), s.bot, &tgmodels.Update{
+			Message: &tgmodels.Message{Chat: tgmodels.Chat{ID: -100, Type: tgmodels.ChatTypeSupergroup}, Text: text},
+		})
+	}
+
+	sent := fake.sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d messages, want a refusal to each of the 2 commands", len(sent))
+	}
+	for _, message := range sent {
+		if message.Text != privateOnlyText {
+			t.Errorf("sent %q, want the private chats only reply", message.Text)
+		}
+	}
+}
+
+func TestSendTextDeliversToChat(t *testing.T) {
+	s, fake := newTestBot(t)
+
+	err := s.SendText(context.Background(), 7, "Your Lynkbin digest")
+	if err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+
+	sent := fake.sent()
+	if len(sent) != 1 || sent[0] != (sentMessage{ChatId: "7", Text: "Your Lynkbin digest"}) {
+		t.Errorf("sent %+v, want the digest to chat 7", sent)
+	}
+}
+
+func TestWebhookModeRequiresSecret(t *testing.T) {
+	_, err := NewBotService(BotConfig{Token: "123:test", WebhookURL: "https://example.com/telegram/webhook"}, nil, nil, nil, nil, nil)
+	if err == nil {
+		t.Fatal("NewBotService accepted a webhook without a secret")
+	}
+}
@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+	"gorm.io/gorm"
+)
+
+const (
+	inlineResultsLimit = 20
+	// inlineStartParameter is sent with /start when someone taps the "link
+	// your account" button on inline results.
+	inlineStartParameter = "inline"
+)
+
+// parseInlineQuery splits an inline query such as "#go @rob_pike generics"
+// into tag, author and keyword filters.
+func parseInlineQuery(text string) (tags []string, author string, keywords string) {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		switch {
+		case strings.HasPrefix(word, "#") && len(word) > 1:
+			tags = append(tags, word[1:])
+		case strings.HasPrefix(word, "@") && len(word) > 1:
+			author = word[1:]
+		default:
+			words = append(words, word)
+		}
+	}
+	return tags, author, strings.Join(words, " ")
+}
+
+// handleInlineQuery answers "@lynkbin_bot <query>" typed in any chat with the
+// linked user's matching posts. Inline mode has no chat of its own, so the
+// user is found through the private chat they linked, whose id is their
+// Telegram user id. Inline mode must be enabled for the bot in BotFather.
+func (s *BotService) handleInlineQuery(ctx context.Context, inlineQuery *tgmodels.InlineQuery) {
+	if inlineQuery.From == nil {
+		return
+	}
+
+	link, err := s.telegramRepo.GetLinkByChatId(inlineQuery.From.ID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Println("Error getting telegram link: ", err)
+			return
+		}
+		s.answerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+			InlineQueryID: inlineQuery.ID,
+			Results:       []tgmodels.InlineQueryResult{},
+			IsPersonal:    true,
+			Button: &tgmodels.InlineQueryResultsButton{
+				Text:           "Link your Lynkbin account",
+				StartParameter: inlineStartParameter,
+			},
+		})
+		return
+	}
+
+	offset, _ := strconv.Atoi(inlineQuery.Offset)
+	tags, author, keywords := parseInlineQuery(inlineQuery.Query)
+	if len(tags) > 0 {
+		tags, err = s.postService.NormalizeTags(repo.PersonalScope(link.UserId), tags)
+		if err != nil {
+			fmt.Println("Error normalizing tags: ", err)
+			return
+		}
+	}
+
+	posts, err := s.postRepo.GetPosts(repo.PostFilter{
+		Scope:  repo.PersonalScope(link.UserId),
+		Tags:   tags,
+		Author: author,
+		Query:  keywords,
+		Limit:  inlineResultsLimit,
+		Offset: offset,
+	})
+	if err != nil {
+		fmt.Println("Error searching posts: ", err)
+		return
+	}
+
+	results := make([]tgmodels.InlineQueryResult, 0, len(posts))
+	for _, post := range posts {
+		results = append(results, inlineResult(post.Post))
+	}
+	nextOffset := ""
+	if len(posts) == inlineResultsLimit {
+		nextOffset = strconv.Itoa(offset + inlineResultsLimit)
+	}
+
+	s.answerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+		InlineQueryID: inlineQuery.ID,
+		Results:       results,
+		CacheTime:     10,
+		IsPersonal:    true,
+		NextOffset:    nextOffset,
+	})
+}
+
+func (s *BotService) answerInlineQuery(ctx context.Context, params *bot.AnswerInlineQueryParams) {
+	_, err := s.bot.AnswerInlineQuery(ctx, params)
+	if err != nil {
+		fmt.Println("Error answering inline query: ", err)
+	}
+}
+
+func inlineResult(post models.Post) *tgmodels.InlineQueryResultArticle {
+	title := post.Topic
+	if title == "" {
+		title = truncate(post.Data, 64)
+	}
+
+	parts := []string{title}
+	if post.Description != "" {
+		parts = append(parts, post.Description)
+	}
+	link := post.OriginalLink()
+	if link != "" {
+		parts = append(parts, link)
+	} else if post.Data != "" && post.Data != title {
+		parts = append(parts, post.Data)
+	}
+
+	return &tgmodels.InlineQueryResultArticle{
+		ID:          strconv.FormatInt(post.Id, 10),
+		Title:       title,
+		Description: truncate(post.Description, 200),
+		URL:         link,
+		InputMessageContent: &tgmodels.InputTextMessageContent{
+			MessageText: truncate(strings.Join(parts, "\n\n"), 4096),
+		},
+	}
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
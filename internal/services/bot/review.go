@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	tgmodels "github.com/go-telegram/bot/models"
+	"gorm.io/gorm"
+)
+
+const (
+	reviewPostsLimit = 3
+	// reviewCallbackPrefix starts the data of the buttons under a post sent
+	// for review, e.g. "review:42:useful".
+	reviewCallbackPrefix = "review:"
+)
+
+// handleReview sends the posts due for review, one message each with
+// buttons to say whether the post is still useful.
+func (s *BotService) handleReview(ctx context.Context, chatId int64, userId int64) {
+	scope := repo.PersonalScope(userId)
+	now := time.Now()
+	due, err := s.reviewService.DuePosts(scope, reviewPostsLimit, now)
+	if err != nil {
+		fmt.Println("Error getting posts to review: ", err)
+		s.reply(ctx, chatId, "Failed to get posts to review.")
+		return
+	}
+	if len(due) == 0 {
+		s.reply(ctx, chatId, "Nothing to review right now. Older posts show up here once they're due for another look.")
+		return
+	}
+
+	postIds := make([]int64, 0, len(due))
+	for _, post := range due {
+		_, err := s.bot.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatId,
+			Text:        reviewText(post.Post),
+			ReplyMarkup: reviewKeyboard(post.Id),
+		})
+		if err != nil {
+			fmt.Println("Error sending telegram message: ", err)
+			continue
+		}
+		postIds = append(postIds, post.Id)
+	}
+	err = s.reviewService.MarkSurfaced(scope, postIds, now)
+	if err != nil {
+		fmt.Println("Error marking posts as surfaced: ", err)
+	}
+}
+
+// handleReviewAnswer records a tap on one of the review buttons for the user
+// linked to the chat the post was sent to.
+func (s *BotService) handleReviewAnswer(ctx context.Context, callback *tgmodels.CallbackQuery) {
+	message := callback.Message.Message
+	postId, feedback, ok := parseReviewCallback(callback.Data)
+	if !ok || message == nil || message.Chat.Type != tgmodels.ChatTypePrivate {
+		s.answerCallback(ctx, callback.ID, "")
+		return
+	}
+	chatId := message.Chat.ID
+
+	link, err := s.telegramRepo.GetLinkByChatId(chatId)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Println("Error getting telegram link: ", err)
+			s.answerCallback(ctx, callback.ID, "Something went wrong, please try again.")
+			return
+		}
+		s.answerCallback(ctx, callback.ID, "This chat isn't linked to a Lynkbin account.")
+		return
+	}
+
+	review, err := s.reviewService.RecordFeedback(repo.PersonalScope(link.UserId), postId, feedback, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.answerCallback(ctx, callback.ID, fmt.Sprintf("Post #%d not found.", postId))
+			return
+		}
+		fmt.Println("Error recording review: ", err)
+		s.answerCallback(ctx, callback.ID, "Failed to record your answer, please try again.")
+		return
+	}
+
+	result := reviewResult(review)
+	s.answerCallback(ctx, callback.ID, result)
+	// Editing the text drops the buttons, so a post can't be answered twice
+	// from the same message.
+	_, err = s.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatId,
+		MessageID: message.ID,
+		Text:      message.Text + "\n\n" + result,
+	})
+	if err != nil {
+		fmt.Println("Error editing telegram message: ", err)
+	}
+}
+
+func (s *BotService) answerCallback(ctx context.Context, callbackId string, text string) {
+	_, err := s.bot.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callbackId, Text: text})
+	if err != nil {
+		fmt.Println("Error answering telegram callback query: ", err)
+	}
+}
+
+func reviewText(post models.Post) string {
+	text := formatPosts([]models.Post{post})
+	if link := post.OriginalLink(); link != "" {
+		text += "\n" + link
+	}
+	return text + "\n\nStill useful?"
+}
+
+func reviewKeyboard(postId int64) *tgmodels.InlineKeyboardMarkup {
+	return &tgmodels.InlineKeyboardMarkup{InlineKeyboard: [][]tgmodels.InlineKeyboardButton{{
+		{Text: "Still useful", CallbackData: fmt.Sprintf("%s%d:%s", reviewCallbackPrefix, postId, models.ReviewUseful)},
+		{Text: "Not useful", CallbackData: fmt.Sprintf("%s%d:%s", reviewCallbackPrefix, postId, models.ReviewNotUseful)},
+	}}}
+}
+
+// parseReviewCallback reads the post and answer back out of a review
+// button's data.
+func parseReviewCallback(data string) (int64, string, bool) {
+	rest, ok := strings.CutPrefix(data, reviewCallbackPrefix)
+	if !ok {
+		return 0, "", false
+	}
+	id, feedback, ok := strings.Cut(rest, ":")
+	if !ok || (feedback != models.ReviewUseful && feedback != models.ReviewNotUseful) {
+		return 0, "", false
+	}
+	postId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return postId, feedback, true
+}
+
+func reviewResult(review models.PostReview) string {
+	if review.RetiredAt != nil {
+		return "Got it, this post won't come back for review."
+	}
+	if review.LastFeedback == models.ReviewUseful {
+		return fmt.Sprintf("Glad it's still useful, it'll come back in %d days.", review.IntervalDays)
+	}
+	return fmt.Sprintf("Got it, it'll come back in %d days.", review.IntervalDays)
+}
@@ -0,0 +1,242 @@
+package categories
+
+import (
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type CategoryService struct {
+	categoryRepo *repo.CategoryRepo
+}
+
+func NewCategoryService(categoryRepo *repo.CategoryRepo) *CategoryService {
+	return &CategoryService{categoryRepo: categoryRepo}
+}
+
+func (s *CategoryService) GetCategoryTree(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	counts, err := s.categoryRepo.GetCategoryPostCounts(scope)
+	if err != nil {
+		fmt.Println("Error getting category post counts: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get categories")
+		return
+	}
+	nodes, err := s.categoryRepo.GetCategoryNodes(scope)
+	if err != nil {
+		fmt.Println("Error getting category nodes: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get categories")
+		return
+	}
+
+	// Posts saved before categories became a tree have no nodes yet.
+	known := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		known[strings.ToLower(node.Path)] = true
+	}
+	backfilled := false
+	for _, count := range counts {
+		if known[strings.ToLower(count.Category)] {
+			continue
+		}
+		_, err = s.categoryRepo.EnsureCategoryPath(scope, count.Category, false)
+		if err != nil {
+			fmt.Println("Error backfilling category path: ", err)
+			utilities.Response(ctx, 500, false, nil, "Failed to get categories")
+			return
+		}
+		backfilled = true
+	}
+	if backfilled {
+		nodes, err = s.categoryRepo.GetCategoryNodes(scope)
+		if err != nil {
+			fmt.Println("Error getting category nodes: ", err)
+			utilities.Response(ctx, 500, false, nil, "Failed to get categories")
+			return
+		}
+	}
+
+	utilities.Response(ctx, 200, true, buildCategoryTree(nodes, counts), "Category tree fetched successfully")
+}
+
+// buildCategoryTree nests the nodes under their parents. Post counts roll up,
+// so a parent's count includes every post in its subtree.
+func buildCategoryTree(nodes []models.CategoryNode, counts []repo.CategoryPostCount) []*dto.CategoryTreeNode {
+	byId := make(map[int64]*dto.CategoryTreeNode, len(nodes))
+	byPath := make(map[string]*dto.CategoryTreeNode, len(nodes))
+	for _, node := range nodes {
+		treeNode := &dto.CategoryTreeNode{CategoryNode: node, Children: []*dto.CategoryTreeNode{}}
+		byId[node.Id] = treeNode
+		byPath[strings.ToLower(node.Path)] = treeNode
+	}
+
+	roots := []*dto.CategoryTreeNode{}
+	for _, node := range nodes {
+		treeNode := byId[node.Id]
+		if node.ParentId != nil {
+			if parent, ok := byId[*node.ParentId]; ok {
+				parent.Children = append(parent.Children, treeNode)
+				continue
+			}
+		}
+		roots = append(roots, treeNode)
+	}
+
+	for _, count := range counts {
+		segments := utilities.SplitCategoryPath(count.Category)
+		for i := range segments {
+			if treeNode, ok := byPath[strings.ToLower(utilities.JoinCategoryPath(segments[:i+1]))]; ok {
+				treeNode.PostCount += count.Count
+			}
+		}
+	}
+	return roots
+}
+
+func (s *CategoryService) CreateCategory(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.CreateCategoryRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	name := utilities.NormalizeCategoryPath(request.Name)
+	if name == "" || strings.Contains(name, ">") {
+		utilities.Response(ctx, 400, false, nil, "Invalid category name")
+		return
+	}
+	path := name
+	if request.ParentId != nil {
+		parent, err := s.categoryRepo.GetCategoryNode(scope, *request.ParentId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utilities.Response(ctx, 404, false, nil, "Parent category not found")
+				return
+			}
+			fmt.Println("Error getting parent category: ", err)
+			utilities.Response(ctx, 500, false, nil, "Failed to create category")
+			return
+		}
+		path = parent.Path + utilities.CategorySeparator + name
+	}
+
+	// Categories created by hand are part of the user's taxonomy by default.
+	pinned := true
+	if request.Pinned != nil {
+		pinned = *request.Pinned
+	}
+	node, err := s.categoryRepo.EnsureCategoryPath(scope, path, pinned)
+	if err != nil {
+		fmt.Println("Error creating category: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create category")
+		return
+	}
+	utilities.Response(ctx, 201, true, node, "Category created successfully")
+}
+
+func (s *CategoryService) PinCategory(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid category ID")
+		return
+	}
+	var request dto.PinCategoryRequest
+	err = ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	_, err = s.categoryRepo.GetCategoryNode(scope, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Category not found")
+			return
+		}
+		fmt.Println("Error getting category: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update category")
+		return
+	}
+	err = s.categoryRepo.SetCategoryPinned(scope, id, request.Pinned)
+	if err != nil {
+		fmt.Println("Error pinning category: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update category")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Category updated successfully")
+}
+
+func (s *CategoryService) DeleteCategory(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid category ID")
+		return
+	}
+	node, err := s.categoryRepo.GetCategoryNode(scope, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Category not found")
+			return
+		}
+		fmt.Println("Error getting category: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to delete category")
+		return
+	}
+	err = s.categoryRepo.DeleteCategoryNode(scope, node)
+	if err != nil {
+		fmt.Println("Error deleting category: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to delete category")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Category deleted successfully")
+}
+
+func (s *CategoryService) GetCategories(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.AggregateRequest
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		fmt.Println("Error binding query parameters: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request")
+		return
+	}
+
+	categories, err := s.categoryRepo.GetCategoryAggregates(repo.AggregateQuery{
+		Scope:    scope,
+		Platform: request.Platform,
+		Search:   strings.TrimSpace(request.Q),
+		Sort:     request.Sort,
+		Limit:    request.Limit,
+	})
+	if err != nil {
+		fmt.Println("Error getting categories: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get categories")
+		return
+	}
+	utilities.Response(ctx, 200, true, categories, "Categories fetched successfully")
+}
@@ -0,0 +1,294 @@
+package collections
+
+import (
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type CollectionService struct {
+	collectionRepo *repo.CollectionRepo
+}
+
+func NewCollectionService(collectionRepo *repo.CollectionRepo) *CollectionService {
+	return &CollectionService{collectionRepo: collectionRepo}
+}
+
+// loadCollection resolves the :id parameter to one of the current scope's
+// collections, responding with an error if it can't.
+func (s *CollectionService) loadCollection(ctx *gin.Context) (models.Collection, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid collection ID")
+		return models.Collection{}, false
+	}
+	collection, err := s.collectionRepo.GetCollection(repo.ScopeFromContext(ctx), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Collection not found")
+			return models.Collection{}, false
+		}
+		fmt.Println("Error getting collection: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get collection")
+		return models.Collection{}, false
+	}
+	return collection, true
+}
+
+func bindCollectionRequest(ctx *gin.Context) (dto.CollectionRequest, bool) {
+	var request dto.CollectionRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return request, false
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	request.Description = strings.TrimSpace(request.Description)
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return request, false
+	}
+	return request, true
+}
+
+func (s *CollectionService) GetCollections(ctx *gin.Context) {
+	collections, err := s.collectionRepo.GetCollections(repo.ScopeFromContext(ctx))
+	if err != nil {
+		fmt.Println("Error getting collections: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get collections")
+		return
+	}
+	utilities.Response(ctx, 200, true, collections, "Collections fetched successfully")
+}
+
+func (s *CollectionService) CreateCollection(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	request, ok := bindCollectionRequest(ctx)
+	if !ok {
+		return
+	}
+
+	exists, err := s.collectionRepo.CollectionNameExists(scope, request.Name, 0)
+	if err != nil {
+		fmt.Println("Error checking collection name: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create collection")
+		return
+	}
+	if exists {
+		utilities.Response(ctx, 409, false, nil, fmt.Sprintf("Collection %q already exists", request.Name))
+		return
+	}
+
+	collection := models.Collection{
+		UserId:      scope.UserId,
+		WorkspaceId: scope.WorkspaceId,
+		Name:        request.Name,
+		Description: request.Description,
+	}
+	err = s.collectionRepo.CreateCollection(&collection)
+	if err != nil {
+		fmt.Println("Error creating collection: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create collection")
+		return
+	}
+	utilities.Response(ctx, 201, true, collection, "Collection created successfully")
+}
+
+func (s *CollectionService) GetCollection(ctx *gin.Context) {
+	collection, ok := s.loadCollection(ctx)
+	if !ok {
+		return
+	}
+	items, err := s.collectionRepo.GetCollectionItems(collection.Id)
+	if err != nil {
+		fmt.Println("Error getting collection items: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get collection")
+		return
+	}
+	utilities.Response(ctx, 200, true, gin.H{
+		"collection": collection,
+		"items":      items,
+	}, "Collection fetched successfully")
+}
+
+func (s *CollectionService) UpdateCollection(ctx *gin.Context) {
+	collection, ok := s.loadCollection(ctx)
+	if !ok {
+		return
+	}
+	request, ok := bindCollectionRequest(ctx)
+	if !ok {
+		return
+	}
+
+	exists, err := s.collectionRepo.CollectionNameExists(repo.ScopeFromContext(ctx), request.Name, collection.Id)
+	if err != nil {
+		fmt.Println("Error checking collection name: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update collection")
+		return
+	}
+	if exists {
+		utilities.Response(ctx, 409, false, nil, fmt.Sprintf("Collection %q already exists", request.Name))
+		return
+	}
+
+	collection.Name = request.Name
+	collection.Description = request.Description
+	err = s.collectionRepo.UpdateCollection(&collection)
+	if err != nil {
+		fmt.Println("Error updating collection: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update collection")
+		return
+	}
+	utilities.Response(ctx, 200, true, collection, "Collection updated successfully")
+}
+
+func (s *CollectionService) DeleteCollection(ctx *gin.Context) {
+	collection, ok := s.loadCollection(ctx)
+	if !ok {
+		return
+	}
+	_, err := s.collectionRepo.DeleteCollection(repo.ScopeFromContext(ctx), collection.Id)
+	if err != nil {
+		fmt.Println("Error deleting collection: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to delete collection")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Collection deleted successfully")
+}
+
+func (s *CollectionService) AddPost(ctx *gin.Context) {
+	collection, ok := s.loadCollection(ctx)
+	if !ok {
+		return
+	}
+	var request dto.AddCollectionPostRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	item, err := s.collectionRepo.AddCollectionItem(repo.ScopeFromContext(ctx), collection.Id, request.PostId, strings.TrimSpace(request.Note))
+	if err != nil {
+		if errors.Is(err, repo.ErrPostNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Post not found")
+			return
+		}
+		if errors.Is(err, repo.ErrAlreadyInCollection) {
+			utilities.Response(ctx, 409, false, nil, "Post is already in the collection")
+			return
+		}
+		fmt.Println("Error adding post to collection: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to add post to collection")
+		return
+	}
+	utilities.Response(ctx, 201, true, item, "Post added to collection successfully")
+}
+
+func (s *CollectionService) UpdatePost(ctx *gin.Context) {
+	collection, ok := s.loadCollection(ctx)
+	if !ok {
+		return
+	}
+	postId, err := strconv.ParseInt(ctx.Param("post_id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid post ID")
+		return
+	}
+	var request dto.UpdateCollectionPostRequest
+	err = ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	err = s.collectionRepo.UpdateCollectionItemNote(collection.Id, postId, strings.TrimSpace(request.Note))
+	if err != nil {
+		if errors.Is(err, repo.ErrCollectionItemAbsent) {
+			utilities.Response(ctx, 404, false, nil, "Post is not in the collection")
+			return
+		}
+		fmt.Println("Error updating collection item: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update collection post")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Collection post updated successfully")
+}
+
+func (s *CollectionService) RemovePost(ctx *gin.Context) {
+	collection, ok := s.loadCollection(ctx)
+	if !ok {
+		return
+	}
+	postId, err := strconv.ParseInt(ctx.Param("post_id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid post ID")
+		return
+	}
+	removed, err := s.collectionRepo.RemoveCollectionItem(collection.Id, postId)
+	if err != nil {
+		fmt.Println("Error removing post from collection: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to remove post from collection")
+		return
+	}
+	if !removed {
+		utilities.Response(ctx, 404, false, nil, "Post is not in the collection")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Post removed from collection successfully")
+}
+
+func (s *CollectionService) ReorderPosts(ctx *gin.Context) {
+	collection, ok := s.loadCollection(ctx)
+	if !ok {
+		return
+	}
+	var request dto.ReorderCollectionRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	err = s.collectionRepo.ReorderCollection(collection.Id, request.PostIds)
+	if err != nil {
+		if errors.Is(err, repo.ErrInvalidPostOrder) {
+			utilities.Response(ctx, 400, false, nil, "post_ids must list every post in the collection exactly once")
+			return
+		}
+		fmt.Println("Error reordering collection: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to reorder collection")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Collection reordered successfully")
+}
@@ -0,0 +1,359 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/clients/gemini"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/services/reviews"
+	"module/lynkbin/internal/utilities"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const (
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+
+	dashboardURL = "https://lynkbin.vercel.app/dashboard"
+
+	// resurfaceLimit is how many posts due for review a digest brings back.
+	resurfaceLimit   = 3
+	summaryPostLimit = 50
+	summaryTimeout   = time.Minute
+)
+
+type DigestPost struct {
+	Id          int64     `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Link        string    `json:"link"`
+	Platform    string    `json:"platform"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type DigestGroup struct {
+	Category string       `json:"category"`
+	Posts    []DigestPost `json:"posts"`
+}
+
+// Digest is what gets sent for one period: the new posts grouped by category,
+// an optional LLM-written overview and a few older posts due for review.
+type Digest struct {
+	From         time.Time     `json:"from"`
+	To           time.Time     `json:"to"`
+	Summary      string        `json:"summary"`
+	Groups       []DigestGroup `json:"groups"`
+	Resurfaced   []DigestPost  `json:"resurfaced"`
+	DashboardURL string        `json:"dashboard_url"`
+}
+
+func (d Digest) PostCount() int {
+	total := 0
+	for _, group := range d.Groups {
+		total += len(group.Posts)
+	}
+	return total
+}
+
+// IsEmpty reports whether there is nothing new and nothing to revisit.
+func (d Digest) IsEmpty() bool {
+	return d.PostCount() == 0 && len(d.Resurfaced) == 0
+}
+
+type DigestService struct {
+	digestRepo    *repo.DigestRepo
+	userRepo      *repo.UserRepo
+	reviewService *reviews.ReviewService
+	geminiClient  *gemini.GeminiClient
+	transports    map[string]Transport
+}
+
+func NewDigestService(digestRepo *repo.DigestRepo, userRepo *repo.UserRepo, reviewService *reviews.ReviewService, geminiClient *gemini.GeminiClient, transports ...Transport) *DigestService {
+	s := &DigestService{
+		digestRepo:    digestRepo,
+		userRepo:      userRepo,
+		reviewService: reviewService,
+		geminiClient:  geminiClient,
+		transports:    map[string]Transport{},
+	}
+	for _, transport := range transports {
+		s.transports[transport.Channel()] = transport
+	}
+	return s
+}
+
+func defaultSchedule(userId int64) models.DigestSchedule {
+	return models.DigestSchedule{
+		UserId:    userId,
+		Frequency: FrequencyWeekly,
+		Hour:      8,
+		Weekday:   int(time.Monday),
+		Timezone:  "UTC",
+		Channels:  pq.StringArray{ChannelEmail},
+	}
+}
+
+func (s *DigestService) getSchedule(userId int64) (models.DigestSchedule, error) {
+	schedule, err := s.digestRepo.GetSchedule(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultSchedule(userId), nil
+	}
+	return schedule, err
+}
+
+func periodStart(schedule models.DigestSchedule, now time.Time) time.Time {
+	if schedule.LastSentAt != nil {
+		return *schedule.LastSentAt
+	}
+	if schedule.Frequency == FrequencyDaily {
+		return now.Add(-24 * time.Hour)
+	}
+	return now.Add(-7 * 24 * time.Hour)
+}
+
+func digestPost(post models.Post) DigestPost {
+	title := post.Topic
+	if title == "" {
+		title = post.Data
+	}
+	return DigestPost{
+		Id:          post.Id,
+		Title:       title,
+		Description: post.Description,
+		Link:        post.OriginalLink(),
+		Platform:    post.Platform,
+		CreatedAt:   post.CreatedAt,
+	}
+}
+
+// BuildDigest collects the posts saved since the last digest. Failing to
+// write the summary is not fatal, the digest is just sent without it.
+func (s *DigestService) BuildDigest(ctx context.Context, schedule models.DigestSchedule, now time.Time) (Digest, error) {
+	digest := Digest{
+		From:         periodStart(schedule, now),
+		To:           now,
+		Groups:       []DigestGroup{},
+		Resurfaced:   []DigestPost{},
+		DashboardURL: dashboardURL,
+	}
+
+	posts, err := s.digestRepo.GetPostsCreatedBetween(repo.PersonalScope(schedule.UserId), digest.From, digest.To)
+	if err != nil {
+		return Digest{}, err
+	}
+	groupIndex := map[string]int{}
+	for _, post := range posts {
+		category := post.Category
+		if category == "" {
+			category = utilities.UncategorizedCategory
+		}
+		i, ok := groupIndex[category]
+		if !ok {
+			i = len(digest.Groups)
+			groupIndex[category] = i
+			digest.Groups = append(digest.Groups, DigestGroup{Category: category})
+		}
+		digest.Groups[i].Posts = append(digest.Groups[i].Posts, digestPost(post))
+	}
+
+	if schedule.IncludeResurfaced {
+		due, err := s.reviewService.DuePosts(repo.PersonalScope(schedule.UserId), resurfaceLimit, now)
+		if err != nil {
+			return Digest{}, err
+		}
+		for _, post := range due {
+			digest.Resurfaced = append(digest.Resurfaced, digestPost(post.Post))
+		}
+	}
+
+	if schedule.IncludeSummary && len(posts) > 0 {
+		digest.Summary, err = s.summarize(ctx, posts)
+		if err != nil {
+			fmt.Println("Error summarizing digest: ", err)
+		}
+	}
+	return digest, nil
+}
+
+func (s *DigestService) summarize(ctx context.Context, posts []models.Post) (string, error) {
+	if len(posts) > summaryPostLimit {
+		posts = posts[:summaryPostLimit]
+	}
+	lines := make([]string, 0, len(posts))
+	for _, post := range posts {
+		lines = append(lines, fmt.Sprintf("%s | %s | %s", post.Category, post.Topic, post.Description))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, summaryTimeout)
+	defer cancel()
+	summary, err := s.geminiClient.GenerateContent(ctx, utilities.GenerateDigestSummaryPrompt(strings.Join(lines, "\n")))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(summary), nil
+}
+
+// SendDigest builds the digest and delivers it over the schedule's channels.
+// Empty digests are not sent, so the next one picks up from the same point.
+// Posts brought back for review are held back from the next few digests.
+func (s *DigestService) SendDigest(ctx context.Context, schedule models.DigestSchedule, now time.Time) (Digest, error) {
+	digest, err := s.BuildDigest(ctx, schedule, now)
+	if err != nil {
+		return Digest{}, err
+	}
+	if digest.IsEmpty() {
+		return digest, nil
+	}
+
+	user, err := s.userRepo.GetUserById(schedule.UserId)
+	if err != nil {
+		return Digest{}, err
+	}
+
+	delivered := false
+	var errs []error
+	for _, channel := range schedule.Channels {
+		transport, ok := s.transports[channel]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s delivery is not configured", channel))
+			continue
+		}
+		err := transport.Send(ctx, user, digest)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+			continue
+		}
+		delivered = true
+	}
+	if !delivered {
+		return Digest{}, errors.Join(append([]error{errors.New("digest was not delivered")}, errs...)...)
+	}
+	if len(errs) > 0 {
+		fmt.Printf("Error delivering digest to user %d: %v\n", schedule.UserId, errors.Join(errs...))
+	}
+
+	err = s.digestRepo.MarkDigestSent(schedule.UserId, now)
+	if err != nil {
+		return Digest{}, err
+	}
+	if len(digest.Resurfaced) > 0 {
+		postIds := make([]int64, 0, len(digest.Resurfaced))
+		for _, post := range digest.Resurfaced {
+			postIds = append(postIds, post.Id)
+		}
+		err = s.reviewService.MarkSurfaced(repo.PersonalScope(schedule.UserId), postIds, now)
+		if err != nil {
+			fmt.Printf("Error marking resurfaced posts for user %d: %v\n", schedule.UserId, err)
+		}
+	}
+	return digest, nil
+}
+
+func (s *DigestService) GetDigestSettings(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	schedule, err := s.getSchedule(userId)
+	if err != nil {
+		fmt.Println("Error getting digest schedule: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get digest settings")
+		return
+	}
+	utilities.Response(ctx, 200, true, schedule, "Digest settings fetched successfully")
+}
+
+func (s *DigestService) UpdateDigestSettings(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	var request dto.UpdateDigestSettingsRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	if request.Timezone == "" {
+		request.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(request.Timezone); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid timezone")
+		return
+	}
+
+	schedule, err := s.getSchedule(userId)
+	if err != nil {
+		fmt.Println("Error getting digest schedule: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update digest settings")
+		return
+	}
+	schedule.Enabled = request.Enabled
+	schedule.Frequency = request.Frequency
+	schedule.Hour = request.Hour
+	schedule.Weekday = request.Weekday
+	schedule.Timezone = request.Timezone
+	schedule.Channels = pq.StringArray(request.Channels)
+	schedule.IncludeSummary = request.IncludeSummary
+	schedule.IncludeResurfaced = request.IncludeResurfaced
+	schedule.NextRunAt = NextRunAt(schedule, time.Now())
+
+	err = s.digestRepo.SaveSchedule(&schedule)
+	if err != nil {
+		fmt.Println("Error saving digest schedule: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update digest settings")
+		return
+	}
+	utilities.Response(ctx, 200, true, schedule, "Digest settings updated successfully")
+}
+
+func (s *DigestService) PreviewDigest(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	schedule, err := s.getSchedule(userId)
+	if err != nil {
+		fmt.Println("Error getting digest schedule: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to build digest")
+		return
+	}
+	digest, err := s.BuildDigest(ctx.Request.Context(), schedule, time.Now())
+	if err != nil {
+		fmt.Println("Error building digest: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to build digest")
+		return
+	}
+	utilities.Response(ctx, 200, true, gin.H{
+		"digest": digest,
+		"text":   RenderText(digest),
+	}, "Digest preview built successfully")
+}
+
+// SendDigestNow sends the digest immediately instead of waiting for the
+// schedule. The next scheduled digest starts from this one.
+func (s *DigestService) SendDigestNow(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	schedule, err := s.getSchedule(userId)
+	if err != nil {
+		fmt.Println("Error getting digest schedule: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to send digest")
+		return
+	}
+	digest, err := s.SendDigest(ctx.Request.Context(), schedule, time.Now())
+	if err != nil {
+		fmt.Println("Error sending digest: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to send digest")
+		return
+	}
+	if digest.IsEmpty() {
+		utilities.Response(ctx, 200, true, digest, "Nothing new to send since your last digest")
+		return
+	}
+	utilities.Response(ctx, 200, true, digest, "Digest sent successfully")
+}
@@ -0,0 +1,92 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+func (d Digest) Subject() string {
+	total := d.PostCount()
+	if total == 0 {
+		return "Your Lynkbin digest: posts to revisit"
+	}
+	if total == 1 {
+		return "Your Lynkbin digest: 1 new post"
+	}
+	return fmt.Sprintf("Your Lynkbin digest: %d new posts", total)
+}
+
+func (d Digest) periodLabel() string {
+	return fmt.Sprintf("%s – %s", d.From.Format("Jan 2"), d.To.Format("Jan 2, 2006"))
+}
+
+func RenderText(d Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", d.Subject(), d.periodLabel())
+	if d.Summary != "" {
+		fmt.Fprintf(&b, "\n%s\n", d.Summary)
+	}
+	for _, group := range d.Groups {
+		fmt.Fprintf(&b, "\n%s (%d)\n", group.Category, len(group.Posts))
+		for _, post := range group.Posts {
+			b.WriteString(textItem(post))
+		}
+	}
+	if len(d.Resurfaced) > 0 {
+		b.WriteString("\nFrom your archive\n")
+		for _, post := range d.Resurfaced {
+			b.WriteString(textItem(post))
+		}
+	}
+	fmt.Fprintf(&b, "\nOpen your dashboard: %s\n", d.DashboardURL)
+	return b.String()
+}
+
+func textItem(post DigestPost) string {
+	line := "• " + post.Title
+	if post.Link != "" {
+		line += "\n  " + post.Link
+	}
+	return line + "\n"
+}
+
+var htmlTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Helvetica, Arial, sans-serif; color: #1f2933; max-width: 640px; margin: 0 auto;">
+<h2>{{.Subject}}</h2>
+<p style="color: #616e7c;">{{.Period}}</p>
+{{if .Summary}}<p>{{.Summary}}</p>{{end}}
+{{range .Groups}}
+<h3>{{.Category}} ({{len .Posts}})</h3>
+<ul>{{range .Posts}}
+<li>{{if .Link}}<a href="{{.Link}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}{{if .Description}}<br><span style="color: #616e7c;">{{.Description}}</span>{{end}}</li>{{end}}
+</ul>
+{{end}}
+{{if .Resurfaced}}
+<h3>From your archive</h3>
+<ul>{{range .Resurfaced}}
+<li>{{if .Link}}<a href="{{.Link}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}{{if .Description}}<br><span style="color: #616e7c;">{{.Description}}</span>{{end}}</li>{{end}}
+</ul>
+{{end}}
+<p><a href="{{.DashboardURL}}">Open your dashboard</a></p>
+</body>
+</html>
+`))
+
+func RenderHTML(d Digest) (string, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, map[string]any{
+		"Subject":      d.Subject(),
+		"Period":       d.periodLabel(),
+		"Summary":      d.Summary,
+		"Groups":       d.Groups,
+		"Resurfaced":   d.Resurfaced,
+		"DashboardURL": d.DashboardURL,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
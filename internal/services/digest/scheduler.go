@@ -0,0 +1,78 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"module/lynkbin/internal/models"
+	"time"
+)
+
+const (
+	schedulerInterval = time.Minute
+	schedulerBatch    = 50
+)
+
+// NextRunAt returns the first scheduled time strictly after the given time,
+// in the schedule's timezone.
+func NextRunAt(schedule models.DigestSchedule, after time.Time) time.Time {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := after.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), schedule.Hour, 0, 0, 0, loc)
+
+	if schedule.Frequency == FrequencyWeekly {
+		next = next.AddDate(0, 0, (schedule.Weekday-int(next.Weekday())+7)%7)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	}
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Run sends due digests every minute until ctx is done.
+func (s *DigestService) Run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+	for {
+		s.sendDueDigests(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *DigestService) sendDueDigests(ctx context.Context) {
+	now := time.Now()
+	schedules, err := s.digestRepo.GetDueSchedules(now, schedulerBatch)
+	if err != nil {
+		fmt.Println("Error getting due digest schedules: ", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		if ctx.Err() != nil {
+			return
+		}
+		claimed, err := s.digestRepo.ClaimSchedule(schedule, NextRunAt(schedule, now))
+		if err != nil {
+			fmt.Println("Error claiming digest schedule: ", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		_, err = s.SendDigest(ctx, schedule, now)
+		if err != nil {
+			fmt.Printf("Error sending digest to user %d: %v\n", schedule.UserId, err)
+		}
+	}
+}
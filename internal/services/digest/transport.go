@@ -0,0 +1,126 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/clients/mailer"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"strings"
+)
+
+const (
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+
+	telegramMessageLimit = 4096
+)
+
+// Transport delivers a rendered digest over one channel.
+type Transport interface {
+	Channel() string
+	Send(ctx context.Context, user models.User, digest Digest) error
+}
+
+type EmailTransport struct {
+	mailer mailer.Mailer
+}
+
+func NewEmailTransport(m mailer.Mailer) *EmailTransport {
+	return &EmailTransport{mailer: m}
+}
+
+func (t *EmailTransport) Channel() string {
+	return ChannelEmail
+}
+
+func (t *EmailTransport) Send(ctx context.Context, user models.User, digest Digest) error {
+	htmlBody, err := RenderHTML(digest)
+	if err != nil {
+		return err
+	}
+	return t.mailer.Send(ctx, mailer.Message{
+		To:      []string{user.Email},
+		Subject: digest.Subject(),
+		Text:    RenderText(digest),
+		HTML:    htmlBody,
+	})
+}
+
+// TelegramSender is the part of the bot the digest needs, kept as an
+// interface so the bot can be swapped out in tests.
+type TelegramSender interface {
+	SendText(ctx context.Context, chatId int64, text string) error
+}
+
+type TelegramTransport struct {
+	sender       TelegramSender
+	telegramRepo *repo.TelegramRepo
+}
+
+func NewTelegramTransport(sender TelegramSender, telegramRepo *repo.TelegramRepo) *TelegramTransport {
+	return &TelegramTransport{sender: sender, telegramRepo: telegramRepo}
+}
+
+func (t *TelegramTransport) Channel() string {
+	return ChannelTelegram
+}
+
+// Send posts the digest to every chat the user has linked.
+func (t *TelegramTransport) Send(ctx context.Context, user models.User, digest Digest) error {
+	links, err := t.telegramRepo.GetUserLinks(*user.Id)
+	if err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		return errors.New("user has no linked telegram chats")
+	}
+
+	chunks := splitMessage(RenderText(digest), telegramMessageLimit)
+	var errs []error
+	for _, link := range links {
+		for _, chunk := range chunks {
+			err := t.sender.SendText(ctx, link.ChatId, chunk)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("chat %d: %w", link.ChatId, err))
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// splitMessage breaks text into chunks no longer than limit, preferring to
+// split between lines.
+func splitMessage(text string, limit int) []string {
+	var chunks []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for len(line) > limit {
+			if current.Len() > 0 {
+				chunks = append(chunks, current.String())
+				current.Reset()
+			}
+			cut := limit
+			for cut > 0 && !isRuneStart(line[cut]) {
+				cut--
+			}
+			chunks = append(chunks, line[:cut])
+			line = line[cut:]
+		}
+		if current.Len()+len(line) > limit {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
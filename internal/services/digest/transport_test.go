@@ -0,0 +1,130 @@
+package digest
+
+import (
+	"context"
+	"module/lynkbin/internal/clients/mailer"
+	"module/lynkbin/internal/models"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sinkMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// smtpSink is a minimal SMTP server that accepts every message and hands it
+// over on Messages.
+type smtpSink struct {
+	listener net.Listener
+	Messages chan sinkMessage
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	sink := &smtpSink{listener: listener, Messages: make(chan sinkMessage, 10)}
+	t.Cleanup(func() { listener.Close() })
+	go sink.serve()
+	return sink
+}
+
+func (s *smtpSink) Port() string {
+	return strings.TrimPrefix(s.listener.Addr().String(), "127.0.0.1:")
+}
+
+func (s *smtpSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(textproto.NewConn(conn))
+	}
+}
+
+func (s *smtpSink) handle(conn *textproto.Conn) {
+	defer conn.Close()
+	var message sinkMessage
+	conn.PrintfLine("220 localhost ESMTP sink")
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(line)
+		if strings.HasPrefix(command, "EHLO") || strings.HasPrefix(command, "HELO") {
+			conn.PrintfLine("250 localhost")
+		} else if strings.HasPrefix(command, "MAIL FROM:") {
+			message = sinkMessage{From: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			conn.PrintfLine("250 OK")
+		} else if strings.HasPrefix(command, "RCPT TO:") {
+			message.To = append(message.To, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			conn.PrintfLine("250 OK")
+		} else if command == "DATA" {
+			conn.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			message.Data = string(data)
+			s.Messages <- message
+			conn.PrintfLine("250 OK")
+		} else if command == "QUIT" {
+			conn.PrintfLine("221 Bye")
+			return
+		} else {
+			conn.PrintfLine("250 OK")
+		}
+	}
+}
+
+func TestEmailTransportDeliversDigest(t *testing.T) {
+	sink := newSMTPSink(t)
+	transport := NewEmailTransport(&mailer.SMTPMailer{Host: "127.0.0.1", Port: sink.Port(), From: "digest@lynkbin.test"})
+
+	now := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	digest := Digest{
+		From: now.Add(-7 * 24 * time.Hour),
+		To:   now,
+		Groups: []DigestGroup{{
+			Category: "Programming",
+			Posts:    []DigestPost{{Id: 1, Title: "Understanding Go generics", Link: "https://example.com/generics"}},
+		}},
+		DashboardURL: dashboardURL,
+	}
+	err := transport.Send(context.Background(), models.User{Email: "reader@lynkbin.test"}, digest)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var message sinkMessage
+	select {
+	case message = <-sink.Messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message reached the SMTP sink")
+	}
+	if message.From != "digest@lynkbin.test" {
+		t.Errorf("envelope sender = %q, want digest@lynkbin.test", message.From)
+	}
+	if len(message.To) != 1 || message.To[0] != "reader@lynkbin.test" {
+		t.Errorf("envelope recipients = %v, want [reader@lynkbin.test]", message.To)
+	}
+	for _, want := range []string{
+		"Subject: Your Lynkbin digest: 1 new post",
+		"Content-Type: multipart/alternative",
+		"Understanding Go generics",
+		"https://example.com/generics",
+	} {
+		if !strings.Contains(message.Data, want) {
+			t.Errorf("delivered message is missing %q:\n%s", want, message.Data)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package exports
+
+import (
+	"archive/zip"
+	"fmt"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/exporter"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ExportService struct {
+	postRepo       *repo.PostRepo
+	collectionRepo *repo.CollectionRepo
+}
+
+func NewExportService(postRepo *repo.PostRepo, collectionRepo *repo.CollectionRepo) *ExportService {
+	return &ExportService{postRepo: postRepo, collectionRepo: collectionRepo}
+}
+
+// Library returns every post in the scope as it is exported, along with
+// the collections it is in.
+func (s *ExportService) Library(scope repo.Scope) ([]exporter.Post, error) {
+	posts, err := s.postRepo.GetAllPosts(scope)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.collectionRepo.GetPostCollections(scope)
+	if err != nil {
+		return nil, err
+	}
+	collections := map[int64][]exporter.CollectionEntry{}
+	for _, entry := range entries {
+		collections[entry.PostId] = append(collections[entry.PostId], exporter.CollectionEntry{
+			Name:        entry.Name,
+			Description: entry.Description,
+			Position:    entry.Position,
+			Note:        entry.Note,
+		})
+	}
+	library := make([]exporter.Post, 0, len(posts))
+	for _, post := range posts {
+		library = append(library, exporter.NewPost(post.Post, post.ReadingState, collections[post.Id]))
+	}
+	return library, nil
+}
+
+// ExportLibrary downloads the library as a ZIP of Markdown notes that can be
+// opened as an Obsidian vault, or with ?format=json as a file that can be
+// imported again.
+func (s *ExportService) ExportLibrary(ctx *gin.Context) {
+	var request dto.ExportLibraryRequest
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return
+	}
+
+	library, err := s.Library(repo.ScopeFromContext(ctx))
+	if err != nil {
+		fmt.Println("Error getting library: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to export library")
+		return
+	}
+
+	date := time.Now().Format("20060102")
+	if request.Format == exporter.FormatJSON {
+		data, err := exporter.LibraryJSON(library)
+		if err != nil {
+			fmt.Println("Error encoding library: ", err)
+			utilities.Response(ctx, 500, false, nil, "Failed to export library")
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "lynkbin-library-"+date+".json"))
+		ctx.Data(200, "application/json", data)
+		return
+	}
+
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "lynkbin-vault-"+date+".zip"))
+	ctx.Status(200)
+	archive := zip.NewWriter(ctx.Writer)
+	for _, file := range exporter.MarkdownFiles(library) {
+		writer, err := archive.Create("lynkbin/" + file.Name)
+		if err == nil {
+			_, err = writer.Write(file.Data)
+		}
+		if err != nil {
+			fmt.Println("Error writing library export: ", err)
+			return
+		}
+	}
+	err = archive.Close()
+	if err != nil {
+		fmt.Println("Error writing library export: ", err)
+	}
+}
@@ -0,0 +1,267 @@
+package feeds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/auth"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// feedSize is how many of the newest posts a feed carries.
+const feedSize = 50
+
+// FeedResponse is what a feed is created with. Its URLs carry the token,
+// which isn't stored, so they are only ever returned here.
+type FeedResponse struct {
+	models.Feed
+	URLs map[string]string `json:"urls"`
+}
+
+type FeedService struct {
+	feedRepo       *repo.FeedRepo
+	postRepo       *repo.PostRepo
+	collectionRepo *repo.CollectionRepo
+	// baseURL is where the API is reachable from feed readers. When empty
+	// it is taken from the request.
+	baseURL string
+}
+
+func NewFeedService(feedRepo *repo.FeedRepo, postRepo *repo.PostRepo, collectionRepo *repo.CollectionRepo, baseURL string) *FeedService {
+	return &FeedService{
+		feedRepo:       feedRepo,
+		postRepo:       postRepo,
+		collectionRepo: collectionRepo,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (s *FeedService) feedURL(ctx *gin.Context, token string, format string) string {
+	base := s.baseURL
+	if base == "" {
+		scheme := "http"
+		if ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + ctx.Request.Host
+	}
+	return fmt.Sprintf("%s/public/feeds/%s/%s", base, token, format)
+}
+
+// feedName describes the filter for feeds created without a name.
+func feedName(feed models.Feed, collection *models.Collection) string {
+	var parts []string
+	if collection != nil {
+		parts = append(parts, collection.Name)
+	}
+	if feed.Category != "" {
+		parts = append(parts, feed.Category)
+	}
+	if len(feed.Tags) > 0 {
+		parts = append(parts, "#"+strings.Join(feed.Tags, " #"))
+	}
+	if feed.Platform != "" {
+		parts = append(parts, feed.Platform)
+	}
+	if len(parts) == 0 {
+		return "Lynkbin"
+	}
+	return "Lynkbin: " + strings.Join(parts, ", ")
+}
+
+func (s *FeedService) CreateFeed(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.CreateFeedRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	var collection *models.Collection
+	if request.CollectionId != nil {
+		found, err := s.collectionRepo.GetCollection(scope, *request.CollectionId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				utilities.Response(ctx, 404, false, nil, "Collection not found")
+				return
+			}
+			fmt.Println("Error getting collection: ", err)
+			utilities.Response(ctx, 500, false, nil, "Failed to create feed")
+			return
+		}
+		collection = &found
+	}
+
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		fmt.Println("Error generating feed token: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create feed")
+		return
+	}
+	feed := models.Feed{
+		UserId:       scope.UserId,
+		WorkspaceId:  scope.WorkspaceId,
+		Name:         strings.TrimSpace(request.Name),
+		TokenHash:    auth.HashToken(token),
+		Platform:     request.Platform,
+		Tags:         request.Tags,
+		Category:     utilities.NormalizeCategoryPath(request.Category),
+		CollectionId: request.CollectionId,
+	}
+	if feed.Name == "" {
+		feed.Name = feedName(feed, collection)
+	}
+	err = s.feedRepo.CreateFeed(&feed)
+	if err != nil {
+		fmt.Println("Error creating feed: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create feed")
+		return
+	}
+	utilities.Response(ctx, 201, true, FeedResponse{
+		Feed: feed,
+		URLs: map[string]string{
+			FormatRSS:  s.feedURL(ctx, token, FormatRSS),
+			FormatAtom: s.feedURL(ctx, token, FormatAtom),
+			FormatJSON: s.feedURL(ctx, token, FormatJSON),
+		},
+	}, "Feed created successfully")
+}
+
+func (s *FeedService) GetFeeds(ctx *gin.Context) {
+	feeds, err := s.feedRepo.GetFeeds(repo.ScopeFromContext(ctx))
+	if err != nil {
+		fmt.Println("Error getting feeds: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get feeds")
+		return
+	}
+	utilities.Response(ctx, 200, true, feeds, "Feeds fetched successfully")
+}
+
+func (s *FeedService) RevokeFeed(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid feed ID")
+		return
+	}
+	revoked, err := s.feedRepo.RevokeFeed(repo.ScopeFromContext(ctx), id)
+	if err != nil {
+		fmt.Println("Error revoking feed: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to revoke feed")
+		return
+	}
+	if !revoked {
+		utilities.Response(ctx, 404, false, nil, "Feed not found")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Feed revoked successfully")
+}
+
+// GetPublicFeed serves a feed to feed readers without authentication. The
+// ETag is a hash of the rendered feed and is what conditional requests are
+// best made with; Last-Modified only moves when a post is added, since
+// posts don't record when they were changed or removed.
+func (s *FeedService) GetPublicFeed(ctx *gin.Context) {
+	format := ctx.Param("format")
+	contentType, ok := contentTypes[format]
+	if !ok {
+		ctx.String(404, "Feed not found")
+		return
+	}
+	token := ctx.Param("token")
+	feed, err := s.feedRepo.GetFeedByTokenHash(auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.String(404, "Feed not found")
+			return
+		}
+		fmt.Println("Error getting feed: ", err)
+		ctx.String(500, "Failed to get feed")
+		return
+	}
+
+	filter := repo.PostFilter{
+		Scope:          repo.Scope{UserId: feed.UserId, WorkspaceId: feed.WorkspaceId},
+		Platform:       feed.Platform,
+		Tags:           feed.Tags,
+		ParentCategory: feed.Category,
+		Limit:          feedSize,
+	}
+	if feed.CollectionId != nil {
+		filter.CollectionId = *feed.CollectionId
+	}
+	posts, err := s.postRepo.GetPosts(filter)
+	if err != nil {
+		fmt.Println("Error getting feed posts: ", err)
+		ctx.String(500, "Failed to get feed")
+		return
+	}
+
+	updated := feed.CreatedAt
+	for _, post := range posts {
+		if post.CreatedAt.After(updated) {
+			updated = post.CreatedAt
+		}
+	}
+	body, err := render(format, channel{
+		Title:   feed.Name,
+		SelfURL: s.feedURL(ctx, token, format),
+		Updated: updated,
+		// Feeds are read without signing in, so nobody's reading state
+		// goes into them.
+		Posts: repo.PlainPosts(posts),
+	})
+	if err != nil {
+		fmt.Println("Error rendering feed: ", err)
+		ctx.String(500, "Failed to get feed")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	ctx.Header("ETag", etag)
+	ctx.Header("Last-Modified", updated.UTC().Format(http.TimeFormat))
+	ctx.Header("Cache-Control", "private, max-age=300")
+	if notModified(ctx.Request, etag, updated) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.Data(200, contentType, body)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is
+// no If-None-Match, as RFC 9110 asks.
+func notModified(request *http.Request, etag string, updated time.Time) bool {
+	if match := request.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(request.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !updated.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,275 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"module/lynkbin/internal/models"
+	"time"
+)
+
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+var contentTypes = map[string]string{
+	FormatRSS:  "application/rss+xml; charset=utf-8",
+	FormatAtom: "application/atom+xml; charset=utf-8",
+	FormatJSON: "application/feed+json; charset=utf-8",
+}
+
+// channel is what the three formats have in common. Nothing in it depends
+// on when it is rendered, so the same posts always render the same bytes
+// and the ETag only changes when the feed does.
+type channel struct {
+	Title   string
+	SelfURL string
+	Updated time.Time
+	Posts   []models.Post
+}
+
+func render(format string, c channel) ([]byte, error) {
+	switch format {
+	case FormatRSS:
+		return renderRSS(c)
+	case FormatAtom:
+		return renderAtom(c)
+	default:
+		return renderJSON(c)
+	}
+}
+
+func postTitle(post models.Post) string {
+	if post.Topic != "" {
+		return post.Topic
+	}
+	if post.Platform == "notes" {
+		return "Note from " + post.CreatedAt.UTC().Format("2006-01-02")
+	}
+	return post.Data
+}
+
+// postContent is the body of an item: the summary, followed by the note
+// itself for notes.
+func postContent(post models.Post) string {
+	if post.Platform != "notes" {
+		return post.Description
+	}
+	if post.Description == "" {
+		return post.Data
+	}
+	return post.Description + "\n\n" + post.Data
+}
+
+// postCategories are the category and tags of a post, which feed readers
+// show as labels.
+func postCategories(post models.Post) []string {
+	categories := []string{}
+	if post.Category != "" {
+		categories = append(categories, post.Category)
+	}
+	return append(categories, post.Tags...)
+}
+
+// postGuid identifies a post across renders; it isn't a link since notes
+// have none.
+func postGuid(post models.Post) string {
+	return fmt.Sprintf("urn:lynkbin:post:%d", post.Id)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          rssLink   `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssGuid struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link,omitempty"`
+	Guid        rssGuid  `xml:"guid"`
+	Description string   `xml:"description,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+}
+
+func renderRSS(c channel) ([]byte, error) {
+	feed := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       c.Title,
+			Link:        c.SelfURL,
+			Description: c.Title,
+			Self:        rssLink{Href: c.SelfURL, Rel: "self", Type: "application/rss+xml"},
+			Items:       make([]rssItem, 0, len(c.Posts)),
+		},
+	}
+	if !c.Updated.IsZero() {
+		feed.Channel.LastBuildDate = c.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, post := range c.Posts {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       postTitle(post),
+			Link:        post.OriginalLink(),
+			Guid:        rssGuid{IsPermaLink: "false", Value: postGuid(post)},
+			Description: postContent(post),
+			Creator:     post.Author,
+			PubDate:     post.CreatedAt.UTC().Format(time.RFC1123Z),
+			Categories:  postCategories(post),
+		})
+	}
+	return marshalXML(feed)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	Id      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	Id         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Links      []atomLink     `xml:"link"`
+	Author     *atomPerson    `xml:"author"`
+	Summary    *atomText      `xml:"summary"`
+	Categories []atomCategory `xml:"category"`
+}
+
+func renderAtom(c channel) ([]byte, error) {
+	feed := atomFeed{
+		Title:   c.Title,
+		Id:      c.SelfURL,
+		Updated: c.Updated.UTC().Format(time.RFC3339),
+		// Atom wants an author for every entry; this one stands in for
+		// posts without one.
+		Author:  atomPerson{Name: "Lynkbin"},
+		Links:   []atomLink{{Href: c.SelfURL, Rel: "self"}},
+		Entries: make([]atomEntry, 0, len(c.Posts)),
+	}
+	for _, post := range c.Posts {
+		entry := atomEntry{
+			Title:     postTitle(post),
+			Id:        postGuid(post),
+			Updated:   post.CreatedAt.UTC().Format(time.RFC3339),
+			Published: post.CreatedAt.UTC().Format(time.RFC3339),
+		}
+		if link := post.OriginalLink(); link != "" {
+			entry.Links = []atomLink{{Href: link, Rel: "alternate"}}
+		}
+		if post.Author != "" {
+			entry.Author = &atomPerson{Name: post.Author}
+		}
+		if content := postContent(post); content != "" {
+			entry.Summary = &atomText{Type: "text", Value: content}
+		}
+		for _, category := range postCategories(post) {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return marshalXML(feed)
+}
+
+func marshalXML(feed any) ([]byte, error) {
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+type jsonFeed struct {
+	Version string         `json:"version"`
+	Title   string         `json:"title"`
+	FeedURL string         `json:"feed_url"`
+	Items   []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	Id            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	DatePublished string           `json:"date_published"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+func renderJSON(c channel) ([]byte, error) {
+	feed := jsonFeed{
+		Version: "https://jsonfeed.org/version/1.1",
+		Title:   c.Title,
+		FeedURL: c.SelfURL,
+		Items:   make([]jsonFeedItem, 0, len(c.Posts)),
+	}
+	for _, post := range c.Posts {
+		item := jsonFeedItem{
+			Id:            postGuid(post),
+			URL:           post.OriginalLink(),
+			Title:         postTitle(post),
+			ContentText:   postContent(post),
+			DatePublished: post.CreatedAt.UTC().Format(time.RFC3339),
+			Tags:          postCategories(post),
+		}
+		if post.Author != "" {
+			item.Authors = []jsonFeedAuthor{{Name: post.Author}}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return json.MarshalIndent(feed, "", "  ")
+}
@@ -0,0 +1,393 @@
+package imports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/exporter"
+	"module/lynkbin/internal/importer"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/services/posts"
+	"module/lynkbin/internal/utilities"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	maxImportFileSize  = 10 << 20
+	maxImportItems     = 5000
+	defaultImportRate  = 20
+	importPollInterval = 5 * time.Second
+	// importClaimTimeout is how long a bookmark may take to save before
+	// another worker takes it over.
+	importClaimTimeout = 10 * time.Minute
+	importItemsPage    = 100
+
+	// Limits of collection names and notes, as for collections made by hand.
+	maxCollectionName = 100
+	maxCollectionNote = 1000
+)
+
+type ImportService struct {
+	importRepo     *repo.ImportRepo
+	postRepo       *repo.PostRepo
+	collectionRepo *repo.CollectionRepo
+	postService    *posts.PostService
+	// interval spaces out saving bookmarks, as each one may be summarized
+	// by the LLM.
+	interval time.Duration
+}
+
+func NewImportService(importRepo *repo.ImportRepo, postRepo *repo.PostRepo, collectionRepo *repo.CollectionRepo, postService *posts.PostService, ratePerMinute int) *ImportService {
+	return &ImportService{
+		importRepo:     importRepo,
+		postRepo:       postRepo,
+		collectionRepo: collectionRepo,
+		postService:    postService,
+		interval:       time.Minute / time.Duration(ratePerMinute),
+	}
+}
+
+// ImportRateFromEnv reads IMPORT_RATE_PER_MINUTE, how many imported
+// bookmarks are saved per minute across all imports (default 20).
+func ImportRateFromEnv() (int, error) {
+	value := os.Getenv("IMPORT_RATE_PER_MINUTE")
+	if value == "" {
+		return defaultImportRate, nil
+	}
+	rate, err := strconv.Atoi(value)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("invalid IMPORT_RATE_PER_MINUTE %q", value)
+	}
+	return rate, nil
+}
+
+// CreateImport takes an uploaded export and queues its bookmarks to be
+// saved in the background.
+func (s *ImportService) CreateImport(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.CreateImportRequest
+	err := ctx.ShouldBind(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	if request.Folders == "" {
+		request.Folders = models.ImportFoldersAsCollections
+	}
+	if request.File.Size > maxImportFileSize {
+		utilities.Response(ctx, 400, false, nil, "The file is too large, the limit is 10 MB")
+		return
+	}
+
+	file, err := request.File.Open()
+	if err != nil {
+		fmt.Println("Error opening import file: ", err)
+		utilities.Response(ctx, 400, false, nil, "Failed to read the file")
+		return
+	}
+	defer file.Close()
+	items, err := importer.Parse(request.Format, io.LimitReader(file, maxImportFileSize))
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Failed to read the file: "+err.Error())
+		return
+	}
+	if len(items) > maxImportItems {
+		utilities.Response(ctx, 400, false, nil, fmt.Sprintf("The file has %d bookmarks, the limit is %d per import", len(items), maxImportItems))
+		return
+	}
+
+	job := models.ImportJob{
+		UserId:      scope.UserId,
+		WorkspaceId: scope.WorkspaceId,
+		Format:      request.Format,
+		Filename:    request.File.Filename,
+		Folders:     request.Folders,
+		Status:      models.ImportStatusPending,
+		Total:       len(items),
+	}
+	importItems := make([]models.ImportItem, 0, len(items))
+	for i, item := range items {
+		snapshot := ""
+		if item.Post != nil {
+			data, err := json.Marshal(item.Post)
+			if err != nil {
+				fmt.Println("Error encoding import snapshot: ", err)
+				utilities.Response(ctx, 500, false, nil, "Failed to start import")
+				return
+			}
+			snapshot = string(data)
+		}
+		importItems = append(importItems, models.ImportItem{
+			Snapshot: snapshot,
+			Position: i,
+			Url:      item.URL,
+			Title:    item.Title,
+			Tags:     item.Tags,
+			Folder:   item.Folder,
+			Note:     item.Note,
+			AddedAt:  item.AddedAt,
+			Status:   models.ImportItemPending,
+		})
+	}
+	err = s.importRepo.CreateImportJob(&job, importItems)
+	if err != nil {
+		fmt.Println("Error creating import job: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to start import")
+		return
+	}
+	utilities.Response(ctx, 202, true, job, "Import started successfully")
+}
+
+func (s *ImportService) GetImports(ctx *gin.Context) {
+	jobs, err := s.importRepo.GetImportJobs(repo.ScopeFromContext(ctx))
+	if err != nil {
+		fmt.Println("Error getting import jobs: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get imports")
+		return
+	}
+	utilities.Response(ctx, 200, true, jobs, "Imports fetched successfully")
+}
+
+func (s *ImportService) loadImportJob(ctx *gin.Context) (models.ImportJob, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid import ID")
+		return models.ImportJob{}, false
+	}
+	job, err := s.importRepo.GetImportJob(repo.ScopeFromContext(ctx), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Import not found")
+			return models.ImportJob{}, false
+		}
+		fmt.Println("Error getting import job: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get import")
+		return models.ImportJob{}, false
+	}
+	return job, true
+}
+
+// GetImport reports the progress of an import.
+func (s *ImportService) GetImport(ctx *gin.Context) {
+	job, ok := s.loadImportJob(ctx)
+	if !ok {
+		return
+	}
+	processed := job.Imported + job.Duplicates + job.Failed
+	utilities.Response(ctx, 200, true, gin.H{
+		"import":    job,
+		"processed": processed,
+		"remaining": job.Total - processed,
+	}, "Import fetched successfully")
+}
+
+// GetImportItems lists what happened to each bookmark, e.g. with
+// ?status=failed to see what needs another look.
+func (s *ImportService) GetImportItems(ctx *gin.Context) {
+	job, ok := s.loadImportJob(ctx)
+	if !ok {
+		return
+	}
+	var request dto.GetImportItemsRequest
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return
+	}
+	items, err := s.importRepo.GetImportItems(job.Id, request.Status, importItemsPage, request.Page*importItemsPage)
+	if err != nil {
+		fmt.Println("Error getting import items: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get import items")
+		return
+	}
+	utilities.Response(ctx, 200, true, items, "Import items fetched successfully")
+}
+
+func (s *ImportService) CancelImport(ctx *gin.Context) {
+	job, ok := s.loadImportJob(ctx)
+	if !ok {
+		return
+	}
+	cancelled, err := s.importRepo.CancelImportJob(job.Id)
+	if err != nil {
+		fmt.Println("Error cancelling import job: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to cancel import")
+		return
+	}
+	if !cancelled {
+		utilities.Response(ctx, 400, false, nil, "Import has already finished")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Import cancelled successfully")
+}
+
+// Run saves queued bookmarks one at a time, at most one per interval, until
+// ctx is done.
+func (s *ImportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		item, job, claimed, err := s.importRepo.ClaimNextImportItem(time.Now().Add(-importClaimTimeout))
+		if err != nil {
+			fmt.Println("Error claiming import item: ", err)
+		}
+		wait := ticker.C
+		if !claimed {
+			wait = time.After(importPollInterval)
+		} else {
+			s.importItem(job, item)
+			// Restoring an exported post doesn't involve the LLM, so there
+			// is nothing to wait for.
+			if item.Snapshot != "" && ctx.Err() == nil {
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-wait:
+		}
+	}
+}
+
+// importItem saves one bookmark through the same path as CreatePost and
+// files it according to the job's folder setting. Posts from a lynkbin
+// export are restored as they were, into the collections they were in.
+func (s *ImportService) importItem(job models.ImportJob, item models.ImportItem) {
+	scope := repo.Scope{UserId: job.UserId, WorkspaceId: job.WorkspaceId}
+	var snapshot *exporter.Post
+	if item.Snapshot != "" {
+		snapshot = &exporter.Post{}
+		err := json.Unmarshal([]byte(item.Snapshot), snapshot)
+		if err != nil {
+			fmt.Println("Error decoding import snapshot: ", err)
+			item.Status = models.ImportItemFailed
+			item.Error = "Invalid post in export"
+			s.finishItem(item)
+			return
+		}
+	}
+	var folderParts []string
+	if item.Folder != "" {
+		folderParts = strings.Split(item.Folder, "/")
+	}
+
+	existing, err := s.postRepo.GetPostByData(scope, item.Url)
+	if err == nil {
+		item.Status = models.ImportItemDuplicate
+		item.PostId = &existing.Id
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		item.Status = models.ImportItemFailed
+		item.Error = "Failed to check for duplicates"
+		fmt.Println("Error getting post by data: ", err)
+	} else if snapshot != nil {
+		post, err := s.postService.RestorePost(scope, *snapshot)
+		if err != nil {
+			fmt.Printf("Error restoring %s: %v\n", item.Url, err)
+			item.Status = models.ImportItemFailed
+			item.Error = "Failed to restore post"
+		} else {
+			item.Status = models.ImportItemImported
+			item.PostId = &post.Id
+		}
+	} else {
+		tags := append([]string{}, item.Tags...)
+		if job.Folders == models.ImportFoldersAsTags {
+			tags = append(tags, folderParts...)
+		}
+		response, err := s.postService.SavePost(scope, dto.CreatePostRequest{
+			Url:   item.Url,
+			IsUrl: true,
+			Tags:  tags,
+			Title: item.Title,
+		})
+		if err != nil {
+			fmt.Printf("Error importing %s: %v\n", item.Url, err)
+			item.Status = models.ImportItemFailed
+			item.Error = "Failed to save post"
+			var saveErr *posts.SavePostError
+			if errors.As(err, &saveErr) {
+				item.Error = saveErr.Message
+			}
+		} else {
+			item.Status = models.ImportItemImported
+			item.PostId = &response.Id
+		}
+	}
+
+	if item.PostId != nil && snapshot != nil {
+		for _, entry := range snapshot.Collections {
+			err = s.addToCollection(scope, truncate(entry.Name, maxCollectionName), entry.Description, *item.PostId, truncate(entry.Note, maxCollectionNote))
+			if err != nil {
+				fmt.Println("Error adding imported post to collection: ", err)
+			}
+		}
+	} else if item.PostId != nil && job.Folders == models.ImportFoldersAsCollections && len(folderParts) > 0 {
+		name := truncate(strings.Join(folderParts, " / "), maxCollectionName)
+		err = s.addToCollection(scope, name, "", *item.PostId, truncate(item.Note, maxCollectionNote))
+		if err != nil {
+			fmt.Println("Error adding imported post to collection: ", err)
+		}
+	}
+	s.finishItem(item)
+}
+
+func (s *ImportService) finishItem(item models.ImportItem) {
+	err := s.importRepo.FinishImportItem(item)
+	if err != nil {
+		fmt.Println("Error finishing import item: ", err)
+	}
+}
+
+// addToCollection files a post into the collection with the given name,
+// creating it on first use.
+func (s *ImportService) addToCollection(scope repo.Scope, name string, description string, postId int64, note string) error {
+	collection, err := s.collectionRepo.GetCollectionByName(scope, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		collection = models.Collection{
+			UserId:      scope.UserId,
+			WorkspaceId: scope.WorkspaceId,
+			Name:        name,
+			Description: description,
+		}
+		err = s.collectionRepo.CreateCollection(&collection)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.collectionRepo.AddCollectionItem(scope, collection.Id, postId, note)
+	if errors.Is(err, repo.ErrAlreadyInCollection) {
+		return nil
+	}
+	return err
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
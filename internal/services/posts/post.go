@@ -3,15 +3,19 @@ package posts
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"module/lynkbin/internal/clients/gemini"
 	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/events"
+	"module/lynkbin/internal/exporter"
 	"module/lynkbin/internal/models"
 	"module/lynkbin/internal/repo"
 	"module/lynkbin/internal/scraper"
 	"module/lynkbin/internal/utilities"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -19,15 +23,69 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/lib/pq"
+	"gorm.io/gorm"
 )
 
 type PostService struct {
 	postRepo     *repo.PostRepo
+	tagRepo      *repo.TagRepo
+	categoryRepo *repo.CategoryRepo
+	authorRepo   *repo.AuthorRepo
 	geminiClient *gemini.GeminiClient
+	eventBus     *events.Bus
 }
 
-func NewPostService(postRepo *repo.PostRepo, geminiClient *gemini.GeminiClient) *PostService {
-	return &PostService{postRepo: postRepo, geminiClient: geminiClient}
+func NewPostService(postRepo *repo.PostRepo, tagRepo *repo.TagRepo, categoryRepo *repo.CategoryRepo, authorRepo *repo.AuthorRepo, geminiClient *gemini.GeminiClient, eventBus *events.Bus) *PostService {
+	return &PostService{postRepo: postRepo, tagRepo: tagRepo, categoryRepo: categoryRepo, authorRepo: authorRepo, geminiClient: geminiClient, eventBus: eventBus}
+}
+
+// PostEvent is the data of the post.* events. SaveId ties a new post to the
+// enrichment events that led up to it.
+type PostEvent struct {
+	SaveId string      `json:"save_id,omitempty"`
+	Post   models.Post `json:"post"`
+}
+
+// EnrichmentEvent is the data of post.enrichment events.
+type EnrichmentEvent struct {
+	SaveId   string `json:"save_id"`
+	Platform string `json:"platform"`
+	Url      string `json:"url,omitempty"`
+	Stage    string `json:"stage"`
+	Topic    string `json:"topic,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+const (
+	EnrichmentStarted  = "started"
+	EnrichmentEnriched = "enriched"
+	EnrichmentFailed   = "failed"
+)
+
+// publishSaved announces a new post, and its category when it got one.
+func (s *PostService) publishSaved(saveId string, post models.Post) {
+	data := PostEvent{SaveId: saveId, Post: post}
+	s.eventBus.Publish(events.New(events.PostCreated, post.UserId, post.WorkspaceId, data))
+	if post.Category != "" {
+		s.eventBus.Publish(events.New(events.PostCategorized, post.UserId, post.WorkspaceId, data))
+	}
+}
+
+func (s *PostService) publishEnrichment(scope repo.Scope, progress EnrichmentEvent) {
+	s.eventBus.Publish(events.New(events.PostEnrichment, scope.UserId, scope.WorkspaceId, progress))
+}
+
+var platforms = []string{"linkedin", "x", "reddit", "instagram", "others", "notes"}
+
+// recentPostsLimit is how many posts /posts/recent returns.
+const recentPostsLimit = 5
+
+var postStatuses = []string{models.PostStatusUnread, models.PostStatusReading, models.PostStatusRead, models.PostStatusArchived}
+
+// MediaDir is where media downloaded while saving a user's posts is kept,
+// so it can be exported and deleted with their account.
+func MediaDir(userId int64) string {
+	return fmt.Sprintf("downloads/users/%d", userId)
 }
 
 func (s *PostService) ExtractPostPlatform(userPost string, isUrl bool) (string, error) {
@@ -74,21 +132,27 @@ func (s *PostService) GenerateTagsAndCategoriesData(userTags []string) (string,
 	return tagString, categoryString, userTagsString, nil
 }
 
-func (s *PostService) SummarizePost(ctx *gin.Context, content string, userTags []string, MediaData dto.MediaData) (dto.SummarizePostResponse, error) {
+func (s *PostService) SummarizePost(scope repo.Scope, content string, userTags []string, MediaData dto.MediaData) (dto.SummarizePostResponse, error) {
 	tagString, categoryString, userTagsString, err := s.GenerateTagsAndCategoriesData(userTags)
 	if err != nil {
 		fmt.Println("Error generating tags and categories data: ", err)
 		return dto.SummarizePostResponse{}, err
 	}
+	pinnedCategories, err := s.categoryRepo.GetPinnedCategoryPaths(scope)
+	if err != nil {
+		fmt.Println("Error getting pinned categories: ", err)
+		return dto.SummarizePostResponse{}, err
+	}
+	pinnedCategoryString := strings.Join(pinnedCategories, "\n")
 	prompt := ""
 	summary := ""
 
 	if MediaData.IsMedia {
-		prompt = utilities.GenerateMediaCategorizationPrompt(MediaData.Media, tagString, categoryString, userTagsString)
+		prompt = utilities.GenerateMediaCategorizationPrompt(MediaData.Media, tagString, categoryString, userTagsString, pinnedCategoryString)
 		summary, err = s.geminiClient.GenerateContentWithMedia(context.Background(), prompt, MediaData.Media)
 
 	} else {
-		prompt = utilities.GenerateCategorizationPrompt(content, tagString, categoryString, userTagsString)
+		prompt = utilities.GenerateCategorizationPrompt(content, tagString, categoryString, userTagsString, pinnedCategoryString)
 		summary, err = s.geminiClient.GenerateContent(context.Background(), prompt)
 	}
 
@@ -110,10 +174,11 @@ func (s *PostService) SummarizePost(ctx *gin.Context, content string, userTags [
 	return summaryJson, nil
 }
 
-func (s *PostService) ExtractPostDetails(ctx *gin.Context, userPost string, platform string, tags []string) (models.Post, error) {
+func (s *PostService) ExtractPostDetails(scope repo.Scope, userPost string, platform string, tags []string) (models.Post, error) {
 	var summary dto.SummarizePostResponse
 	var scrapedPost scraper.ScrapedPost
 	author := ""
+	var profile scraper.AuthorProfile
 	var err error
 	if platform == "linkedin" {
 		// scrapedPost, err = scraper.ScrapeLinkedInPost(userPost, "socks5://10.101.116.69:1088")
@@ -123,7 +188,8 @@ func (s *PostService) ExtractPostDetails(ctx *gin.Context, userPost string, plat
 			return models.Post{}, err
 		}
 		author = scrapedPost.Author
-		summary, err = s.SummarizePost(ctx, scrapedPost.Content, tags, dto.MediaData{IsMedia: false, Media: nil})
+		profile = scrapedPost.Profile
+		summary, err = s.SummarizePost(scope, scrapedPost.Content, tags, dto.MediaData{IsMedia: false, Media: nil})
 		if err != nil {
 			fmt.Println("Error summarizing LinkedIn post: ", err)
 			return models.Post{}, err
@@ -136,7 +202,8 @@ func (s *PostService) ExtractPostDetails(ctx *gin.Context, userPost string, plat
 			return models.Post{}, err
 		}
 		author = scrapedPost.Author
-		summary, err = s.SummarizePost(ctx, scrapedPost.Content, tags, dto.MediaData{IsMedia: false, Media: nil})
+		profile = scrapedPost.Profile
+		summary, err = s.SummarizePost(scope, scrapedPost.Content, tags, dto.MediaData{IsMedia: false, Media: nil})
 		if err != nil {
 			fmt.Println("Error summarizing X post: ", err)
 			return models.Post{}, err
@@ -149,14 +216,15 @@ func (s *PostService) ExtractPostDetails(ctx *gin.Context, userPost string, plat
 			return models.Post{}, err
 		}
 		author = scrapedPost.Author
-		summary, err = s.SummarizePost(ctx, scrapedPost.Content, tags, dto.MediaData{IsMedia: false, Media: nil})
+		profile = scrapedPost.Profile
+		summary, err = s.SummarizePost(scope, scrapedPost.Content, tags, dto.MediaData{IsMedia: false, Media: nil})
 		if err != nil {
 			fmt.Println("Error summarizing Reddit post: ", err)
 			return models.Post{}, err
 		}
 	} else if platform == "instagram" {
 		config := &scraper.InstagramScraperConfig{
-			OutputDir: fmt.Sprintf("downloads/instagram/%d", time.Now().Unix()),
+			OutputDir: fmt.Sprintf("%s/instagram/%d", MediaDir(scope.UserId), time.Now().Unix()),
 			HTTPClient: &http.Client{
 				Timeout: 60 * time.Second,
 				// Transport: &http.Transport{
@@ -173,7 +241,8 @@ func (s *PostService) ExtractPostDetails(ctx *gin.Context, userPost string, plat
 			return models.Post{}, err
 		}
 		author = instagramScrapedPost.Author
-		summary, err = s.SummarizePost(ctx, "", tags, dto.MediaData{
+		profile = instagramScrapedPost.Profile
+		summary, err = s.SummarizePost(scope, "", tags, dto.MediaData{
 			IsMedia: true,
 			Media:   instagramScrapedPost.Data,
 		})
@@ -184,7 +253,7 @@ func (s *PostService) ExtractPostDetails(ctx *gin.Context, userPost string, plat
 	} else if platform == "others" {
 		summary.Tags = pq.StringArray(tags)
 	} else if platform == "notes" {
-		summary, err = s.SummarizePost(ctx, userPost, tags, dto.MediaData{IsMedia: false, Media: nil})
+		summary, err = s.SummarizePost(scope, userPost, tags, dto.MediaData{IsMedia: false, Media: nil})
 
 		if err != nil {
 			fmt.Println("Error summarizing notes: ", err)
@@ -194,41 +263,133 @@ func (s *PostService) ExtractPostDetails(ctx *gin.Context, userPost string, plat
 		return models.Post{}, fmt.Errorf("invalid platform")
 	}
 
+	normalizedTags, err := s.NormalizeTags(scope, summary.Tags)
+	if err != nil {
+		fmt.Println("Error normalizing tags: ", err)
+		return models.Post{}, err
+	}
+
+	category, err := s.ResolveCategory(scope, summary.Category)
+	if err != nil {
+		fmt.Println("Error resolving category: ", err)
+		return models.Post{}, err
+	}
+
+	authorId, author, err := s.ResolveAuthor(scope, platform, author, profile)
+	if err != nil {
+		fmt.Println("Error resolving author: ", err)
+		return models.Post{}, err
+	}
+
 	post := models.Post{
-		UserId:      ctx.GetInt64("user_id"),
+		UserId:      scope.UserId,
+		WorkspaceId: scope.WorkspaceId,
 		Data:        userPost,
 		Author:      author,
+		AuthorId:    authorId,
 		Topic:       summary.Topic,
 		Platform:    platform,
-		Category:    summary.Category,
-		Tags:        summary.Tags,
+		Category:    category,
+		Tags:        normalizedTags,
 		Description: summary.Description,
 	}
 	return post, nil
 }
 
-func (s *PostService) UpdateAuthorTagsCategories(ctx *gin.Context, post models.Post) error {
-	userId := ctx.GetInt64("user_id")
-	exists, err := s.postRepo.CheckUserAuthorExists(userId, post.Author, post.Platform)
+// ResolveAuthor links the scraped author to an author entity and returns the
+// entity's display name, so the same person is always stored under one name.
+func (s *PostService) ResolveAuthor(scope repo.Scope, platform string, author string, profile scraper.AuthorProfile) (*int64, string, error) {
+	if profile.DisplayName == "" {
+		profile.DisplayName = author
+	}
+	if profile.DisplayName == "Unknown" {
+		profile.DisplayName = ""
+	}
+	if profile.Handle == "" && profile.DisplayName == "" {
+		return nil, author, nil
+	}
+	resolved, err := s.authorRepo.ResolveAuthor(models.Author{
+		UserId:      scope.OwnerId(),
+		WorkspaceId: scope.WorkspaceId,
+		Platform:    platform,
+		Handle:      profile.Handle,
+		DisplayName: profile.DisplayName,
+		ProfileUrl:  profile.ProfileURL,
+		AvatarUrl:   profile.AvatarURL,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	name := resolved.DisplayName
+	if name == "" {
+		name = resolved.Handle
+	}
+	return &resolved.Id, name, nil
+}
+
+// ResolveCategory turns the suggested category into a path in the scope's
+// tree. When the user has pinned a taxonomy the suggestion is forced onto it;
+// otherwise any missing nodes of the suggested path are created.
+func (s *PostService) ResolveCategory(scope repo.Scope, suggested string) (string, error) {
+	category := utilities.NormalizeCategoryPath(suggested)
+	if category == "" {
+		return "", nil
+	}
+	pinnedCategories, err := s.categoryRepo.GetPinnedCategoryPaths(scope)
+	if err != nil {
+		return "", err
+	}
+	if len(pinnedCategories) > 0 {
+		category = utilities.ResolvePinnedCategory(category, pinnedCategories)
+		if category == "" {
+			category = utilities.UncategorizedCategory
+		}
+	}
+
+	node, err := s.categoryRepo.EnsureCategoryPath(scope, category, false)
+	if err != nil {
+		return "", err
+	}
+	return node.Path, nil
+}
+
+func (s *PostService) NormalizeTags(scope repo.Scope, tags []string) (pq.StringArray, error) {
+	if len(tags) == 0 {
+		return pq.StringArray(tags), nil
+	}
+	userTags, err := s.tagRepo.GetUserTagNames(scope)
+	if err != nil {
+		return nil, err
+	}
+	synonyms, err := s.tagRepo.GetTagSynonymMap(scope)
+	if err != nil {
+		return nil, err
+	}
+	return pq.StringArray(utilities.CanonicalizeTags(tags, userTags, synonyms)), nil
+}
+
+func (s *PostService) UpdateAuthorTagsCategories(post models.Post) error {
+	scope := repo.PostScope(post)
+	exists, err := s.postRepo.CheckUserAuthorExists(scope, post.Author, post.Platform)
 	if err != nil {
 		fmt.Println("Error checking user author exists: ", err)
 		return err
 	}
 	if !exists {
-		err = s.postRepo.AddUserAuthor(userId, post.Author, post.Platform)
+		err = s.postRepo.AddUserAuthor(scope, post.Author, post.Platform)
 		if err != nil {
 			fmt.Println("Error adding user author: ", err)
 			return err
 		}
 	}
 
-	err = s.postRepo.UpdateUserTags(userId, post.Platform, post.Tags)
+	err = s.postRepo.UpdateUserTags(scope, post.Platform, post.Tags)
 	if err != nil {
 		fmt.Println("Error updating user tags: ", err)
 		return err
 	}
 
-	err = s.postRepo.UpdateUserCategory(userId, post.Platform, post.Category)
+	err = s.postRepo.UpdateUserCategory(scope, post.Platform, post.Category)
 	if err != nil {
 		fmt.Println("Error updating user category: ", err)
 		return err
@@ -236,87 +397,234 @@ func (s *PostService) UpdateAuthorTagsCategories(ctx *gin.Context, post models.P
 	return nil
 }
 
-func (s *PostService) CreatePost(ctx *gin.Context) {
-	var request dto.CreatePostRequest
-	err := ctx.ShouldBindBodyWithJSON(&request)
+// RestorePost saves a post exported from lynkbin as it was, without
+// scraping or summarizing it again.
+func (s *PostService) RestorePost(scope repo.Scope, exported exporter.Post) (models.Post, error) {
+	if !slices.Contains(platforms, exported.Platform) {
+		return models.Post{}, fmt.Errorf("invalid platform %q", exported.Platform)
+	}
+	category := utilities.NormalizeCategoryPath(exported.Category)
+	if category != "" {
+		node, err := s.categoryRepo.EnsureCategoryPath(scope, category, false)
+		if err != nil {
+			return models.Post{}, err
+		}
+		category = node.Path
+	}
+	authorId, author, err := s.ResolveAuthor(scope, exported.Platform, exported.Author, scraper.AuthorProfile{})
 	if err != nil {
-		fmt.Println("Error binding request body: ", err)
-		utilities.Response(ctx, 400, false, nil, "Invalid request body")
-		return
+		return models.Post{}, err
+	}
+	post := models.Post{
+		UserId:      scope.UserId,
+		WorkspaceId: scope.WorkspaceId,
+		Data:        exported.Data,
+		Author:      author,
+		AuthorId:    authorId,
+		Topic:       exported.Topic,
+		Platform:    exported.Platform,
+		Category:    category,
+		Tags:        pq.StringArray(exported.Tags),
+		Description: exported.Description,
+		Source:      exported.Source,
+		CreatedAt:   exported.CreatedAt,
+	}
+	err = s.UpdateAuthorTagsCategories(post)
+	if err != nil {
+		return models.Post{}, err
 	}
+	err = s.postRepo.CreatePost(&post)
+	if err != nil {
+		return models.Post{}, err
+	}
+
+	// The reading state comes back as the importing user's. Exports from
+	// before it was kept have no status.
+	state := models.ReadingState{
+		Status:   exported.Status,
+		Favorite: exported.Favorite,
+		Progress: min(max(exported.Progress, 0), 100),
+	}
+	if !slices.Contains(postStatuses, state.Status) {
+		state.Status = models.PostStatusUnread
+	}
+	if state != (models.ReadingState{Status: models.PostStatusUnread}) {
+		err = s.postRepo.SavePostState(&models.PostState{UserId: scope.UserId, PostId: post.Id, ReadingState: state})
+		if err != nil {
+			return models.Post{}, err
+		}
+	}
+	s.publishSaved("", post)
+	return post, nil
+}
+
+// SavePostError is returned by SavePost for failures that should reach the
+// caller with a specific status and message.
+type SavePostError struct {
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *SavePostError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *SavePostError) Unwrap() error {
+	return e.Err
+}
+
+// savePost summarizes and stores a post once its platform is known.
+func (s *PostService) savePost(scope repo.Scope, request dto.CreatePostRequest, userPost string, platform string, progress *EnrichmentEvent) (models.Post, error) {
+	post, err := s.ExtractPostDetails(scope, userPost, platform, request.Tags)
+	if err != nil {
+		return models.Post{}, &SavePostError{StatusCode: 400, Message: "Failed to extract post details", Err: err}
+	}
+	if post.Topic == "" {
+		post.Topic = strings.TrimSpace(request.Title)
+	}
+	post.Source = request.Source
+	progress.Stage = EnrichmentEnriched
+	progress.Topic = post.Topic
+	s.publishEnrichment(scope, *progress)
+
+	err = s.UpdateAuthorTagsCategories(post)
+	if err != nil {
+		return models.Post{}, &SavePostError{StatusCode: 500, Message: "Failed to update author tags categories", Err: err}
+	}
+
+	err = s.postRepo.CreatePost(&post)
+	if err != nil {
+		return models.Post{}, &SavePostError{StatusCode: 500, Message: "Failed to create post", Err: err}
+	}
+	return post, nil
+}
+
+// SavePost runs the whole ingestion pipeline for a link or note: validation,
+// scraping, summarization, tag/category/author bookkeeping and storage. It is
+// shared by the HTTP API and the Telegram bot.
+func (s *PostService) SavePost(scope repo.Scope, request dto.CreatePostRequest) (models.CreatePostResponse, error) {
+	var err error
 	userPost := ""
 	if request.IsUrl {
 		request.Url, err = url.PathUnescape(request.Url)
 		if err != nil {
-			fmt.Println("Error unescaping URL: ", err)
-			utilities.Response(ctx, 400, false, nil, "Invalid url")
-			return
+			return models.CreatePostResponse{}, &SavePostError{StatusCode: 400, Message: "Invalid url", Err: err}
 		}
 		request.Url = strings.ReplaceAll(request.Url, "\\", "")
 		validator := validator.New()
 		if err := validator.Struct(request); err != nil {
-			utilities.Response(ctx, 400, false, nil, "Invalid request body")
-			return
+			return models.CreatePostResponse{}, &SavePostError{StatusCode: 400, Message: "Invalid request body", Err: err}
 		}
 		userPost = request.Url
 	} else if strings.TrimSpace(request.Notes) == "" {
-		utilities.Response(ctx, 400, false, nil, "Notes are required")
-		return
+		return models.CreatePostResponse{}, &SavePostError{StatusCode: 400, Message: "Notes are required"}
 	} else if len(strings.TrimSpace(request.Notes)) > 3500 {
-		utilities.Response(ctx, 400, false, nil, "maximum notes length is 3500 characters")
-		return
+		return models.CreatePostResponse{}, &SavePostError{StatusCode: 400, Message: "maximum notes length is 3500 characters"}
 	} else {
 		userPost = request.Notes
 	}
 
 	platform, err := s.ExtractPostPlatform(userPost, request.IsUrl)
 	if err != nil {
-		fmt.Println("Error extracting post platform: ", err)
-		utilities.Response(ctx, 400, false, nil, "Invalid url")
-		return
+		return models.CreatePostResponse{}, &SavePostError{StatusCode: 400, Message: "Invalid url", Err: err}
 	}
 
-	post, err := s.ExtractPostDetails(ctx, userPost, platform, request.Tags)
+	// Summarizing can take a while, so progress is announced for clients
+	// waiting on the post.
+	progress := EnrichmentEvent{SaveId: events.NewId(), Platform: platform, Stage: EnrichmentStarted}
+	if request.IsUrl {
+		progress.Url = userPost
+	}
+	s.publishEnrichment(scope, progress)
+	post, err := s.savePost(scope, request, userPost, platform, &progress)
 	if err != nil {
-		fmt.Println("Error extracting post details: ", err)
-		utilities.Response(ctx, 400, false, nil, "Failed to extract post details")
-		return
+		progress.Stage = EnrichmentFailed
+		progress.Error = "Failed to save post"
+		var saveErr *SavePostError
+		if errors.As(err, &saveErr) {
+			progress.Error = saveErr.Message
+		}
+		s.publishEnrichment(scope, progress)
+		return models.CreatePostResponse{}, err
 	}
+	s.publishSaved(progress.SaveId, post)
+
+	postLink := fmt.Sprintf("https://lynkbin.vercel.app/dashboard?platform=%s", platform)
+	return models.CreatePostResponse{
+		Post:     post,
+		PostLink: postLink,
+	}, nil
+}
 
-	err = s.UpdateAuthorTagsCategories(ctx, post)
+func (s *PostService) CreatePost(ctx *gin.Context) {
+	var request dto.CreatePostRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
 	if err != nil {
-		fmt.Println("Error updating author tags categories: ", err)
-		utilities.Response(ctx, 500, false, nil, "Failed to update author tags categories")
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
 		return
 	}
 
-	err = s.postRepo.CreatePost(&post)
+	response, err := s.SavePost(repo.ScopeFromContext(ctx), request)
 	if err != nil {
-		fmt.Println("Error creating post: ", err)
+		fmt.Println("Error saving post: ", err)
+		var saveErr *SavePostError
+		if errors.As(err, &saveErr) {
+			utilities.Response(ctx, saveErr.StatusCode, false, nil, saveErr.Message)
+			return
+		}
 		utilities.Response(ctx, 500, false, nil, "Failed to create post")
 		return
 	}
 
-	postLink := fmt.Sprintf("https://lynkbin.vercel.app/dashboard?platform=%s", platform)
-	response := models.CreatePostResponse{
-		Post:     post,
-		PostLink: postLink,
-	}
-
 	utilities.Response(ctx, 201, true, response, "Post created successfully")
 }
 
-func (s *PostService) GetPosts(ctx *gin.Context) {
-	userId := ctx.GetInt64("user_id")
+// bindPostFilter reads the post list filters shared by /posts,
+// /posts/recent and /posts/reading-list, answering 400 itself when they
+// don't parse.
+func bindPostFilter(ctx *gin.Context) (repo.PostFilter, bool) {
 	var request dto.GetPostsRequest
 	err := ctx.ShouldBindQuery(&request)
 	if err != nil {
 		fmt.Println("Error binding query parameters: ", err)
 		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return repo.PostFilter{}, false
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return repo.PostFilter{}, false
+	}
+
+	return repo.PostFilter{
+		Scope:          repo.ScopeFromContext(ctx),
+		Platform:       request.Platform,
+		Tags:           request.Tags,
+		Authors:        request.Authors,
+		Categories:     request.Categories,
+		ParentCategory: utilities.NormalizeCategoryPath(request.ParentCategory),
+		Query:          strings.TrimSpace(request.Query),
+		CollectionId:   request.CollectionId,
+		Statuses:       request.Status,
+		Favorite:       request.Favorite,
+		Snoozed:        request.Snoozed,
+		Sort:           request.Sort,
+		Order:          request.Order,
+	}, true
+}
+
+func (s *PostService) GetPosts(ctx *gin.Context) {
+	filter, ok := bindPostFilter(ctx)
+	if !ok {
 		return
 	}
 
-	posts, err := s.postRepo.GetPosts(userId, request.Platform, request.Tags, request.Authors, request.Categories)
+	posts, err := s.postRepo.GetPosts(filter)
 	if err != nil {
 		fmt.Println("Error getting posts: ", err)
 		utilities.Response(ctx, 500, false, nil, "Failed to get posts")
@@ -326,14 +634,37 @@ func (s *PostService) GetPosts(ctx *gin.Context) {
 	utilities.Response(ctx, 200, true, posts, "Posts fetched successfully")
 }
 
+// GetReadingList lists what the user still has to read: by default their
+// unread posts and the ones they are reading, leaving out snoozed ones.
+func (s *PostService) GetReadingList(ctx *gin.Context) {
+	filter, ok := bindPostFilter(ctx)
+	if !ok {
+		return
+	}
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []string{models.PostStatusUnread, models.PostStatusReading}
+	}
+	if filter.Snoozed == "" {
+		filter.Snoozed = "exclude"
+	}
+
+	posts, err := s.postRepo.GetPosts(filter)
+	if err != nil {
+		fmt.Println("Error getting reading list: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get reading list")
+		return
+	}
+	utilities.Response(ctx, 200, true, posts, "Reading list fetched successfully")
+}
+
 func (s *PostService) GetUserAuthors(ctx *gin.Context) {
-	userId := ctx.GetInt64("user_id")
+	scope := repo.ScopeFromContext(ctx)
 	platform := ctx.Query("platform")
 	if platform == "" {
 		utilities.Response(ctx, 400, false, nil, "Platform is required")
 		return
 	}
-	authors, err := s.postRepo.GetUserAuthors(userId, platform)
+	authors, err := s.postRepo.GetUserAuthors(scope, platform)
 	if err != nil {
 		fmt.Println("Error getting user authors: ", err)
 		utilities.Response(ctx, 500, false, nil, "Failed to get user authors")
@@ -343,13 +674,13 @@ func (s *PostService) GetUserAuthors(ctx *gin.Context) {
 }
 
 func (s *PostService) GetUserCategories(ctx *gin.Context) {
-	userId := ctx.GetInt64("user_id")
+	scope := repo.ScopeFromContext(ctx)
 	platform := ctx.Query("platform")
 	if platform == "" {
 		utilities.Response(ctx, 400, false, nil, "Platform is required")
 		return
 	}
-	categories, err := s.postRepo.GetUserCategories(userId, platform)
+	categories, err := s.postRepo.GetUserCategories(scope, platform)
 	if err != nil {
 		fmt.Println("Error getting user categories: ", err)
 		utilities.Response(ctx, 500, false, nil, "Failed to get user categories")
@@ -359,13 +690,13 @@ func (s *PostService) GetUserCategories(ctx *gin.Context) {
 }
 
 func (s *PostService) GetUserTags(ctx *gin.Context) {
-	userId := ctx.GetInt64("user_id")
+	scope := repo.ScopeFromContext(ctx)
 	platform := ctx.Query("platform")
 	if platform == "" {
 		utilities.Response(ctx, 400, false, nil, "Platform is required")
 		return
 	}
-	tags, err := s.postRepo.GetUserTags(userId, platform)
+	tags, err := s.postRepo.GetUserTags(scope, platform)
 	if err != nil {
 		fmt.Println("Error getting user tags: ", err)
 		utilities.Response(ctx, 500, false, nil, "Failed to get user tags")
@@ -375,35 +706,59 @@ func (s *PostService) GetUserTags(ctx *gin.Context) {
 }
 
 func (s *PostService) GetAllUserPostsTagsAndCategoriesCount(ctx *gin.Context) {
-	userId := ctx.GetInt64("user_id")
-	totalPostsCount, err := s.postRepo.GetAllUserPostsCount(userId)
+	scope := repo.ScopeFromContext(ctx)
+	totalPostsCount, err := s.postRepo.GetAllUserPostsCount(scope)
 	if err != nil {
 		fmt.Println("Error getting all user posts count: ", err)
 		utilities.Response(ctx, 500, false, nil, "Failed to get all user posts count")
 		return
 	}
-	totalTagsCount, err := s.postRepo.GetAllTagsCount(userId)
+	totalTagsCount, err := s.postRepo.GetAllTagsCount(scope)
 	if err != nil {
 		fmt.Println("Error getting all tags count: ", err)
 		utilities.Response(ctx, 500, false, nil, "Failed to get all tags count")
 		return
 	}
-	totalCategoriesCount, err := s.postRepo.GetAllCategoriesCount(userId)
+	totalCategoriesCount, err := s.postRepo.GetAllCategoriesCount(scope)
 	if err != nil {
 		fmt.Println("Error getting all categories count: ", err)
 		utilities.Response(ctx, 500, false, nil, "Failed to get all categories count")
 		return
 	}
+	unreadPostsCount, err := s.postRepo.GetUnreadPostsCount(scope)
+	if err != nil {
+		fmt.Println("Error getting unread posts count: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get unread posts count")
+		return
+	}
+	favoritePostsCount, err := s.postRepo.GetFavoritePostsCount(scope)
+	if err != nil {
+		fmt.Println("Error getting favorite posts count: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get favorite posts count")
+		return
+	}
 	response := dto.GetAllTagsAndCategoriesCountResponse{
 		TotalPostsCount:      totalPostsCount,
 		TotalTagsCount:       totalTagsCount,
 		TotalCategoriesCount: totalCategoriesCount,
+		UnreadPostsCount:     unreadPostsCount,
+		FavoritePostsCount:   favoritePostsCount,
 	}
 	utilities.Response(ctx, 200, true, response, "All counts fetched successfully")
 }
 
+// RemovePost deletes one of the scope's posts and announces it.
+func (s *PostService) RemovePost(scope repo.Scope, postId int64) (models.Post, error) {
+	post, err := s.postRepo.DeletePost(scope, postId)
+	if err != nil {
+		return models.Post{}, err
+	}
+	s.eventBus.Publish(events.New(events.PostDeleted, post.UserId, post.WorkspaceId, PostEvent{Post: post}))
+	return post, nil
+}
+
 func (s *PostService) DeletePost(ctx *gin.Context) {
-	userId := ctx.GetInt64("user_id")
+	scope := repo.ScopeFromContext(ctx)
 
 	// Get post ID from URL parameter
 	postIdStr := ctx.Param("id")
@@ -422,10 +777,10 @@ func (s *PostService) DeletePost(ctx *gin.Context) {
 	}
 
 	// Delete the post
-	err = s.postRepo.DeletePost(userId, postId)
+	_, err = s.RemovePost(scope, postId)
 	if err != nil {
 		fmt.Println("Error deleting post: ", err)
-		if err.Error() == "post not found or you don't have permission to delete it" {
+		if errors.Is(err, repo.ErrPostNotFound) {
 			utilities.Response(ctx, 404, false, nil, "Post not found or you don't have permission to delete it")
 			return
 		}
@@ -437,8 +792,12 @@ func (s *PostService) DeletePost(ctx *gin.Context) {
 }
 
 func (s *PostService) GetRecentPosts(ctx *gin.Context) {
-	userId := ctx.GetInt64("user_id")
-	posts, err := s.postRepo.GetRecentPosts(userId)
+	filter, ok := bindPostFilter(ctx)
+	if !ok {
+		return
+	}
+	filter.Limit = recentPostsLimit
+	posts, err := s.postRepo.GetPosts(filter)
 	if err != nil {
 		fmt.Println("Error getting recent posts: ", err)
 		utilities.Response(ctx, 500, false, nil, "Failed to get recent posts")
@@ -446,3 +805,124 @@ func (s *PostService) GetRecentPosts(ctx *gin.Context) {
 	}
 	utilities.Response(ctx, 200, true, posts, "Recent posts fetched successfully")
 }
+
+// UpdatePostState applies a change to the scope user's reading state of one
+// of the scope's posts. Progress moves an unread post to reading, and
+// finishing it marks it read, unless the request sets the status itself.
+func (s *PostService) UpdatePostState(scope repo.Scope, postId int64, request dto.UpdatePostStateRequest) (repo.PostWithState, error) {
+	post, err := s.postRepo.GetPostWithState(scope, postId)
+	if err != nil {
+		return repo.PostWithState{}, err
+	}
+
+	if request.Progress != nil {
+		post.Progress = *request.Progress
+		if post.Progress == 100 {
+			post.Status = models.PostStatusRead
+		} else if post.Progress > 0 && post.Status == models.PostStatusUnread {
+			post.Status = models.PostStatusReading
+		}
+	}
+	if request.Status != nil {
+		post.Status = *request.Status
+	}
+	if request.Favorite != nil {
+		post.Favorite = *request.Favorite
+	}
+	if request.SnoozedUntil != nil {
+		if request.SnoozedUntil.After(time.Now()) {
+			post.SnoozedUntil = request.SnoozedUntil
+		} else {
+			post.SnoozedUntil = nil
+		}
+	}
+
+	err = s.saveReadingState(scope, post)
+	if err != nil {
+		return repo.PostWithState{}, err
+	}
+	return post, nil
+}
+
+// OpenPost records that the scope's user opened the post, which starts
+// reading it.
+func (s *PostService) OpenPost(scope repo.Scope, postId int64) (repo.PostWithState, error) {
+	post, err := s.postRepo.GetPostWithState(scope, postId)
+	if err != nil {
+		return repo.PostWithState{}, err
+	}
+
+	now := time.Now()
+	post.LastOpenedAt = &now
+	if post.Status == models.PostStatusUnread {
+		post.Status = models.PostStatusReading
+	}
+	err = s.saveReadingState(scope, post)
+	if err != nil {
+		return repo.PostWithState{}, err
+	}
+	return post, nil
+}
+
+// saveReadingState stores the user's state of the post. The event carries
+// the post without it, since everyone in a workspace receives it.
+func (s *PostService) saveReadingState(scope repo.Scope, post repo.PostWithState) error {
+	err := s.postRepo.SavePostState(&models.PostState{UserId: scope.UserId, PostId: post.Id, ReadingState: post.ReadingState})
+	if err != nil {
+		return err
+	}
+	s.eventBus.Publish(events.New(events.PostUpdated, scope.UserId, post.WorkspaceId, PostEvent{Post: post.Post}))
+	return nil
+}
+
+func (s *PostService) UpdatePost(ctx *gin.Context) {
+	postId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid post ID")
+		return
+	}
+	var request dto.UpdatePostStateRequest
+	err = ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	post, err := s.UpdatePostState(repo.ScopeFromContext(ctx), postId, request)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Post not found")
+			return
+		}
+		fmt.Println("Error updating post: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update post")
+		return
+	}
+	utilities.Response(ctx, 200, true, post, "Post updated successfully")
+}
+
+func (s *PostService) MarkPostOpened(ctx *gin.Context) {
+	postId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid post ID")
+		return
+	}
+
+	post, err := s.OpenPost(repo.ScopeFromContext(ctx), postId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Post not found")
+			return
+		}
+		fmt.Println("Error opening post: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to open post")
+		return
+	}
+	utilities.Response(ctx, 200, true, post, "Post opened successfully")
+}
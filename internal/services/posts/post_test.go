@@ -0,0 +1,85 @@
+package posts
+
+import (
+	"module/lynkbin/internal/db/dbtest"
+	"module/lynkbin/internal/repo"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordingDB records the SQL of the queries it is asked to run.
+func recordingDB(t *testing.T, queries *[]string) *gorm.DB {
+	return dbtest.New(t, func(tx *gorm.DB) {
+		*queries = append(*queries, tx.Statement.SQL.String())
+	})
+}
+
+func TestOnlyReadingListHidesSnoozedPosts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var queries []string
+	s := NewPostService(repo.NewPostRepo(recordingDB(t, &queries)), nil, nil, nil, nil, nil)
+
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) { ctx.Set("user_id", int64(7)) })
+	router.GET("/posts", s.GetPosts)
+	router.GET("/posts/recent", s.GetRecentPosts)
+	router.GET("/posts/reading-list", s.GetReadingList)
+
+	for _, test := range []struct {
+		path        string
+		hideSnoozed bool
+	}{
+		{"/posts", false},
+		{"/posts/recent", false},
+		{"/posts/reading-list", true},
+		{"/posts?snoozed=exclude", true},
+		{"/posts/reading-list?snoozed=include", false},
+	} {
+		queries = nil
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if recorder.Code != http.StatusOK || len(queries) != 1 {
+			t.Fatalf("GET %s = %d with %d queries, want 200 with one", test.path, recorder.Code, len(queries))
+		}
+		_, where, _ := strings.Cut(queries[0], " WHERE ")
+		if got := strings.Contains(where, "snoozed_until"); got != test.hideSnoozed {
+			t.Errorf("GET %s filters on snoozed_until = %v, want %v:\n%s", test.path, got, test.hideSnoozed, queries[0])
+		}
+	}
+}
+
+func TestOrderAppliesToDefaultSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var queries []string
+	s := NewPostService(repo.NewPostRepo(recordingDB(t, &queries)), nil, nil, nil, nil, nil)
+
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) { ctx.Set("user_id", int64(7)) })
+	router.GET("/posts", s.GetPosts)
+
+	for _, test := range []struct {
+		path    string
+		orderBy string
+	}{
+		{"/posts", "posts.created_at DESC"},
+		{"/posts?order=asc", "posts.created_at ASC"},
+		{"/posts?sort=created_at&order=asc", "posts.created_at ASC"},
+		{"/posts?sort=progress&order=asc", "COALESCE(post_states.progress, 0) ASC NULLS LAST,posts.created_at DESC"},
+	} {
+		queries = nil
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if recorder.Code != http.StatusOK || len(queries) != 1 {
+			t.Fatalf("GET %s = %d with %d queries, want 200 with one", test.path, recorder.Code, len(queries))
+		}
+		_, orderBy, _ := strings.Cut(queries[0], " ORDER BY ")
+		if !strings.HasPrefix(orderBy, test.orderBy) {
+			t.Errorf("GET %s orders by %q, want %q", test.path, orderBy, test.orderBy)
+		}
+	}
+}
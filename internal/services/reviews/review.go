@@ -0,0 +1,230 @@
+package reviews
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	day = 24 * time.Hour
+
+	// A post first comes up for review this long after it was saved.
+	firstReviewAfter = 7 * day
+	// surfaceCooldown holds back a post that was sent out but got no
+	// feedback.
+	surfaceCooldown = 7 * day
+
+	defaultReviewLimit = 10
+
+	defaultEase = 2.5
+	minEase     = 1.3
+	// The first two useful reviews schedule these intervals, later ones
+	// multiply the last interval by the ease factor.
+	firstIntervalDays  = 7
+	secondIntervalDays = 30
+	// A post found not useful waits at least this long, twice its interval
+	// if that is longer.
+	notUsefulIntervalDays = 90
+	maxIntervalDays       = 365
+	// Favorites come back more often than the rest.
+	maxFavoriteIntervalDays = 60
+	// retireAfterLapses not useful answers in a row stop a post from being
+	// brought back at all.
+	retireAfterLapses = 3
+)
+
+// DuePost is a post due for review along with its schedule, which is nil
+// for posts that were never reviewed.
+type DuePost struct {
+	repo.PostWithState
+	Review *models.PostReview `json:"review"`
+}
+
+type ReviewService struct {
+	reviewRepo *repo.ReviewRepo
+	postRepo   *repo.PostRepo
+}
+
+func NewReviewService(reviewRepo *repo.ReviewRepo, postRepo *repo.PostRepo) *ReviewService {
+	return &ReviewService{reviewRepo: reviewRepo, postRepo: postRepo}
+}
+
+// quality maps feedback to an SM-2 answer grade out of 5.
+func quality(feedback string) int {
+	if feedback == models.ReviewUseful {
+		return 4
+	}
+	return 1
+}
+
+// Schedule applies an answer to a review, SM-2 style. Useful answers space
+// the post out further each time; not useful ones lower its ease and push it
+// well back, and enough of them in a row retire it.
+func Schedule(review models.PostReview, feedback string, favorite bool, now time.Time) models.PostReview {
+	if review.EaseFactor == 0 {
+		review.EaseFactor = defaultEase
+	}
+	q := float64(5 - quality(feedback))
+	review.EaseFactor = math.Max(minEase, review.EaseFactor+0.1-q*(0.08+q*0.02))
+
+	if feedback == models.ReviewUseful {
+		review.Repetitions++
+		review.Lapses = 0
+		if review.Repetitions == 1 {
+			review.IntervalDays = firstIntervalDays
+		} else if review.Repetitions == 2 {
+			review.IntervalDays = secondIntervalDays
+		} else {
+			review.IntervalDays = int(math.Round(float64(review.IntervalDays) * review.EaseFactor))
+		}
+	} else {
+		review.Repetitions = 0
+		review.Lapses++
+		review.IntervalDays = max(notUsefulIntervalDays, 2*review.IntervalDays)
+		if review.Lapses >= retireAfterLapses {
+			review.RetiredAt = &now
+		}
+	}
+
+	limit := maxIntervalDays
+	if favorite {
+		limit = maxFavoriteIntervalDays
+	}
+	review.IntervalDays = min(max(review.IntervalDays, 1), limit)
+	review.DueAt = now.Add(time.Duration(review.IntervalDays) * day)
+	review.LastFeedback = feedback
+	review.LastReviewedAt = &now
+	return review
+}
+
+// DuePosts returns up to limit of the scope's posts that are due for review.
+func (s *ReviewService) DuePosts(scope repo.Scope, limit int, now time.Time) ([]DuePost, error) {
+	posts, err := s.reviewRepo.GetDuePosts(scope, now, now.Add(-firstReviewAfter), limit)
+	if err != nil {
+		return nil, err
+	}
+	postIds := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		postIds = append(postIds, post.Id)
+	}
+	reviews, err := s.reviewRepo.GetReviews(scope.UserId, postIds)
+	if err != nil {
+		return nil, err
+	}
+	reviewsByPost := map[int64]models.PostReview{}
+	for _, review := range reviews {
+		reviewsByPost[review.PostId] = review
+	}
+
+	due := make([]DuePost, 0, len(posts))
+	for _, post := range posts {
+		duePost := DuePost{PostWithState: post}
+		if review, ok := reviewsByPost[post.Id]; ok {
+			duePost.Review = &review
+		}
+		due = append(due, duePost)
+	}
+	return due, nil
+}
+
+// MarkSurfaced records that the posts were sent out for review, so they
+// aren't sent again straight away if nobody answers.
+func (s *ReviewService) MarkSurfaced(scope repo.Scope, postIds []int64, now time.Time) error {
+	return s.reviewRepo.MarkSurfaced(scope, postIds, now, now.Add(surfaceCooldown))
+}
+
+// RecordFeedback reschedules one of the scope's posts in the user's own
+// schedule from their answer to its review.
+func (s *ReviewService) RecordFeedback(scope repo.Scope, postId int64, feedback string, now time.Time) (models.PostReview, error) {
+	post, err := s.postRepo.GetPostWithState(scope, postId)
+	if err != nil {
+		return models.PostReview{}, err
+	}
+	review, err := s.reviewRepo.GetReview(scope.UserId, post.Id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		review = models.PostReview{UserId: scope.UserId, PostId: post.Id, WorkspaceId: post.WorkspaceId, EaseFactor: defaultEase}
+	} else if err != nil {
+		return models.PostReview{}, err
+	}
+
+	review = Schedule(review, feedback, post.Favorite, now)
+	err = s.reviewRepo.SaveFeedback(&review, &models.PostReviewFeedback{
+		PostId:       post.Id,
+		UserId:       scope.UserId,
+		WorkspaceId:  post.WorkspaceId,
+		Feedback:     feedback,
+		IntervalDays: review.IntervalDays,
+	})
+	if err != nil {
+		return models.PostReview{}, err
+	}
+	return review, nil
+}
+
+func (s *ReviewService) GetReviewPosts(ctx *gin.Context) {
+	var request dto.GetReviewPostsRequest
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultReviewLimit
+	}
+
+	posts, err := s.DuePosts(repo.ScopeFromContext(ctx), request.Limit, time.Now())
+	if err != nil {
+		fmt.Println("Error getting posts to review: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get posts to review")
+		return
+	}
+	utilities.Response(ctx, 200, true, posts, "Posts to review fetched successfully")
+}
+
+func (s *ReviewService) ReviewPost(ctx *gin.Context) {
+	postId, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid post ID")
+		return
+	}
+	var request dto.ReviewPostRequest
+	err = ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	review, err := s.RecordFeedback(repo.ScopeFromContext(ctx), postId, request.Feedback, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Post not found")
+			return
+		}
+		fmt.Println("Error recording review: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to record review")
+		return
+	}
+	utilities.Response(ctx, 200, true, review, "Review recorded successfully")
+}
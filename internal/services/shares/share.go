@@ -0,0 +1,233 @@
+package shares
+
+import (
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/auth"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const shareBaseURL = "https://lynkbin.vercel.app/s/"
+
+// PublicPost is what a share link exposes about a post. It deliberately
+// leaves out anything tied to the owner's account.
+type PublicPost struct {
+	Topic       string    `json:"topic"`
+	Description string    `json:"description"`
+	Author      string    `json:"author"`
+	Platform    string    `json:"platform"`
+	Link        string    `json:"link"`
+	SavedAt     time.Time `json:"saved_at"`
+}
+
+type PublicCollectionItem struct {
+	Note string     `json:"note"`
+	Post PublicPost `json:"post"`
+}
+
+type PublicCollection struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Items       []PublicCollectionItem `json:"items"`
+}
+
+type ShareLinkResponse struct {
+	models.ShareLink
+	URL    string `json:"url"`
+	Active bool   `json:"active"`
+}
+
+type ShareService struct {
+	shareRepo      *repo.ShareRepo
+	postRepo       *repo.PostRepo
+	collectionRepo *repo.CollectionRepo
+}
+
+func NewShareService(shareRepo *repo.ShareRepo, postRepo *repo.PostRepo, collectionRepo *repo.CollectionRepo) *ShareService {
+	return &ShareService{shareRepo: shareRepo, postRepo: postRepo, collectionRepo: collectionRepo}
+}
+
+func shareLinkResponse(link models.ShareLink) ShareLinkResponse {
+	return ShareLinkResponse{
+		ShareLink: link,
+		URL:       shareBaseURL + link.Slug,
+		Active:    link.IsActive(time.Now()),
+	}
+}
+
+func publicPost(post models.Post) PublicPost {
+	return PublicPost{
+		Topic:       post.Topic,
+		Description: post.Description,
+		Author:      post.Author,
+		Platform:    post.Platform,
+		Link:        post.OriginalLink(),
+		SavedAt:     post.CreatedAt,
+	}
+}
+
+func (s *ShareService) CreateShareLink(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.CreateShareLinkRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Provide either post_id or collection_id")
+		return
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		utilities.Response(ctx, 400, false, nil, "expires_at must be in the future")
+		return
+	}
+
+	if request.PostId != nil {
+		_, err = s.postRepo.GetPost(scope, *request.PostId)
+	} else {
+		_, err = s.collectionRepo.GetCollection(scope, *request.CollectionId)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Nothing to share with that ID")
+			return
+		}
+		fmt.Println("Error getting share target: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create share link")
+		return
+	}
+
+	slug, err := auth.NewOpaqueToken()
+	if err != nil {
+		fmt.Println("Error generating share slug: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create share link")
+		return
+	}
+	link := models.ShareLink{
+		UserId:       scope.UserId,
+		WorkspaceId:  scope.WorkspaceId,
+		Slug:         slug,
+		PostId:       request.PostId,
+		CollectionId: request.CollectionId,
+		ExpiresAt:    request.ExpiresAt,
+	}
+	err = s.shareRepo.CreateShareLink(&link)
+	if err != nil {
+		fmt.Println("Error creating share link: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create share link")
+		return
+	}
+	utilities.Response(ctx, 201, true, shareLinkResponse(link), "Share link created successfully")
+}
+
+func (s *ShareService) GetShareLinks(ctx *gin.Context) {
+	links, err := s.shareRepo.GetShareLinks(repo.ScopeFromContext(ctx))
+	if err != nil {
+		fmt.Println("Error getting share links: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get share links")
+		return
+	}
+	response := make([]ShareLinkResponse, 0, len(links))
+	for _, link := range links {
+		response = append(response, shareLinkResponse(link))
+	}
+	utilities.Response(ctx, 200, true, response, "Share links fetched successfully")
+}
+
+func (s *ShareService) RevokeShareLink(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid share link ID")
+		return
+	}
+	revoked, err := s.shareRepo.RevokeShareLink(scope, id)
+	if err != nil {
+		fmt.Println("Error revoking share link: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to revoke share link")
+		return
+	}
+	if !revoked {
+		utilities.Response(ctx, 404, false, nil, "Share link not found")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Share link revoked successfully")
+}
+
+// GetPublicShare serves a share link without authentication. Expired and
+// revoked links look exactly like links that never existed.
+func (s *ShareService) GetPublicShare(ctx *gin.Context) {
+	link, err := s.shareRepo.GetShareLinkBySlug(ctx.Param("slug"))
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		fmt.Println("Error getting share link: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get shared content")
+		return
+	}
+	if err != nil || !link.IsActive(time.Now()) {
+		utilities.Response(ctx, 404, false, nil, "Share link not found")
+		return
+	}
+
+	// The target must still be in the library the link was created in.
+	scope := repo.Scope{UserId: link.UserId, WorkspaceId: link.WorkspaceId}
+	if link.PostId != nil {
+		post, err := s.postRepo.GetPost(scope, *link.PostId)
+		if err != nil {
+			s.respondMissingTarget(ctx, err)
+			return
+		}
+		utilities.Response(ctx, 200, true, gin.H{
+			"type": "post",
+			"post": publicPost(post),
+		}, "Shared post fetched successfully")
+		return
+	}
+
+	collection, err := s.collectionRepo.GetCollection(scope, *link.CollectionId)
+	if err != nil {
+		s.respondMissingTarget(ctx, err)
+		return
+	}
+	items, err := s.collectionRepo.GetCollectionItems(collection.Id)
+	if err != nil {
+		fmt.Println("Error getting collection items: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get shared content")
+		return
+	}
+	public := PublicCollection{
+		Name:        collection.Name,
+		Description: collection.Description,
+		Items:       make([]PublicCollectionItem, 0, len(items)),
+	}
+	for _, item := range items {
+		if item.Post == nil {
+			continue
+		}
+		public.Items = append(public.Items, PublicCollectionItem{Note: item.Note, Post: publicPost(*item.Post)})
+	}
+	utilities.Response(ctx, 200, true, gin.H{
+		"type":       "collection",
+		"collection": public,
+	}, "Shared collection fetched successfully")
+}
+
+func (s *ShareService) respondMissingTarget(ctx *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utilities.Response(ctx, 404, false, nil, "Share link not found")
+		return
+	}
+	fmt.Println("Error getting shared content: ", err)
+	utilities.Response(ctx, 500, false, nil, "Failed to get shared content")
+}
@@ -0,0 +1,143 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"module/lynkbin/internal/events"
+	"module/lynkbin/internal/repo"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// heartbeatInterval keeps proxies from closing an idle stream.
+	heartbeatInterval = 25 * time.Second
+	// authCheckInterval is how often an open stream checks that its
+	// credentials haven't been revoked and the user hasn't left the
+	// workspace.
+	authCheckInterval = time.Minute
+	// retryAfter tells EventSource how long to wait before reconnecting.
+	retryAfter = 5 * time.Second
+
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// BackendFromEnv reads EVENTS_BACKEND: "memory" (the default) keeps events
+// on the instance they happened on, "postgres" shares them between
+// instances with LISTEN/NOTIFY.
+func BackendFromEnv() (string, error) {
+	backend := os.Getenv("EVENTS_BACKEND")
+	if backend == "" {
+		return BackendMemory, nil
+	}
+	if backend != BackendMemory && backend != BackendPostgres {
+		return "", fmt.Errorf("invalid EVENTS_BACKEND %q, expected memory or postgres", backend)
+	}
+	return backend, nil
+}
+
+// Authorizer reports whether the credentials a stream was opened with are
+// still valid.
+type Authorizer func(ctx *gin.Context) (bool, error)
+
+type StreamService struct {
+	broker     *events.Broker
+	relay      events.Relay
+	authorized Authorizer
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+func NewStreamService(broker *events.Broker, relay events.Relay, authorized Authorizer) *StreamService {
+	return &StreamService{broker: broker, relay: relay, authorized: authorized, done: make(chan struct{})}
+}
+
+// Close ends every open stream, so a graceful shutdown doesn't wait on
+// clients that never hang up. Clients reconnect to another instance.
+func (s *StreamService) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// HandleEvent passes an event published on this instance to the relay.
+func (s *StreamService) HandleEvent(event events.Event) {
+	err := s.relay.Send(event)
+	if err != nil {
+		fmt.Println("Error relaying event: ", err)
+	}
+}
+
+// inScope reports whether an event belongs to the library a stream was
+// opened for.
+func inScope(scope repo.Scope, event events.Event) bool {
+	if scope.IsWorkspace() {
+		return event.WorkspaceId == scope.WorkspaceId
+	}
+	return event.WorkspaceId == 0 && event.UserId == scope.UserId
+}
+
+// StreamEvents streams the events of the current library as Server-Sent
+// Events until the client goes away or loses access. Events a client is too
+// slow to take are dropped rather than holding up everyone else.
+func (s *StreamService) StreamEvents(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	subscription := s.broker.Subscribe(func(event events.Event) bool {
+		return inScope(scope, event)
+	})
+	defer subscription.Close()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(200)
+	fmt.Fprintf(ctx.Writer, "retry: %d\n\n", retryAfter.Milliseconds())
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	authCheck := time.NewTicker(authCheckInterval)
+	defer authCheck.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-authCheck.C:
+			// A logout, revoked key or removal from the workspace ends the
+			// stream; the client's reconnect is then refused by the auth
+			// middleware. Errors close it too and leave the decision to
+			// that reconnect.
+			authorized, err := s.authorized(ctx)
+			if err != nil {
+				fmt.Println("Error checking stream authorization: ", err)
+			}
+			if !authorized {
+				return
+			}
+			continue
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(ctx.Writer, ": ping\n\n")
+			if err != nil {
+				return
+			}
+		case event := <-subscription.Events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				fmt.Println("Error encoding event: ", err)
+				continue
+			}
+			_, err = fmt.Fprintf(ctx.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+			if err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
@@ -0,0 +1,524 @@
+package subscriptions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/feedreader"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/services/posts"
+	"module/lynkbin/internal/utilities"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPollInterval = time.Hour
+	minPollInterval     = 5 * time.Minute
+	maxPollBackoff      = 24 * time.Hour
+
+	// pollCheckInterval is how often the poller looks for subscriptions
+	// that are due.
+	pollCheckInterval = time.Minute
+	// pollLease is how long a claimed subscription is left alone before
+	// another poller may take it over.
+	pollLease = 15 * time.Minute
+
+	// maxItemsPerPoll caps how many items one poll saves, since every item
+	// is summarized. The rest are picked up by the next poll, which comes
+	// sooner than usual.
+	maxItemsPerPoll  = 10
+	backlogPollDelay = 5 * time.Minute
+	// firstPollItems is how many of the newest items a new subscription
+	// saves; older ones are only marked seen.
+	firstPollItems = 3
+
+	// maxFeedSize caps how much of a feed is read.
+	maxFeedSize = 5 << 20
+	entriesPage = 50
+	feedAccept  = "application/rss+xml, application/atom+xml, application/rdf+xml, application/xml;q=0.9, text/xml;q=0.9, */*;q=0.1"
+	feedAgent   = "lynkbin-feed-reader/1.0"
+)
+
+var errNotModified = errors.New("feed not modified")
+
+type SubscriptionService struct {
+	subscriptionRepo *repo.SubscriptionRepo
+	postRepo         *repo.PostRepo
+	postService      *posts.PostService
+	client           *http.Client
+	interval         time.Duration
+}
+
+func NewSubscriptionService(subscriptionRepo *repo.SubscriptionRepo, postRepo *repo.PostRepo, postService *posts.PostService, interval time.Duration) *SubscriptionService {
+	return &SubscriptionService{
+		subscriptionRepo: subscriptionRepo,
+		postRepo:         postRepo,
+		postService:      postService,
+		client:           utilities.NewPublicHTTPClient(30 * time.Second),
+		interval:         interval,
+	}
+}
+
+// PollIntervalFromEnv reads SUBSCRIPTION_POLL_INTERVAL, how often each feed
+// is checked for new items (default 1h, at least 5m).
+func PollIntervalFromEnv() (time.Duration, error) {
+	value := os.Getenv("SUBSCRIPTION_POLL_INTERVAL")
+	if value == "" {
+		return defaultPollInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < minPollInterval {
+		return 0, fmt.Errorf("invalid SUBSCRIPTION_POLL_INTERVAL %q, expected a duration of at least %s", value, minPollInterval)
+	}
+	return interval, nil
+}
+
+// cleanTerms trims and drops empty or repeated keywords and tags.
+func cleanTerms(terms []string) []string {
+	seen := map[string]bool{}
+	cleaned := []string{}
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" || seen[strings.ToLower(term)] {
+			continue
+		}
+		seen[strings.ToLower(term)] = true
+		cleaned = append(cleaned, term)
+	}
+	return cleaned
+}
+
+func (s *SubscriptionService) loadSubscription(ctx *gin.Context) (models.Subscription, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid subscription ID")
+		return models.Subscription{}, false
+	}
+	subscription, err := s.subscriptionRepo.GetSubscription(repo.ScopeFromContext(ctx), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Subscription not found")
+			return models.Subscription{}, false
+		}
+		fmt.Println("Error getting subscription: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get subscription")
+		return models.Subscription{}, false
+	}
+	return subscription, true
+}
+
+// CreateSubscription follows a feed. The feed is fetched once up front so a
+// link that isn't a feed is turned away straight away.
+func (s *SubscriptionService) CreateSubscription(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.CreateSubscriptionRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	feedURL := strings.TrimSpace(request.Url)
+	if !strings.HasPrefix(feedURL, "http://") && !strings.HasPrefix(feedURL, "https://") {
+		utilities.Response(ctx, 400, false, nil, "Feed URL must be a web link")
+		return
+	}
+
+	exists, err := s.subscriptionRepo.SubscriptionUrlExists(scope, feedURL)
+	if err != nil {
+		fmt.Println("Error checking subscription: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create subscription")
+		return
+	}
+	if exists {
+		utilities.Response(ctx, 409, false, nil, "Already subscribed to this feed")
+		return
+	}
+
+	subscription := models.Subscription{
+		UserId:      scope.UserId,
+		WorkspaceId: scope.WorkspaceId,
+		Url:         feedURL,
+		Keywords:    cleanTerms(request.Keywords),
+		MatchTags:   cleanTerms(request.MatchTags),
+		NextPollAt:  time.Now(),
+	}
+	feed, err := s.fetch(ctx.Request.Context(), &subscription)
+	if err != nil {
+		fmt.Printf("Error fetching feed %s: %v\n", feedURL, err)
+		if errors.Is(err, utilities.ErrPrivateAddress) {
+			utilities.Response(ctx, 400, false, nil, "Feeds have to be on a public address")
+			return
+		}
+		utilities.Response(ctx, 400, false, nil, "Couldn't read a feed at that URL")
+		return
+	}
+	subscription.Title = feed.Title
+	// The first poll has to see the whole feed to pick the newest items.
+	subscription.ETag = ""
+	subscription.LastModified = ""
+	err = s.subscriptionRepo.CreateSubscription(&subscription)
+	if err != nil {
+		fmt.Println("Error creating subscription: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create subscription")
+		return
+	}
+	utilities.Response(ctx, 201, true, subscription, "Subscription created successfully")
+}
+
+func (s *SubscriptionService) GetSubscriptions(ctx *gin.Context) {
+	subscriptions, err := s.subscriptionRepo.GetSubscriptions(repo.ScopeFromContext(ctx))
+	if err != nil {
+		fmt.Println("Error getting subscriptions: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get subscriptions")
+		return
+	}
+	utilities.Response(ctx, 200, true, subscriptions, "Subscriptions fetched successfully")
+}
+
+func (s *SubscriptionService) UpdateSubscription(ctx *gin.Context) {
+	subscription, ok := s.loadSubscription(ctx)
+	if !ok {
+		return
+	}
+	var request dto.UpdateSubscriptionRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	subscription.Keywords = cleanTerms(request.Keywords)
+	subscription.MatchTags = cleanTerms(request.MatchTags)
+	if request.Paused && subscription.PausedAt == nil {
+		now := time.Now()
+		subscription.PausedAt = &now
+	} else if !request.Paused && subscription.PausedAt != nil {
+		subscription.PausedAt = nil
+		subscription.NextPollAt = time.Now()
+	}
+	err = s.subscriptionRepo.UpdateSubscriptionSettings(subscription)
+	if err != nil {
+		fmt.Println("Error updating subscription: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update subscription")
+		return
+	}
+	utilities.Response(ctx, 200, true, subscription, "Subscription updated successfully")
+}
+
+func (s *SubscriptionService) DeleteSubscription(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid subscription ID")
+		return
+	}
+	deleted, err := s.subscriptionRepo.DeleteSubscription(repo.ScopeFromContext(ctx), id)
+	if err != nil {
+		fmt.Println("Error deleting subscription: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to delete subscription")
+		return
+	}
+	if !deleted {
+		utilities.Response(ctx, 404, false, nil, "Subscription not found")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Subscription deleted successfully")
+}
+
+// GetSubscriptionEntries lists the items a subscription has seen, newest
+// first, and what became of them.
+func (s *SubscriptionService) GetSubscriptionEntries(ctx *gin.Context) {
+	subscription, ok := s.loadSubscription(ctx)
+	if !ok {
+		return
+	}
+	var request dto.GetSubscriptionEntriesRequest
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return
+	}
+	entries, err := s.subscriptionRepo.GetSubscriptionEntries(subscription.Id, entriesPage, request.Page*entriesPage)
+	if err != nil {
+		fmt.Println("Error getting subscription entries: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get subscription entries")
+		return
+	}
+	utilities.Response(ctx, 200, true, entries, "Subscription entries fetched successfully")
+}
+
+// Run polls subscriptions as they come due until ctx is done.
+func (s *SubscriptionService) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollCheckInterval)
+	defer ticker.Stop()
+	for {
+		for ctx.Err() == nil {
+			subscription, claimed, err := s.subscriptionRepo.ClaimDueSubscription(time.Now(), pollLease)
+			if err != nil {
+				fmt.Println("Error claiming subscription: ", err)
+			}
+			if !claimed {
+				break
+			}
+			s.poll(ctx, subscription)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetch downloads the feed, sending the validators of the last response.
+// It returns errNotModified when the feed hasn't changed, and otherwise
+// stores the new validators on the subscription.
+func (s *SubscriptionService) fetch(ctx context.Context, subscription *models.Subscription) (feedreader.Feed, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, subscription.Url, nil)
+	if err != nil {
+		return feedreader.Feed{}, err
+	}
+	request.Header.Set("Accept", feedAccept)
+	request.Header.Set("User-Agent", feedAgent)
+	if subscription.ETag != "" {
+		request.Header.Set("If-None-Match", subscription.ETag)
+	}
+	if subscription.LastModified != "" {
+		request.Header.Set("If-Modified-Since", subscription.LastModified)
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return feedreader.Feed{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotModified {
+		return feedreader.Feed{}, errNotModified
+	}
+	if response.StatusCode != http.StatusOK {
+		return feedreader.Feed{}, &statusError{code: response.StatusCode}
+	}
+	feed, err := feedreader.Parse(io.LimitReader(response.Body, maxFeedSize), subscription.Url)
+	if err != nil {
+		return feedreader.Feed{}, err
+	}
+	subscription.ETag = response.Header.Get("ETag")
+	subscription.LastModified = response.Header.Get("Last-Modified")
+	return feed, nil
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("feed responded with %d %s", e.code, http.StatusText(e.code))
+}
+
+// poll fetches a subscription's feed and saves the items it hasn't seen.
+// Failures back off exponentially; a feed that is gone for good pauses the
+// subscription.
+func (s *SubscriptionService) poll(ctx context.Context, subscription models.Subscription) {
+	// LastPolledAt only moves on success, so a feed that fails at first
+	// still gets its first poll.
+	firstPoll := subscription.LastPolledAt == nil
+	now := time.Now()
+
+	feed, err := s.fetch(ctx, &subscription)
+	if errors.Is(err, errNotModified) {
+		err = nil
+	} else if err == nil {
+		var backlog bool
+		backlog, err = s.saveItems(ctx, subscription, feed, firstPoll)
+		if feed.Title != "" {
+			subscription.Title = feed.Title
+		}
+		if backlog {
+			// Items were left over, so the next poll has to see the whole
+			// feed again rather than be told it hasn't changed.
+			subscription.ETag = ""
+			subscription.LastModified = ""
+		}
+		if err == nil && backlog {
+			subscription.LastPolledAt = &now
+			subscription.LastError = ""
+			subscription.FailureCount = 0
+			subscription.NextPollAt = now.Add(backlogPollDelay)
+			s.finishPoll(subscription)
+			return
+		}
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down; the lease runs out and the poll is retried.
+			return
+		}
+		fmt.Printf("Error polling subscription %d: %v\n", subscription.Id, err)
+		subscription.LastError = err.Error()
+		subscription.FailureCount++
+		var status *statusError
+		if errors.As(err, &status) && status.code == http.StatusGone {
+			subscription.PausedAt = &now
+		}
+		backoff := s.interval << min(subscription.FailureCount, 6)
+		subscription.NextPollAt = now.Add(min(backoff, maxPollBackoff))
+	} else {
+		subscription.LastPolledAt = &now
+		subscription.LastError = ""
+		subscription.FailureCount = 0
+		subscription.NextPollAt = now.Add(s.interval)
+	}
+	s.finishPoll(subscription)
+}
+
+func (s *SubscriptionService) finishPoll(subscription models.Subscription) {
+	err := s.subscriptionRepo.FinishPoll(subscription)
+	if err != nil {
+		fmt.Println("Error finishing subscription poll: ", err)
+	}
+}
+
+type newItem struct {
+	url  string
+	item feedreader.Item
+}
+
+// saveItems saves the feed's unseen items, newest first up to
+// maxItemsPerPoll, and reports whether some were left for later.
+func (s *SubscriptionService) saveItems(ctx context.Context, subscription models.Subscription, feed feedreader.Feed, firstPoll bool) (bool, error) {
+	var items []newItem
+	inFeed := map[string]bool{}
+	var urls []string
+	for _, item := range feed.Items {
+		if !strings.HasPrefix(item.Link, "http://") && !strings.HasPrefix(item.Link, "https://") {
+			continue
+		}
+		url := utilities.CanonicalURL(item.Link)
+		if inFeed[url] {
+			continue
+		}
+		inFeed[url] = true
+		urls = append(urls, url)
+		items = append(items, newItem{url: url, item: item})
+	}
+	seen, err := s.subscriptionRepo.GetSeenUrls(subscription.Id, urls)
+	if err != nil {
+		return false, err
+	}
+	unseen := items[:0]
+	for _, item := range items {
+		if !seen[item.url] {
+			unseen = append(unseen, item)
+		}
+	}
+
+	scope := repo.Scope{UserId: subscription.UserId, WorkspaceId: subscription.WorkspaceId}
+	saved := 0
+	backlog := false
+	for _, item := range unseen {
+		if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+		entry := models.SubscriptionEntry{
+			SubscriptionId: subscription.Id,
+			Url:            item.url,
+			Title:          item.item.Title,
+		}
+		switch {
+		case !matches(subscription, item.item):
+			entry.Status = models.SubscriptionEntrySkipped
+		case firstPoll && saved >= firstPollItems:
+			entry.Status = models.SubscriptionEntrySkipped
+		case saved >= maxItemsPerPoll:
+			backlog = true
+			continue
+		default:
+			saved++
+			s.saveItem(scope, subscription, item, &entry)
+		}
+		err = s.subscriptionRepo.CreateSubscriptionEntry(&entry)
+		if err != nil {
+			return backlog, err
+		}
+	}
+	return backlog, nil
+}
+
+// saveItem saves one item through the same path as CreatePost, unless the
+// library already has it.
+func (s *SubscriptionService) saveItem(scope repo.Scope, subscription models.Subscription, item newItem, entry *models.SubscriptionEntry) {
+	existing, err := s.postRepo.GetPostByData(scope, item.url)
+	if err == nil {
+		entry.Status = models.SubscriptionEntryDuplicate
+		entry.PostId = &existing.Id
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		fmt.Println("Error getting post by data: ", err)
+		entry.Status = models.SubscriptionEntryFailed
+		return
+	}
+	response, err := s.postService.SavePost(scope, dto.CreatePostRequest{
+		Url:    item.url,
+		IsUrl:  true,
+		Title:  item.item.Title,
+		Source: subscription.Url,
+	})
+	if err != nil {
+		fmt.Printf("Error saving feed item %s: %v\n", item.url, err)
+		entry.Status = models.SubscriptionEntryFailed
+		return
+	}
+	entry.Status = models.SubscriptionEntrySaved
+	entry.PostId = &response.Id
+}
+
+// matches applies the subscription's filters: with none every item is
+// kept, otherwise an item needs a keyword in its title or summary or one of
+// the tags among its categories.
+func matches(subscription models.Subscription, item feedreader.Item) bool {
+	if len(subscription.Keywords) == 0 && len(subscription.MatchTags) == 0 {
+		return true
+	}
+	text := strings.ToLower(item.Title + " " + item.Summary)
+	for _, keyword := range subscription.Keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	for _, tag := range subscription.MatchTags {
+		for _, category := range item.Categories {
+			if strings.EqualFold(tag, category) {
+				return true
+			}
+		}
+	}
+	return false
+}
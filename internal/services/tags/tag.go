@@ -0,0 +1,234 @@
+package tags
+
+import (
+	"fmt"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/events"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/services/posts"
+	"module/lynkbin/internal/utilities"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type TagService struct {
+	tagRepo  *repo.TagRepo
+	eventBus *events.Bus
+}
+
+func NewTagService(tagRepo *repo.TagRepo, eventBus *events.Bus) *TagService {
+	return &TagService{tagRepo: tagRepo, eventBus: eventBus}
+}
+
+// publishUpdated tells subscribers about the posts a merge or rename
+// rewrote.
+func (s *TagService) publishUpdated(scope repo.Scope, updated []models.Post) {
+	for _, post := range updated {
+		s.eventBus.Publish(events.New(events.PostUpdated, scope.UserId, post.WorkspaceId, posts.PostEvent{Post: post}))
+	}
+}
+
+// resolveTarget normalizes the target tag and reuses the user's existing
+// spelling of it when there is one.
+func (s *TagService) resolveTarget(scope repo.Scope, target string) (string, []string, error) {
+	userTags, err := s.tagRepo.GetUserTagNames(scope)
+	if err != nil {
+		return "", nil, err
+	}
+	resolved := utilities.CanonicalizeTags([]string{target}, userTags, nil)
+	if len(resolved) == 0 {
+		return "", userTags, nil
+	}
+	return resolved[0], userTags, nil
+}
+
+func (s *TagService) MergeTags(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.MergeTagsRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	target, userTags, err := s.resolveTarget(scope, request.Target)
+	if err != nil {
+		fmt.Println("Error getting user tags: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to merge tags")
+		return
+	}
+	if target == "" {
+		utilities.Response(ctx, 400, false, nil, "Invalid target tag")
+		return
+	}
+
+	sources := []string{}
+	for _, source := range request.Sources {
+		if source == target || slices.Contains(sources, source) {
+			continue
+		}
+		if !slices.Contains(userTags, source) {
+			utilities.Response(ctx, 404, false, nil, fmt.Sprintf("Tag %q not found", source))
+			return
+		}
+		sources = append(sources, source)
+	}
+	if len(sources) == 0 {
+		utilities.Response(ctx, 400, false, nil, "Nothing to merge")
+		return
+	}
+
+	updated, err := s.tagRepo.MergeTags(scope, sources, target)
+	if err != nil {
+		fmt.Println("Error merging tags: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to merge tags")
+		return
+	}
+	s.publishUpdated(scope, updated)
+	utilities.Response(ctx, 200, true, gin.H{"sources": sources, "target": target}, "Tags merged successfully")
+}
+
+func (s *TagService) RenameTag(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.RenameTagRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	userTags, err := s.tagRepo.GetUserTagNames(scope)
+	if err != nil {
+		fmt.Println("Error getting user tags: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to rename tag")
+		return
+	}
+	if !slices.Contains(userTags, request.From) {
+		utilities.Response(ctx, 404, false, nil, "Tag not found")
+		return
+	}
+
+	// Renaming only changes spelling; the new name must not collide with
+	// another existing tag, that is what merge is for.
+	to := utilities.NormalizeTag(request.To)
+	if to == "" {
+		utilities.Response(ctx, 400, false, nil, "Invalid tag name")
+		return
+	}
+	if to == request.From {
+		utilities.Response(ctx, 400, false, nil, "Nothing to rename")
+		return
+	}
+	toKey := utilities.TagKey(to)
+	for _, tag := range userTags {
+		if tag != request.From && utilities.TagKey(tag) == toKey {
+			utilities.Response(ctx, 409, false, nil, fmt.Sprintf("Tag %q already exists, merge the tags instead", tag))
+			return
+		}
+	}
+
+	updated, err := s.tagRepo.MergeTags(scope, []string{request.From}, to)
+	if err != nil {
+		fmt.Println("Error renaming tag: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to rename tag")
+		return
+	}
+	s.publishUpdated(scope, updated)
+	utilities.Response(ctx, 200, true, gin.H{"from": request.From, "to": to}, "Tag renamed successfully")
+}
+
+func (s *TagService) GetTagSynonyms(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	synonyms, err := s.tagRepo.GetTagSynonyms(scope)
+	if err != nil {
+		fmt.Println("Error getting tag synonyms: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get tag synonyms")
+		return
+	}
+	utilities.Response(ctx, 200, true, synonyms, "Tag synonyms fetched successfully")
+}
+
+func (s *TagService) AddTagSynonym(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.AddTagSynonymRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	tag, _, err := s.resolveTarget(scope, request.Tag)
+	if err != nil {
+		fmt.Println("Error getting user tags: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to add tag synonym")
+		return
+	}
+	if tag == "" || utilities.TagKey(request.Alias) == "" {
+		utilities.Response(ctx, 400, false, nil, "Invalid tag synonym")
+		return
+	}
+	if utilities.TagKey(request.Alias) == utilities.TagKey(tag) {
+		utilities.Response(ctx, 400, false, nil, "Alias and tag are the same")
+		return
+	}
+
+	err = s.tagRepo.AddTagSynonym(scope, request.Alias, tag)
+	if err != nil {
+		fmt.Println("Error adding tag synonym: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to add tag synonym")
+		return
+	}
+	utilities.Response(ctx, 201, true, gin.H{"alias": utilities.TagKey(request.Alias), "tag": tag}, "Tag synonym added successfully")
+}
+
+func (s *TagService) GetTags(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	var request dto.AggregateRequest
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		fmt.Println("Error binding query parameters: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request")
+		return
+	}
+
+	tags, err := s.tagRepo.GetTagAggregates(repo.AggregateQuery{
+		Scope:    scope,
+		Platform: request.Platform,
+		Search:   strings.TrimSpace(request.Q),
+		Sort:     request.Sort,
+		Limit:    request.Limit,
+	})
+	if err != nil {
+		fmt.Println("Error getting tags: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get tags")
+		return
+	}
+	utilities.Response(ctx, 200, true, tags, "Tags fetched successfully")
+}
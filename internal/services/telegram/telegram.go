@@ -0,0 +1,127 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/auth"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	linkCodeLength = 8
+	linkCodeTTL    = 10 * time.Minute
+)
+
+type TelegramService struct {
+	telegramRepo *repo.TelegramRepo
+}
+
+func NewTelegramService(telegramRepo *repo.TelegramRepo) *TelegramService {
+	return &TelegramService{telegramRepo: telegramRepo}
+}
+
+// HashLinkCode is how link codes are stored; codes are matched
+// case-insensitively since people retype them by hand.
+func HashLinkCode(code string) string {
+	return auth.HashToken(strings.ToUpper(strings.TrimSpace(code)))
+}
+
+func (s *TelegramService) CreateLinkCode(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	code, err := auth.NewLinkCode(linkCodeLength)
+	if err != nil {
+		fmt.Println("Error generating link code: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create link code")
+		return
+	}
+
+	linkCode := models.TelegramLinkCode{
+		UserId:    userId,
+		CodeHash:  HashLinkCode(code),
+		ExpiresAt: time.Now().Add(linkCodeTTL),
+	}
+	err = s.telegramRepo.CreateLinkCode(&linkCode)
+	if err != nil {
+		fmt.Println("Error creating link code: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create link code")
+		return
+	}
+
+	response := gin.H{
+		"code":       code,
+		"expires_at": linkCode.ExpiresAt,
+	}
+	if botUsername := os.Getenv("TELEGRAM_BOT_USERNAME"); botUsername != "" {
+		response["bot_link"] = fmt.Sprintf("https://t.me/%s?start=%s", botUsername, code)
+	}
+	utilities.Response(ctx, 201, true, response, "Link code created successfully")
+}
+
+func (s *TelegramService) GetLinks(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	links, err := s.telegramRepo.GetUserLinks(userId)
+	if err != nil {
+		fmt.Println("Error getting telegram links: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get telegram links")
+		return
+	}
+	utilities.Response(ctx, 200, true, links, "Telegram links fetched successfully")
+}
+
+func (s *TelegramService) DeleteLink(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	chatId, err := strconv.ParseInt(ctx.Param("chat_id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid chat ID")
+		return
+	}
+	deleted, err := s.telegramRepo.DeleteLink(userId, chatId)
+	if err != nil {
+		fmt.Println("Error deleting telegram link: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to unlink telegram chat")
+		return
+	}
+	if !deleted {
+		utilities.Response(ctx, 404, false, nil, "Telegram link not found")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Telegram chat unlinked successfully")
+}
+
+// LinkChat is called by the bot (authenticated with its service credential)
+// when a user sends it a link code.
+func (s *TelegramService) LinkChat(ctx *gin.Context) {
+	var request dto.LinkTelegramChatRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	link, err := s.telegramRepo.RedeemLinkCode(HashLinkCode(request.Code), request.ChatId, request.Username)
+	if err != nil {
+		if errors.Is(err, repo.ErrInvalidLinkCode) {
+			utilities.Response(ctx, 400, false, nil, "Link code is invalid or expired")
+			return
+		}
+		fmt.Println("Error linking telegram chat: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to link telegram chat")
+		return
+	}
+	utilities.Response(ctx, 200, true, link, "Telegram chat linked successfully")
+}
@@ -0,0 +1,250 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/auth"
+	"module/lynkbin/internal/clients/mailer"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/utilities"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	verifyEmailTokenTTL   = 48 * time.Hour
+	resetPasswordTokenTTL = time.Hour
+	// accountEmailInterval is how long to wait before sending the same kind
+	// of email to a user again.
+	accountEmailInterval = time.Minute
+
+	defaultDeletionGracePeriod = 7 * 24 * time.Hour
+)
+
+type AccountConfig struct {
+	// RequireEmailVerification keeps users from signing in with a password
+	// until they verified their email.
+	RequireEmailVerification bool
+	// AppURL is the web app the links in account emails open. Without it the
+	// emails carry the bare token.
+	AppURL string
+	// DeletionGracePeriod is how long a deleted account can still be
+	// recovered by signing in.
+	DeletionGracePeriod time.Duration
+}
+
+// AccountConfigFromEnv reads REQUIRE_EMAIL_VERIFICATION, APP_URL and
+// ACCOUNT_DELETION_GRACE_PERIOD (default 7 days).
+func AccountConfigFromEnv() (AccountConfig, error) {
+	config := AccountConfig{
+		AppURL:              strings.TrimRight(os.Getenv("APP_URL"), "/"),
+		DeletionGracePeriod: defaultDeletionGracePeriod,
+	}
+	if value := os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD"); value != "" {
+		gracePeriod, err := time.ParseDuration(value)
+		if err != nil {
+			return AccountConfig{}, fmt.Errorf("invalid ACCOUNT_DELETION_GRACE_PERIOD: %w", err)
+		}
+		config.DeletionGracePeriod = gracePeriod
+	}
+	if value := os.Getenv("REQUIRE_EMAIL_VERIFICATION"); value != "" {
+		required, err := strconv.ParseBool(value)
+		if err != nil {
+			return AccountConfig{}, fmt.Errorf("invalid REQUIRE_EMAIL_VERIFICATION: %w", err)
+		}
+		config.RequireEmailVerification = required
+	}
+	return config, nil
+}
+
+// sendAccountEmail issues a single-use token for purpose and emails it to
+// the user. Nothing is sent if the same kind of email went out moments ago.
+func (s *UserService) sendAccountEmail(ctx context.Context, user models.User, purpose string) error {
+	if s.mailer == nil {
+		return errors.New("email delivery is not configured")
+	}
+	recent, err := s.userRepo.HasRecentUserToken(*user.Id, purpose, time.Now().Add(-accountEmailInterval))
+	if err != nil || recent {
+		return err
+	}
+
+	tokenId, err := auth.NewSessionId()
+	if err != nil {
+		return err
+	}
+	ttl := verifyEmailTokenTTL
+	if purpose == models.UserTokenResetPassword {
+		ttl = resetPasswordTokenTTL
+	}
+	expiresAt := time.Now().Add(ttl)
+	token, err := s.tokenManager.IssueActionToken(purpose, *user.Id, tokenId, expiresAt)
+	if err != nil {
+		return err
+	}
+	err = s.userRepo.CreateUserToken(&models.UserToken{
+		Id:        tokenId,
+		UserId:    *user.Id,
+		Purpose:   purpose,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, accountEmail(s.accountConfig.AppURL, user, purpose, token))
+}
+
+func accountEmail(appURL string, user models.User, purpose string, token string) mailer.Message {
+	subject := "Verify your Lynkbin email"
+	intro := "Confirm this is your email address to finish setting up your Lynkbin account."
+	path := "/verify-email"
+	outro := "The link is valid for 48 hours."
+	if purpose == models.UserTokenResetPassword {
+		subject = "Reset your Lynkbin password"
+		intro = "Someone asked to reset the password of your Lynkbin account."
+		path = "/reset-password"
+		outro = "The link is valid for one hour. If you did not ask for this, you can ignore this email."
+	}
+
+	action := "Your code: " + token
+	if appURL != "" {
+		action = appURL + path + "?token=" + url.QueryEscape(token)
+	}
+	return mailer.Message{
+		To:      []string{user.Email},
+		Subject: subject,
+		Text:    fmt.Sprintf("Hi %s,\n\n%s\n\n%s\n\n%s\n", user.Name, intro, action, outro),
+	}
+}
+
+func (s *UserService) VerifyEmail(ctx *gin.Context) {
+	var request dto.VerifyEmailRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	claims, err := s.tokenManager.ParseActionToken(request.Token, models.UserTokenVerifyEmail)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid or expired verification link")
+		return
+	}
+	verified, err := s.userRepo.VerifyEmail(claims.ID, claims.UserId)
+	if err != nil {
+		fmt.Println("Error verifying email: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to verify email")
+		return
+	}
+	if !verified {
+		utilities.Response(ctx, 400, false, nil, "Invalid or expired verification link")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Email verified successfully")
+}
+
+// ResendVerification answers the same whether or not the email belongs to an
+// unverified account, so it can't be used to find out who has one.
+func (s *UserService) ResendVerification(ctx *gin.Context) {
+	user, ok := s.bindEmailUser(ctx)
+	if !ok {
+		return
+	}
+	if user != nil && user.EmailVerifiedAt == nil {
+		err := s.sendAccountEmail(ctx.Request.Context(), *user, models.UserTokenVerifyEmail)
+		if err != nil {
+			fmt.Println("Error sending verification email: ", err)
+		}
+	}
+	utilities.Response(ctx, 200, true, nil, "If the account needs verifying, an email is on its way")
+}
+
+// ForgotPassword answers the same whether or not an account exists, so it
+// can't be used to find out who has one.
+func (s *UserService) ForgotPassword(ctx *gin.Context) {
+	user, ok := s.bindEmailUser(ctx)
+	if !ok {
+		return
+	}
+	if user != nil {
+		err := s.sendAccountEmail(ctx.Request.Context(), *user, models.UserTokenResetPassword)
+		if err != nil {
+			fmt.Println("Error sending password reset email: ", err)
+		}
+	}
+	utilities.Response(ctx, 200, true, nil, "If an account exists for this email, a reset link is on its way")
+}
+
+// bindEmailUser reads an email from the request and looks up its user, which
+// is nil when there is none.
+func (s *UserService) bindEmailUser(ctx *gin.Context) (*models.User, bool) {
+	var request dto.EmailRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return nil, false
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return nil, false
+	}
+	user, err := s.userRepo.GetUserByEmail(request.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		fmt.Println("Error getting user by email: ", err)
+		utilities.Response(ctx, 500, false, nil, "Internal server error")
+		return nil, false
+	}
+	return user, true
+}
+
+func (s *UserService) ResetPassword(ctx *gin.Context) {
+	var request dto.ResetPasswordRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	claims, err := s.tokenManager.ParseActionToken(request.Token, models.UserTokenResetPassword)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid or expired reset link")
+		return
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), 10)
+	if err != nil {
+		fmt.Printf("Error hashing password: %v\n", err)
+		utilities.Response(ctx, 500, false, nil, "Internal server error")
+		return
+	}
+	reset, err := s.userRepo.ResetPassword(claims.ID, claims.UserId, string(hashedPassword))
+	if err != nil {
+		fmt.Println("Error resetting password: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to reset password")
+		return
+	}
+	if !reset {
+		utilities.Response(ctx, 400, false, nil, "Invalid or expired reset link")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Password reset successfully, please log in again")
+}
@@ -0,0 +1,239 @@
+package users
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/exporter"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/services/posts"
+	"module/lynkbin/internal/utilities"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// recentSignInWindow is how fresh a session has to be to stand in for the
+	// password of a user who has none.
+	recentSignInWindow = 10 * time.Minute
+
+	accountPurgeInterval = 10 * time.Minute
+	accountPurgeBatch    = 20
+)
+
+// ExportAccount streams a ZIP of everything the user has saved: posts as
+// JSON and Markdown, their reading state, tags, categories, authors and
+// collections, their imports, feeds, subscriptions and webhooks, their
+// review history, and the media downloaded for their posts.
+func (s *UserService) ExportAccount(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	export, err := s.accountRepo.GetAccountExport(userId)
+	if err != nil {
+		fmt.Println("Error getting account export: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to export account")
+		return
+	}
+	export.User.Password = ""
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"account.json", gin.H{"user": export.User, "identities": export.Identities}},
+		{"posts.json", export.Posts},
+		{"reading.json", export.PostStates},
+		{"tags.json", gin.H{"tags": export.Tags, "synonyms": export.TagSynonyms}},
+		{"categories.json", gin.H{"categories": export.Categories, "tree": export.CategoryNodes}},
+		{"authors.json", gin.H{"authors": export.Authors, "names": export.UserAuthors}},
+		{"collections.json", export.Collections},
+		{"imports.json", export.Imports},
+		{"feeds.json", export.Feeds},
+		{"subscriptions.json", export.Subscriptions},
+		{"webhooks.json", export.Webhooks},
+		{"reviews.json", gin.H{"reviews": export.Reviews, "answers": export.ReviewAnswers}},
+	}
+
+	filename := fmt.Sprintf("lynkbin-export-%s.zip", time.Now().Format("20060102"))
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Status(200)
+
+	// Once the body is being written the status can't change any more, so
+	// errors from here on can only cut the download short.
+	archive := zip.NewWriter(ctx.Writer)
+	for _, file := range files {
+		err = writeZipJSON(archive, file.name, file.data)
+		if err != nil {
+			fmt.Println("Error writing account export: ", err)
+			return
+		}
+	}
+	states := map[int64]models.ReadingState{}
+	for _, state := range export.PostStates {
+		states[state.PostId] = state.ReadingState
+	}
+	for _, post := range export.Posts {
+		state, ok := states[post.Id]
+		if !ok {
+			state.Status = models.PostStatusUnread
+		}
+		writer, err := archive.Create(fmt.Sprintf("posts/%d.md", post.Id))
+		if err == nil {
+			_, err = io.WriteString(writer, exporter.Markdown(exporter.NewPost(post, state, nil)))
+		}
+		if err != nil {
+			fmt.Println("Error writing account export: ", err)
+			return
+		}
+	}
+	err = writeZipDir(archive, posts.MediaDir(userId), "media")
+	if err != nil {
+		fmt.Println("Error writing account export media: ", err)
+		return
+	}
+	err = archive.Close()
+	if err != nil {
+		fmt.Println("Error writing account export: ", err)
+	}
+}
+
+func writeZipJSON(archive *zip.Writer, name string, data any) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// writeZipDir adds the files under dir to the archive below prefix. A
+// missing dir adds nothing.
+func writeZipDir(archive *zip.Writer, dir string, prefix string) error {
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		writer, err := archive.Create(prefix + "/" + filepath.ToSlash(relative))
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(writer, file)
+		return err
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// DeleteAccount schedules the account for deletion after the grace period
+// and signs it out everywhere. Signing in again before then keeps it.
+func (s *UserService) DeleteAccount(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	var request dto.DeleteAccountRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil && !errors.Is(err, io.EOF) {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	user, err := s.userRepo.GetUserById(userId)
+	if err != nil {
+		fmt.Println("Error getting user by id: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to delete account")
+		return
+	}
+	if user.Password != "" {
+		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password))
+		if err != nil {
+			utilities.Response(ctx, 403, false, nil, "Invalid password")
+			return
+		}
+	} else {
+		startedAt, err := s.sessionRepo.GetSessionStartedAt(userId, ctx.GetString("session_id"))
+		if err != nil {
+			fmt.Println("Error getting session: ", err)
+			utilities.Response(ctx, 500, false, nil, "Failed to delete account")
+			return
+		}
+		if time.Since(startedAt) > recentSignInWindow {
+			utilities.Response(ctx, 403, false, nil, "Please sign in again to delete your account")
+			return
+		}
+	}
+
+	deleteAt := time.Now().Add(s.accountConfig.DeletionGracePeriod)
+	err = s.accountRepo.ScheduleDeletion(userId, deleteAt)
+	if err != nil {
+		fmt.Println("Error scheduling account deletion: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to delete account")
+		return
+	}
+	utilities.Response(ctx, 200, true, gin.H{"delete_at": deleteAt},
+		"Account scheduled for deletion, sign in again before then to keep it")
+}
+
+// RunAccountPurge deletes accounts whose grace period is over until ctx is
+// done.
+func (s *UserService) RunAccountPurge(ctx context.Context) {
+	ticker := time.NewTicker(accountPurgeInterval)
+	defer ticker.Stop()
+	for {
+		s.purgeDueAccounts(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *UserService) purgeDueAccounts(ctx context.Context) {
+	now := time.Now()
+	userIds, err := s.accountRepo.GetAccountsDueForDeletion(now, accountPurgeBatch)
+	if err != nil {
+		fmt.Println("Error getting accounts due for deletion: ", err)
+		return
+	}
+	for _, userId := range userIds {
+		if ctx.Err() != nil {
+			return
+		}
+		err = s.accountRepo.DeleteAccount(userId, now)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Signed in again since it was picked up.
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Error deleting account of user %d: %v\n", userId, err)
+			continue
+		}
+		// The rows are gone for good at this point; media left behind by a
+		// failure here is only reachable from disk.
+		err = os.RemoveAll(posts.MediaDir(userId))
+		if err != nil {
+			fmt.Printf("Error deleting media of user %d: %v\n", userId, err)
+		}
+		fmt.Printf("Deleted account of user %d\n", userId)
+	}
+}
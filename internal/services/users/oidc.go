@@ -0,0 +1,225 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"module/lynkbin/internal/auth"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+func (s *UserService) loadOIDCProvider(ctx *gin.Context) (*auth.OIDCProvider, bool) {
+	provider, ok := s.oidcProviders[strings.ToLower(ctx.Param("provider"))]
+	if !ok {
+		utilities.Response(ctx, 404, false, nil, "Unknown login provider")
+		return nil, false
+	}
+	return provider, true
+}
+
+func (s *UserService) GetOIDCProviders(ctx *gin.Context) {
+	names := make([]string, 0, len(s.oidcProviders))
+	for name := range s.oidcProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	utilities.Response(ctx, 200, true, names, "Login providers fetched successfully")
+}
+
+// StartOIDCLogin begins the authorization-code flow. The client sends the
+// user to the returned URL and passes the code and state the provider
+// redirects back with to FinishOIDCLogin.
+func (s *UserService) StartOIDCLogin(ctx *gin.Context) {
+	provider, ok := s.loadOIDCProvider(ctx)
+	if !ok {
+		return
+	}
+	state, err := auth.NewOpaqueToken()
+	if err != nil {
+		fmt.Println("Error generating login state: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to start login")
+		return
+	}
+	nonce, err := auth.NewOpaqueToken()
+	if err != nil {
+		fmt.Println("Error generating login nonce: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to start login")
+		return
+	}
+	verifier, challenge, err := auth.NewPKCEVerifier()
+	if err != nil {
+		fmt.Println("Error generating PKCE verifier: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to start login")
+		return
+	}
+
+	authorizationURL, err := provider.AuthorizationURL(ctx.Request.Context(), state, nonce, challenge)
+	if err != nil {
+		fmt.Println("Error building authorization url: ", err)
+		utilities.Response(ctx, 502, false, nil, "Login provider is unavailable")
+		return
+	}
+	err = s.identityRepo.CreateLoginState(&models.OIDCLoginState{
+		StateHash:    auth.HashToken(state),
+		Provider:     provider.Name(),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		fmt.Println("Error saving login state: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to start login")
+		return
+	}
+	utilities.Response(ctx, 200, true, gin.H{
+		"authorization_url": authorizationURL,
+		"state":             state,
+	}, "Login started successfully")
+}
+
+// FinishOIDCLogin exchanges the code and signs the user in. A provider
+// account seen for the first time is linked to the user with the same
+// verified email, or becomes a new user.
+func (s *UserService) FinishOIDCLogin(ctx *gin.Context) {
+	provider, ok := s.loadOIDCProvider(ctx)
+	if !ok {
+		return
+	}
+	var request dto.OIDCCallbackRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	state, err := s.identityRepo.ConsumeLoginState(auth.HashToken(request.State), provider.Name())
+	if err != nil {
+		if errors.Is(err, repo.ErrInvalidLoginState) {
+			utilities.Response(ctx, 400, false, nil, "Login has expired, please try again")
+			return
+		}
+		fmt.Println("Error getting login state: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to log in")
+		return
+	}
+	identity, err := provider.Exchange(ctx.Request.Context(), request.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		fmt.Println("Error exchanging authorization code: ", err)
+		utilities.Response(ctx, 401, false, nil, "Login with the provider failed")
+		return
+	}
+
+	userId, err := s.resolveOIDCUser(provider.Name(), identity)
+	if err != nil {
+		if errors.Is(err, errUnverifiedEmail) {
+			utilities.Response(ctx, 403, false, nil, "Your account at the provider has no verified email")
+			return
+		}
+		if errors.Is(err, errUnverifiedAccount) {
+			utilities.Response(ctx, 409, false, nil, "An account with this email already exists, sign in with your password and verify your email before using this provider")
+			return
+		}
+		log.Printf("Error resolving login identity: %v\n", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to log in")
+		return
+	}
+
+	tokens, err := s.issueTokens(ctx, userId)
+	if err != nil {
+		log.Printf("Error issuing tokens: %v\n", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to generate token")
+		return
+	}
+	utilities.Response(ctx, 200, true, tokens, "User logged in successfully")
+}
+
+var (
+	errUnverifiedEmail   = errors.New("provider did not return a verified email")
+	errUnverifiedAccount = errors.New("existing account has not verified its email")
+)
+
+// resolveOIDCUser finds or creates the user behind a provider identity. Only
+// verified emails are trusted for linking, otherwise anyone could claim an
+// existing account by signing up at a provider with its email. The existing
+// account has to have verified the email too: anyone can register an
+// address with a password, and linking to such an account would hand the
+// real owner's sign-in to whoever set that password.
+func (s *UserService) resolveOIDCUser(provider string, identity auth.OIDCIdentity) (int64, error) {
+	existing, err := s.identityRepo.GetIdentity(provider, identity.Subject)
+	if err == nil {
+		return existing.UserId, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		return 0, errUnverifiedEmail
+	}
+
+	link := models.UserIdentity{
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	user, err := s.userRepo.GetUserByEmail(identity.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	if user != nil {
+		if user.EmailVerifiedAt == nil {
+			return 0, errUnverifiedAccount
+		}
+		link.UserId = *user.Id
+		err = s.identityRepo.CreateIdentity(&link)
+		if err != nil {
+			return 0, err
+		}
+		return link.UserId, nil
+	}
+
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	// Users created here have no password and can only sign in through a
+	// provider until they set one.
+	verifiedAt := time.Now()
+	newUser := models.User{
+		Name:            name,
+		Email:           identity.Email,
+		EmailVerifiedAt: &verifiedAt,
+	}
+	err = s.identityRepo.CreateUserWithIdentity(&newUser, &link)
+	if err != nil {
+		return 0, err
+	}
+	return *newUser.Id, nil
+}
+
+func (s *UserService) GetIdentities(ctx *gin.Context) {
+	identities, err := s.identityRepo.GetUserIdentities(ctx.GetInt64("user_id"))
+	if err != nil {
+		fmt.Println("Error getting identities: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get linked accounts")
+		return
+	}
+	utilities.Response(ctx, 200, true, identities, "Linked accounts fetched successfully")
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"module/lynkbin/internal/auth"
+	"module/lynkbin/internal/clients/mailer"
 	"module/lynkbin/internal/dto"
 	"module/lynkbin/internal/models"
 	"module/lynkbin/internal/repo"
@@ -12,17 +14,91 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+var errRefreshTokenReused = errors.New("refresh token already used")
+
 type UserService struct {
-	userRepo *repo.UserRepo
+	userRepo      *repo.UserRepo
+	sessionRepo   *repo.SessionRepo
+	identityRepo  *repo.IdentityRepo
+	accountRepo   *repo.AccountRepo
+	tokenManager  *auth.TokenManager
+	oidcProviders map[string]*auth.OIDCProvider
+	// mailer sends verification and password reset emails. It is nil when
+	// email delivery is not configured.
+	mailer        mailer.Mailer
+	accountConfig AccountConfig
 }
 
-func NewUserService(userRepo *repo.UserRepo) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(userRepo *repo.UserRepo, sessionRepo *repo.SessionRepo, identityRepo *repo.IdentityRepo, accountRepo *repo.AccountRepo, tokenManager *auth.TokenManager, oidcProviders map[string]*auth.OIDCProvider, mailer mailer.Mailer, accountConfig AccountConfig) *UserService {
+	return &UserService{
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		identityRepo:  identityRepo,
+		accountRepo:   accountRepo,
+		tokenManager:  tokenManager,
+		oidcProviders: oidcProviders,
+		mailer:        mailer,
+		accountConfig: accountConfig,
+	}
+}
+
+// issueTokens starts a new session and returns its first access and refresh
+// tokens. Signing in also keeps an account that was about to be deleted.
+func (s *UserService) issueTokens(ctx *gin.Context, userId int64) (dto.TokenResponse, error) {
+	cancelled, err := s.accountRepo.CancelDeletion(userId)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+	if cancelled {
+		fmt.Printf("Account deletion of user %d cancelled by sign in\n", userId)
+	}
+	sessionId, err := auth.NewSessionId()
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+	return s.issueSessionTokens(ctx, userId, sessionId, nil)
+}
+
+// issueSessionTokens issues the next pair of tokens for a session. When
+// current is set it is rotated out atomically.
+func (s *UserService) issueSessionTokens(ctx *gin.Context, userId int64, sessionId string, current *models.RefreshToken) (dto.TokenResponse, error) {
+	refreshToken, err := auth.NewOpaqueToken()
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+	next := models.RefreshToken{
+		UserId:    userId,
+		SessionId: sessionId,
+		TokenHash: auth.HashToken(refreshToken),
+		UserAgent: ctx.Request.UserAgent(),
+		ExpiresAt: time.Now().Add(s.tokenManager.RefreshTokenTTL),
+	}
+	if current == nil {
+		err = s.sessionRepo.CreateRefreshToken(&next)
+	} else {
+		var rotated bool
+		rotated, err = s.sessionRepo.RotateRefreshToken(*current, &next)
+		if err == nil && !rotated {
+			err = errRefreshTokenReused
+		}
+	}
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	accessToken, err := s.tokenManager.IssueAccessToken(userId, sessionId)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+	return dto.TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.tokenManager.AccessTokenTTL.Seconds()),
+	}, nil
 }
 
 func (s *UserService) RegisterUser(ctx *gin.Context) {
@@ -69,19 +145,24 @@ func (s *UserService) RegisterUser(ctx *gin.Context) {
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": int64(*newUser.Id),
-		"exp":     time.Now().Add(time.Hour * 720).Unix(),
-	})
+	err = s.sendAccountEmail(ctx.Request.Context(), newUser, models.UserTokenVerifyEmail)
+	if err != nil {
+		// The user can ask for another email, so the sign up still succeeds.
+		fmt.Println("Error sending verification email: ", err)
+	}
+	if s.accountConfig.RequireEmailVerification {
+		utilities.Response(ctx, 201, true, nil, "User registered successfully, check your email to verify your account")
+		return
+	}
 
-	signedToken, err := token.SignedString([]byte("secret_key"))
+	tokens, err := s.issueTokens(ctx, *newUser.Id)
 	if err != nil {
-		log.Printf("Error signing token: %v\n", err)
+		log.Printf("Error issuing tokens: %v\n", err)
 		utilities.Response(ctx, 500, false, nil, "Failed to generate token")
 		return
 	}
 
-	utilities.Response(ctx, 201, true, gin.H{"token": signedToken}, "User registered successfully")
+	utilities.Response(ctx, 201, true, tokens, "User registered successfully")
 }
 
 func (s *UserService) LoginUser(ctx *gin.Context) {
@@ -115,20 +196,19 @@ func (s *UserService) LoginUser(ctx *gin.Context) {
 		utilities.Response(ctx, 400, false, nil, "Invalid password")
 		return
 	}
+	if s.accountConfig.RequireEmailVerification && user.EmailVerifiedAt == nil {
+		utilities.Response(ctx, 403, false, nil, "Please verify your email before logging in")
+		return
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": int64(*user.Id),
-		"exp":     time.Now().Add(time.Hour * 720).Unix(),
-	})
-
-	signedToken, err := token.SignedString([]byte("secret_key"))
+	tokens, err := s.issueTokens(ctx, *user.Id)
 	if err != nil {
-		log.Printf("Error signing token: %v\n", err)
+		log.Printf("Error issuing tokens: %v\n", err)
 		utilities.Response(ctx, 500, false, nil, "Failed to generate token")
 		return
 	}
 
-	utilities.Response(ctx, 200, true, gin.H{"token": signedToken}, "User logged in successfully")
+	utilities.Response(ctx, 200, true, tokens, "User logged in successfully")
 }
 
 func (s *UserService) GetCurrentUser(ctx *gin.Context) {
@@ -147,3 +227,104 @@ func (s *UserService) GetCurrentUser(ctx *gin.Context) {
 	}
 	utilities.Response(ctx, 200, true, user, "User fetched successfully")
 }
+
+func (s *UserService) RefreshToken(ctx *gin.Context) {
+	var request dto.RefreshTokenRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	current, err := s.sessionRepo.GetRefreshTokenByHash(auth.HashToken(request.RefreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 401, false, nil, "Invalid refresh token")
+			return
+		}
+		log.Printf("Error getting refresh token: %v\n", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to refresh token")
+		return
+	}
+	if current.RevokedAt != nil || time.Now().After(current.ExpiresAt) {
+		utilities.Response(ctx, 401, false, nil, "Refresh token expired or revoked")
+		return
+	}
+
+	tokens := dto.TokenResponse{}
+	if current.UsedAt == nil {
+		tokens, err = s.issueSessionTokens(ctx, current.UserId, current.SessionId, &current)
+	} else {
+		err = errRefreshTokenReused
+	}
+	if errors.Is(err, errRefreshTokenReused) {
+		// A rotated token showing up again means it leaked; end the session.
+		log.Printf("Refresh token reuse detected for session %s\n", current.SessionId)
+		err = s.sessionRepo.RevokeSession(current.UserId, current.SessionId)
+		if err != nil {
+			log.Printf("Error revoking session: %v\n", err)
+		}
+		utilities.Response(ctx, 401, false, nil, "Refresh token already used")
+		return
+	}
+	if err != nil {
+		log.Printf("Error refreshing token: %v\n", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to refresh token")
+		return
+	}
+	utilities.Response(ctx, 200, true, tokens, "Token refreshed successfully")
+}
+
+func (s *UserService) Logout(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	sessionId := ctx.GetString("session_id")
+	if sessionId == "" {
+		utilities.Response(ctx, 400, false, nil, "No session to log out of")
+		return
+	}
+	err := s.sessionRepo.RevokeSession(userId, sessionId)
+	if err != nil {
+		log.Printf("Error revoking session: %v\n", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to log out")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Logged out successfully")
+}
+
+func (s *UserService) LogoutAllSessions(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	err := s.sessionRepo.RevokeAllUserSessions(userId)
+	if err != nil {
+		log.Printf("Error revoking sessions: %v\n", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to log out")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Logged out of all sessions successfully")
+}
+
+func (s *UserService) GetSessions(ctx *gin.Context) {
+	userId := ctx.GetInt64("user_id")
+	sessions, err := s.sessionRepo.GetActiveSessions(userId)
+	if err != nil {
+		log.Printf("Error getting sessions: %v\n", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get sessions")
+		return
+	}
+	currentSessionId := ctx.GetString("session_id")
+	response := make([]gin.H, len(sessions))
+	for i, session := range sessions {
+		response[i] = gin.H{
+			"session_id":  session.SessionId,
+			"user_agent":  session.UserAgent,
+			"last_active": session.CreatedAt,
+			"expires_at":  session.ExpiresAt,
+			"current":     session.SessionId == currentSessionId,
+		}
+	}
+	utilities.Response(ctx, 200, true, response, "Sessions fetched successfully")
+}
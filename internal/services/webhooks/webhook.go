@@ -0,0 +1,423 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"module/lynkbin/internal/auth"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/events"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	secretPrefix = "whsec_"
+
+	// deliveryCheckInterval is how often the worker looks for retries that
+	// are due; new events wake it straight away.
+	deliveryCheckInterval = 15 * time.Second
+	deliveryLease         = 2 * time.Minute
+	deliveryTimeout       = 15 * time.Second
+
+	// Retries wait 30s, 1m, 2m, ... up to maxRetryDelay, and a delivery is
+	// given up on after maxAttempts.
+	firstRetryDelay = 30 * time.Second
+	maxRetryDelay   = 6 * time.Hour
+	maxAttempts     = 10
+
+	// maxResponseDrain is how much of a response is read, and thrown away,
+	// so the connection can be reused.
+	maxResponseDrain = 64 << 10
+	deliveriesPage   = 50
+	signatureHeader  = "X-Lynkbin-Signature"
+)
+
+type WebhookService struct {
+	webhookRepo *repo.WebhookRepo
+	client      *http.Client
+	wake        chan struct{}
+}
+
+func NewWebhookService(webhookRepo *repo.WebhookRepo) *WebhookService {
+	// Webhook URLs come from users, so deliveries only go to public
+	// addresses, checked on every connection rather than when the URL is
+	// saved since DNS can change in between.
+	client := utilities.NewPublicHTTPClient(deliveryTimeout)
+	// A redirected POST usually arrives as a GET; better to log the
+	// redirect than to deliver nothing.
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		client:      client,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+type CreateWebhookResponse struct {
+	Webhook models.Webhook `json:"webhook"`
+	// Secret is only ever shown once, when the webhook is created.
+	Secret string `json:"secret"`
+}
+
+// Sign returns the signature header of a payload sent at timestamp. The
+// receiver recomputes the HMAC-SHA256 of "<timestamp>.<body>" with the
+// webhook's secret, compares it with v1 and rejects old timestamps to stop
+// replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// HandleEvent queues deliveries of an event published on the bus. They are
+// stored before the request that caused the event finishes, so they survive
+// a restart.
+func (s *WebhookService) HandleEvent(event events.Event) {
+	if !slices.Contains(events.Types, event.Type) {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		fmt.Println("Error encoding webhook event: ", err)
+		return
+	}
+	scope := repo.Scope{UserId: event.UserId, WorkspaceId: event.WorkspaceId}
+	err = s.webhookRepo.QueueEvent(scope, event.Id, event.Type, string(payload))
+	if err != nil {
+		fmt.Println("Error queueing webhook deliveries: ", err)
+		return
+	}
+	s.wakeWorker()
+}
+
+func (s *WebhookService) wakeWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func bindWebhookRequest(ctx *gin.Context) (dto.WebhookRequest, bool) {
+	var request dto.WebhookRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return request, false
+	}
+	request.Url = strings.TrimSpace(request.Url)
+	request.Description = strings.TrimSpace(request.Description)
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return request, false
+	}
+	if !strings.HasPrefix(request.Url, "http://") && !strings.HasPrefix(request.Url, "https://") {
+		utilities.Response(ctx, 400, false, nil, "Webhook URL must be a web link")
+		return request, false
+	}
+	// Deliveries check every address they dial; this only catches the
+	// obvious cases early.
+	if !isPublicHost(request.Url) {
+		utilities.Response(ctx, 400, false, nil, "Webhook URL must be on a public address")
+		return request, false
+	}
+	return request, true
+}
+
+// isPublicHost reports whether a webhook URL's host could be public: a
+// hostname other than localhost, or a public IP address.
+func isPublicHost(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return true
+	}
+	return utilities.IsPublicAddr(ip)
+}
+
+func (s *WebhookService) loadWebhook(ctx *gin.Context) (models.Webhook, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid webhook ID")
+		return models.Webhook{}, false
+	}
+	webhook, err := s.webhookRepo.GetWebhook(repo.ScopeFromContext(ctx), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Webhook not found")
+			return models.Webhook{}, false
+		}
+		fmt.Println("Error getting webhook: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get webhook")
+		return models.Webhook{}, false
+	}
+	return webhook, true
+}
+
+func (s *WebhookService) CreateWebhook(ctx *gin.Context) {
+	scope := repo.ScopeFromContext(ctx)
+	request, ok := bindWebhookRequest(ctx)
+	if !ok {
+		return
+	}
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		fmt.Println("Error generating webhook secret: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create webhook")
+		return
+	}
+	webhook := models.Webhook{
+		UserId:      scope.UserId,
+		WorkspaceId: scope.WorkspaceId,
+		Url:         request.Url,
+		Description: request.Description,
+		Events:      request.Events,
+		Secret:      secretPrefix + token,
+	}
+	if request.Disabled {
+		now := time.Now()
+		webhook.DisabledAt = &now
+	}
+	err = s.webhookRepo.CreateWebhook(&webhook)
+	if err != nil {
+		fmt.Println("Error creating webhook: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create webhook")
+		return
+	}
+	utilities.Response(ctx, 201, true, CreateWebhookResponse{Webhook: webhook, Secret: webhook.Secret}, "Webhook created successfully")
+}
+
+func (s *WebhookService) GetWebhooks(ctx *gin.Context) {
+	webhooks, err := s.webhookRepo.GetWebhooks(repo.ScopeFromContext(ctx))
+	if err != nil {
+		fmt.Println("Error getting webhooks: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get webhooks")
+		return
+	}
+	utilities.Response(ctx, 200, true, webhooks, "Webhooks fetched successfully")
+}
+
+// UpdateWebhook changes where a webhook points and what it receives.
+// Re-enabling it lets the deliveries queued meanwhile go out.
+func (s *WebhookService) UpdateWebhook(ctx *gin.Context) {
+	webhook, ok := s.loadWebhook(ctx)
+	if !ok {
+		return
+	}
+	request, ok := bindWebhookRequest(ctx)
+	if !ok {
+		return
+	}
+	webhook.Url = request.Url
+	webhook.Description = request.Description
+	webhook.Events = request.Events
+	if request.Disabled && webhook.DisabledAt == nil {
+		now := time.Now()
+		webhook.DisabledAt = &now
+	} else if !request.Disabled {
+		webhook.DisabledAt = nil
+	}
+	err := s.webhookRepo.UpdateWebhook(webhook)
+	if err != nil {
+		fmt.Println("Error updating webhook: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update webhook")
+		return
+	}
+	s.wakeWorker()
+	utilities.Response(ctx, 200, true, webhook, "Webhook updated successfully")
+}
+
+func (s *WebhookService) DeleteWebhook(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid webhook ID")
+		return
+	}
+	deleted, err := s.webhookRepo.DeleteWebhook(repo.ScopeFromContext(ctx), id)
+	if err != nil {
+		fmt.Println("Error deleting webhook: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to delete webhook")
+		return
+	}
+	if !deleted {
+		utilities.Response(ctx, 404, false, nil, "Webhook not found")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Webhook deleted successfully")
+}
+
+// GetDeliveries is the webhook's delivery log, newest first, e.g. with
+// ?status=failed to find what needs replaying.
+func (s *WebhookService) GetDeliveries(ctx *gin.Context) {
+	webhook, ok := s.loadWebhook(ctx)
+	if !ok {
+		return
+	}
+	var request dto.GetWebhookDeliveriesRequest
+	err := ctx.ShouldBindQuery(&request)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid query parameters")
+		return
+	}
+	deliveries, err := s.webhookRepo.GetDeliveries(webhook.Id, request.Status, deliveriesPage, request.Page*deliveriesPage)
+	if err != nil {
+		fmt.Println("Error getting webhook deliveries: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get webhook deliveries")
+		return
+	}
+	utilities.Response(ctx, 200, true, deliveries, "Webhook deliveries fetched successfully")
+}
+
+// ReplayDelivery sends an earlier delivery's event again as a new delivery,
+// with the same event ID so the receiver can tell it has seen it.
+func (s *WebhookService) ReplayDelivery(ctx *gin.Context) {
+	webhook, ok := s.loadWebhook(ctx)
+	if !ok {
+		return
+	}
+	deliveryId, err := strconv.ParseInt(ctx.Param("delivery_id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid delivery ID")
+		return
+	}
+	original, err := s.webhookRepo.GetDelivery(webhook.Id, deliveryId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Delivery not found")
+			return
+		}
+		fmt.Println("Error getting webhook delivery: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to replay delivery")
+		return
+	}
+	replay := models.WebhookDelivery{
+		WebhookId:     webhook.Id,
+		EventId:       original.EventId,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	err = s.webhookRepo.CreateDelivery(&replay)
+	if err != nil {
+		fmt.Println("Error creating webhook delivery: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to replay delivery")
+		return
+	}
+	s.wakeWorker()
+	utilities.Response(ctx, 201, true, replay, "Delivery queued for replay")
+}
+
+// Run sends queued deliveries until ctx is done.
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(deliveryCheckInterval)
+	defer ticker.Stop()
+	for {
+		for ctx.Err() == nil {
+			delivery, webhook, claimed, err := s.webhookRepo.ClaimDueDelivery(time.Now(), deliveryLease)
+			if err != nil {
+				fmt.Println("Error claiming webhook delivery: ", err)
+			}
+			if !claimed {
+				break
+			}
+			s.deliver(ctx, delivery, webhook)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *WebhookService) deliver(ctx context.Context, delivery models.WebhookDelivery, webhook models.Webhook) {
+	delivery.Attempts++
+	statusCode, err := s.post(ctx, delivery, webhook)
+	if ctx.Err() != nil {
+		// Shutting down; the lease runs out and the attempt is retried.
+		return
+	}
+	delivery.StatusCode = statusCode
+	delivery.Error = ""
+	now := time.Now()
+	if err == nil && statusCode >= 200 && statusCode < 300 {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+	} else {
+		if err != nil {
+			delivery.Error = err.Error()
+		} else {
+			delivery.Error = fmt.Sprintf("endpoint responded with %d", statusCode)
+		}
+		if delivery.Attempts >= maxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+		} else {
+			delay := firstRetryDelay << (delivery.Attempts - 1)
+			delivery.NextAttemptAt = now.Add(min(delay, maxRetryDelay))
+		}
+	}
+	err = s.webhookRepo.FinishDeliveryAttempt(delivery)
+	if err != nil {
+		fmt.Println("Error finishing webhook delivery: ", err)
+	}
+}
+
+func (s *WebhookService) post(ctx context.Context, delivery models.WebhookDelivery, webhook models.Webhook) (int, error) {
+	body := []byte(delivery.Payload)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "lynkbin-webhooks/1.0")
+	request.Header.Set("X-Lynkbin-Event", delivery.Event)
+	request.Header.Set("X-Lynkbin-Event-Id", delivery.EventId)
+	request.Header.Set("X-Lynkbin-Delivery", strconv.FormatInt(delivery.Id, 10))
+	request.Header.Set(signatureHeader, Sign(webhook.Secret, time.Now().Unix(), body))
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	// Only the status code is kept; the body is whatever the endpoint
+	// chose to send back and isn't ours to store.
+	io.Copy(io.Discard, io.LimitReader(response.Body, maxResponseDrain))
+	return response.StatusCode, nil
+}
@@ -0,0 +1,42 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/utilities"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeliveryRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("delivery reached a loopback endpoint")
+	}))
+	defer server.Close()
+
+	s := NewWebhookService(nil)
+	delivery := models.WebhookDelivery{Id: 1, EventId: "evt_1", Event: "post.created", Payload: `{}`}
+	_, err := s.post(context.Background(), delivery, models.Webhook{Url: server.URL, Secret: "whsec_test"})
+	if !errors.Is(err, utilities.ErrPrivateAddress) {
+		t.Fatalf("post to %s error = %v, want ErrPrivateAddress", server.URL, err)
+	}
+}
+
+func TestIsPublicHost(t *testing.T) {
+	for rawURL, want := range map[string]bool{
+		"https://hooks.example.com/lynkbin": true,
+		"http://93.184.216.34/hook":         true,
+		"http://localhost:8080/hook":        false,
+		"http://api.localhost/hook":         false,
+		"http://127.0.0.1/hook":             false,
+		"http://169.254.169.254/latest":     false,
+		"http://[::1]/hook":                 false,
+		"http://10.0.0.5/hook":              false,
+	} {
+		if got := isPublicHost(rawURL); got != want {
+			t.Errorf("isPublicHost(%s) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
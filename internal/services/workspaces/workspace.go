@@ -0,0 +1,357 @@
+package workspaces
+
+import (
+	"errors"
+	"fmt"
+	"module/lynkbin/internal/auth"
+	"module/lynkbin/internal/dto"
+	"module/lynkbin/internal/models"
+	"module/lynkbin/internal/repo"
+	"module/lynkbin/internal/utilities"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const inviteTTL = 7 * 24 * time.Hour
+
+type WorkspaceService struct {
+	workspaceRepo *repo.WorkspaceRepo
+	userRepo      *repo.UserRepo
+}
+
+func NewWorkspaceService(workspaceRepo *repo.WorkspaceRepo, userRepo *repo.UserRepo) *WorkspaceService {
+	return &WorkspaceService{workspaceRepo: workspaceRepo, userRepo: userRepo}
+}
+
+// loadMembership resolves the :id parameter to a workspace the current user
+// belongs to. With ownerOnly set, members that are not owners are turned
+// away. It responds with an error if it can't.
+func (s *WorkspaceService) loadMembership(ctx *gin.Context, ownerOnly bool) (models.WorkspaceMember, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid workspace ID")
+		return models.WorkspaceMember{}, false
+	}
+	member, err := s.workspaceRepo.GetMembership(id, ctx.GetInt64("user_id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utilities.Response(ctx, 404, false, nil, "Workspace not found")
+			return models.WorkspaceMember{}, false
+		}
+		fmt.Println("Error getting workspace membership: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get workspace")
+		return models.WorkspaceMember{}, false
+	}
+	if ownerOnly && member.Role != models.WorkspaceRoleOwner {
+		utilities.Response(ctx, 403, false, nil, "Only workspace owners can do this")
+		return models.WorkspaceMember{}, false
+	}
+	return member, true
+}
+
+func bindWorkspaceRequest(ctx *gin.Context) (dto.WorkspaceRequest, bool) {
+	var request dto.WorkspaceRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return request, false
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return request, false
+	}
+	return request, true
+}
+
+func (s *WorkspaceService) GetWorkspaces(ctx *gin.Context) {
+	workspaces, err := s.workspaceRepo.GetWorkspaces(ctx.GetInt64("user_id"))
+	if err != nil {
+		fmt.Println("Error getting workspaces: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get workspaces")
+		return
+	}
+	utilities.Response(ctx, 200, true, workspaces, "Workspaces fetched successfully")
+}
+
+func (s *WorkspaceService) CreateWorkspace(ctx *gin.Context) {
+	request, ok := bindWorkspaceRequest(ctx)
+	if !ok {
+		return
+	}
+	workspace := models.Workspace{
+		Name:      request.Name,
+		CreatedBy: ctx.GetInt64("user_id"),
+	}
+	err := s.workspaceRepo.CreateWorkspace(&workspace)
+	if err != nil {
+		fmt.Println("Error creating workspace: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create workspace")
+		return
+	}
+	utilities.Response(ctx, 201, true, workspace, "Workspace created successfully")
+}
+
+func (s *WorkspaceService) GetWorkspace(ctx *gin.Context) {
+	member, ok := s.loadMembership(ctx, false)
+	if !ok {
+		return
+	}
+	workspace, err := s.workspaceRepo.GetWorkspace(member.WorkspaceId)
+	if err != nil {
+		fmt.Println("Error getting workspace: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get workspace")
+		return
+	}
+	members, err := s.workspaceRepo.GetMembers(member.WorkspaceId)
+	if err != nil {
+		fmt.Println("Error getting workspace members: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get workspace")
+		return
+	}
+	utilities.Response(ctx, 200, true, gin.H{
+		"workspace": workspace,
+		"role":      member.Role,
+		"members":   members,
+	}, "Workspace fetched successfully")
+}
+
+func (s *WorkspaceService) UpdateWorkspace(ctx *gin.Context) {
+	member, ok := s.loadMembership(ctx, true)
+	if !ok {
+		return
+	}
+	request, ok := bindWorkspaceRequest(ctx)
+	if !ok {
+		return
+	}
+	workspace, err := s.workspaceRepo.GetWorkspace(member.WorkspaceId)
+	if err != nil {
+		fmt.Println("Error getting workspace: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update workspace")
+		return
+	}
+	workspace.Name = request.Name
+	err = s.workspaceRepo.UpdateWorkspace(&workspace)
+	if err != nil {
+		fmt.Println("Error updating workspace: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to update workspace")
+		return
+	}
+	utilities.Response(ctx, 200, true, workspace, "Workspace updated successfully")
+}
+
+func (s *WorkspaceService) DeleteWorkspace(ctx *gin.Context) {
+	member, ok := s.loadMembership(ctx, true)
+	if !ok {
+		return
+	}
+	err := s.workspaceRepo.DeleteWorkspace(member.WorkspaceId)
+	if err != nil {
+		fmt.Println("Error deleting workspace: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to delete workspace")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Workspace deleted successfully")
+}
+
+func (s *WorkspaceService) UpdateMember(ctx *gin.Context) {
+	member, ok := s.loadMembership(ctx, true)
+	if !ok {
+		return
+	}
+	userId, err := strconv.ParseInt(ctx.Param("user_id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid user ID")
+		return
+	}
+	var request dto.UpdateWorkspaceMemberRequest
+	err = ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Role must be one of owner, editor or viewer")
+		return
+	}
+
+	err = s.workspaceRepo.UpdateMemberRole(member.WorkspaceId, userId, request.Role)
+	if err != nil {
+		s.respondMemberError(ctx, err, "Failed to update member")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Member updated successfully")
+}
+
+// RemoveMember lets owners remove anyone and every member remove
+// themselves, which is how a member leaves a workspace.
+func (s *WorkspaceService) RemoveMember(ctx *gin.Context) {
+	member, ok := s.loadMembership(ctx, false)
+	if !ok {
+		return
+	}
+	userId, err := strconv.ParseInt(ctx.Param("user_id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid user ID")
+		return
+	}
+	if userId != member.UserId && member.Role != models.WorkspaceRoleOwner {
+		utilities.Response(ctx, 403, false, nil, "Only workspace owners can do this")
+		return
+	}
+
+	err = s.workspaceRepo.RemoveMember(member.WorkspaceId, userId)
+	if err != nil {
+		s.respondMemberError(ctx, err, "Failed to remove member")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Member removed successfully")
+}
+
+func (s *WorkspaceService) respondMemberError(ctx *gin.Context, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utilities.Response(ctx, 404, false, nil, "Member not found")
+		return
+	}
+	if errors.Is(err, repo.ErrLastOwner) {
+		utilities.Response(ctx, 409, false, nil, "A workspace needs at least one owner")
+		return
+	}
+	fmt.Println("Error updating workspace member: ", err)
+	utilities.Response(ctx, 500, false, nil, message)
+}
+
+// CreateInvite returns the invite token once. Only its hash is kept, so it
+// is up to the owner to pass the token on to the invitee.
+func (s *WorkspaceService) CreateInvite(ctx *gin.Context) {
+	member, ok := s.loadMembership(ctx, true)
+	if !ok {
+		return
+	}
+	var request dto.CreateWorkspaceInviteRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	request.Email = strings.TrimSpace(request.Email)
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		fmt.Println("Error generating invite token: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create invite")
+		return
+	}
+	invite := models.WorkspaceInvite{
+		WorkspaceId: member.WorkspaceId,
+		Email:       request.Email,
+		Role:        request.Role,
+		TokenHash:   auth.HashToken(token),
+		InvitedBy:   member.UserId,
+		ExpiresAt:   time.Now().Add(inviteTTL),
+	}
+	err = s.workspaceRepo.CreateInvite(&invite)
+	if err != nil {
+		fmt.Println("Error creating invite: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to create invite")
+		return
+	}
+	utilities.Response(ctx, 201, true, gin.H{
+		"invite": invite,
+		"token":  token,
+	}, "Invite created successfully")
+}
+
+func (s *WorkspaceService) GetInvites(ctx *gin.Context) {
+	member, ok := s.loadMembership(ctx, true)
+	if !ok {
+		return
+	}
+	invites, err := s.workspaceRepo.GetPendingInvites(member.WorkspaceId)
+	if err != nil {
+		fmt.Println("Error getting invites: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to get invites")
+		return
+	}
+	utilities.Response(ctx, 200, true, invites, "Invites fetched successfully")
+}
+
+func (s *WorkspaceService) RevokeInvite(ctx *gin.Context) {
+	member, ok := s.loadMembership(ctx, true)
+	if !ok {
+		return
+	}
+	inviteId, err := strconv.ParseInt(ctx.Param("invite_id"), 10, 64)
+	if err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid invite ID")
+		return
+	}
+	revoked, err := s.workspaceRepo.RevokeInvite(member.WorkspaceId, inviteId)
+	if err != nil {
+		fmt.Println("Error revoking invite: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to revoke invite")
+		return
+	}
+	if !revoked {
+		utilities.Response(ctx, 404, false, nil, "Invite not found")
+		return
+	}
+	utilities.Response(ctx, 200, true, nil, "Invite revoked successfully")
+}
+
+func (s *WorkspaceService) AcceptInvite(ctx *gin.Context) {
+	var request dto.AcceptWorkspaceInviteRequest
+	err := ctx.ShouldBindBodyWithJSON(&request)
+	if err != nil {
+		fmt.Println("Error binding request body: ", err)
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+	validator := validator.New()
+	if err := validator.Struct(request); err != nil {
+		utilities.Response(ctx, 400, false, nil, "Invalid request body")
+		return
+	}
+
+	user, err := s.userRepo.GetUserById(ctx.GetInt64("user_id"))
+	if err != nil {
+		fmt.Println("Error getting user by id: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to accept invite")
+		return
+	}
+	member, err := s.workspaceRepo.AcceptInvite(auth.HashToken(strings.TrimSpace(request.Token)), user)
+	if err != nil {
+		if errors.Is(err, repo.ErrInvalidInvite) {
+			utilities.Response(ctx, 400, false, nil, "Invite is invalid or expired")
+			return
+		}
+		if errors.Is(err, repo.ErrInviteEmailMismatch) {
+			utilities.Response(ctx, 403, false, nil, "This invite was sent to a different email address")
+			return
+		}
+		if errors.Is(err, repo.ErrAlreadyMember) {
+			utilities.Response(ctx, 409, false, nil, "You are already a member of this workspace")
+			return
+		}
+		fmt.Println("Error accepting invite: ", err)
+		utilities.Response(ctx, 500, false, nil, "Failed to accept invite")
+		return
+	}
+	utilities.Response(ctx, 200, true, member, "Invite accepted successfully")
+}
@@ -0,0 +1,60 @@
+package utilities
+
+import "strings"
+
+const (
+	CategorySeparator     = " > "
+	UncategorizedCategory = "Uncategorized"
+)
+
+// SplitCategoryPath splits "Technology > AI > LLM Tooling" into its segments,
+// tolerating missing spaces around the separator and stray whitespace.
+func SplitCategoryPath(path string) []string {
+	segments := []string{}
+	for _, segment := range strings.Split(path, ">") {
+		segment = strings.Join(strings.Fields(segment), " ")
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+func JoinCategoryPath(segments []string) string {
+	return strings.Join(segments, CategorySeparator)
+}
+
+func NormalizeCategoryPath(path string) string {
+	return JoinCategoryPath(SplitCategoryPath(path))
+}
+
+// ResolvePinnedCategory maps a suggested category path onto the user's pinned
+// taxonomy. It prefers an exact (case-insensitive) match, then the pinned path
+// whose leaf matches the deepest segment of the suggestion. An empty string is
+// returned when nothing in the taxonomy fits.
+func ResolvePinnedCategory(suggested string, pinned []string) string {
+	suggested = NormalizeCategoryPath(suggested)
+	for _, path := range pinned {
+		if strings.EqualFold(path, suggested) {
+			return path
+		}
+	}
+
+	segments := SplitCategoryPath(suggested)
+	for i := len(segments) - 1; i >= 0; i-- {
+		best := ""
+		for _, path := range pinned {
+			pinnedSegments := SplitCategoryPath(path)
+			if len(pinnedSegments) == 0 || !strings.EqualFold(pinnedSegments[len(pinnedSegments)-1], segments[i]) {
+				continue
+			}
+			if len(path) > len(best) {
+				best = path
+			}
+		}
+		if best != "" {
+			return best
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// CreateHTTPClientWithProxy creates an HTTP client with SOCKS5 or HTTP proxy support
+func CreateHTTPClientWithProxy(proxyURL string) *http.Client {
+	if proxyURL == "" {
+		// No proxy, return default client
+		return &http.Client{
+			Timeout: 30 * time.Second,
+		}
+	}
+
+	parsedURL, err := url.Parse(proxyURL)
+	if err != nil {
+		fmt.Printf("Error parsing proxy URL: %v, using default client\n", err)
+		return &http.Client{Timeout: 30 * time.Second}
+	}
+
+	var transport *http.Transport
+
+	if parsedURL.Scheme == "socks5" {
+		// SOCKS5 proxy
+		dialer, err := proxy.SOCKS5("tcp", parsedURL.Host, nil, proxy.Direct)
+		if err != nil {
+			fmt.Printf("Error creating SOCKS5 proxy: %v, using default client\n", err)
+			return &http.Client{Timeout: 30 * time.Second}
+		}
+
+		transport = &http.Transport{
+			Dial: dialer.Dial,
+		}
+	} else {
+		// HTTP/HTTPS proxy
+		transport = &http.Transport{
+			Proxy: http.ProxyURL(parsedURL),
+		}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}
+}
+
+// ErrPrivateAddress is returned by clients from NewPublicHTTPClient when a
+// request would reach an address that isn't on the public internet.
+var ErrPrivateAddress = errors.New("address is not public")
+
+// nonPublicPrefixes are the special-purpose ranges netip has no predicate
+// for.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// IsPublicAddr reports whether ip is reachable on the public internet, as
+// opposed to loopback, private, link-local, unspecified or otherwise
+// reserved.
+func IsPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewPublicHTTPClient returns a client for fetching URLs that users hand us,
+// which only connects to public addresses. The check runs on the address
+// each connection actually dials, after DNS resolution and on redirects, so
+// a hostname can't be used to point it at internal services. Proxies from
+// the environment are ignored since they would dial on the client's behalf.
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !IsPublicAddr(ip) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, ip)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		Timeout: timeout,
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"strings"
 )
 
-func GenerateCategorizationPrompt(content string, tags string, categories string, userTags string) string {
+func GenerateCategorizationPrompt(content string, tags string, categories string, userTags string, pinnedCategories string) string {
 	existingTagsSection := ""
 	if tags != "" {
 		existingTagsSection = fmt.Sprintf(`
@@ -29,6 +29,9 @@ Existing Categories (for reference only):
 
 You may use one of these if it fits, but feel free to suggest a new category if it better describes the post.`, categories)
 	}
+	if pinnedCategories != "" {
+		existingCategoriesSection = pinnedCategoriesSection(pinnedCategories)
+	}
 
 	userTagsSection := ""
 	tagsInstruction := "Exactly 3 SPECIFIC tags that capture the key topics/themes in this post"
@@ -52,7 +55,7 @@ IMPORTANT: You MUST include ALL user-provided tags in your final response. Gener
 %s
 
 Analyze the following post content and provide:
-1. **Category**: A single broad category (e.g., Technology, Business, Career, Health, Education, Entertainment, Personal Development, etc.)
+1. **Category**: A category path from broad to specific with 1-3 levels separated by " > " (e.g., "Technology > AI > LLM Tooling", "Career > Job Hunting", "Health")
 
 2. **Topic**: The first few words of the post (approximately 5-10 words) that serve as a natural headline or opening statement
 
@@ -86,14 +89,14 @@ IMPORTANT:
 
 JSON format:
 {
-  "category": "category_name",
+  "category": "Parent > Child",
   "topic": "first few words of the post",
   "tags": ["specific_tag1", "specific_tag2", "specific_tag3"],
   "description": "brief summary here"
 }`, existingCategoriesSection, existingTagsSection, userTagsSection, tagsInstruction, content)
 }
 
-func GenerateMediaCategorizationPrompt(Media []dto.Media, tags string, categories string, userTags string) string {
+func GenerateMediaCategorizationPrompt(Media []dto.Media, tags string, categories string, userTags string, pinnedCategories string) string {
 	mediaCount := len(Media)
 
 	mediaTypesMap := make(map[string]int)
@@ -159,6 +162,9 @@ Existing Categories (for reference only):
 
 You may use one of these if it fits, but feel free to suggest a new category if it better describes the media.`, categories)
 	}
+	if pinnedCategories != "" {
+		existingCategoriesSection = pinnedCategoriesSection(pinnedCategories)
+	}
 
 	userTagsSection := ""
 	tagsInstruction := "Exactly 3 SPECIFIC tags that capture the key topics/themes in this media"
@@ -195,13 +201,12 @@ IMPORTANT: You MUST include ALL user-provided tags in your final response. Gener
 %s
 %s
 %s
-%s
 %s%s
 
 %s
 
 Analyze the media and provide:
-1. **Category**: A single broad category (e.g., Technology, Business, Fitness, Travel, Food, Fashion, Entertainment, Education, Lifestyle, Comedy, etc.)
+1. **Category**: A category path from broad to specific with 1-3 levels separated by " > " (e.g., "Fitness > Home Workouts", "Food > Recipes > Pasta", "Travel")
 
 2. **Topic**: A brief 5-10 word description that captures what the media is about (e.g., "Morning workout routine demonstration", "Travel vlog in Paris", "Cooking pasta recipe tutorial")
 
@@ -235,13 +240,49 @@ IMPORTANT:
 
 JSON format:
 {
-  "category": "category_name",
+  "category": "Parent > Child",
   "topic": "brief description of what media shows",
   "tags": ["specific_tag1", "specific_tag2", "specific_tag3"],
   "description": "detailed summary of visual content"	
 }`, existingCategoriesSection, existingTagsSection, userTagsSection, mediaInfoSection, mediaContextDetails, mediaTypeDescription, tagsInstruction)
 }
 
+// GenerateDigestSummaryPrompt asks for a short plain-text overview of the
+// themes in a digest. posts is one line per post.
+func GenerateDigestSummaryPrompt(posts string) string {
+	return fmt.Sprintf(`You are writing the introduction of a personal digest of saved posts.
+
+Saved posts (category | topic | description):
+%s
+
+Write 2-4 sentences in plain text (no markdown, no lists) describing the main themes across these posts and how they connect. Speak to the reader as "you". Do not list every post and do not invent details that are not in the posts.`, posts)
+}
+
+// GenerateAskPrompt asks for an answer to the user's question grounded in
+// their saved posts. Each source in sources is headed by its [#id] tag.
+func GenerateAskPrompt(question string, sources string) string {
+	return fmt.Sprintf(`You answer questions using only the user's saved posts below.
+
+Saved posts:
+%s
+
+Question: %s
+
+RULES:
+1. Answer only from the saved posts. If they don't answer the question, say so briefly instead of guessing.
+2. Cite every claim with the tag of the post it came from, exactly as written, e.g. [#12]. Use several tags if several posts support it.
+3. Never cite a post that is not listed above.
+4. Be concise: a short paragraph or a few bullet points in plain text.`, sources, question)
+}
+
+func pinnedCategoriesSection(pinnedCategories string) string {
+	return fmt.Sprintf(`
+User's Category Taxonomy (MANDATORY):
+%s
+
+CRITICAL: The category MUST be exactly one of the paths listed above, copied verbatim. Do not invent new categories or reword them. Pick the most specific path that fits.`, pinnedCategories)
+}
+
 func detectMediaTypeFromPath(path string) string {
 	ext := strings.ToLower(filepath.Ext(path))
 
@@ -22,6 +22,7 @@ func Response(ctx *gin.Context, statusCode int, success bool, data interface{},
 }
 
 type CustomClaims struct {
-	UserId int64 `json:"user_id"`
+	UserId    int64  `json:"user_id"`
+	SessionId string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
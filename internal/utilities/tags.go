@@ -0,0 +1,128 @@
+package utilities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words that end in "s" but are already singular (or have no singular form).
+var singularExceptions = map[string]bool{
+	"news":        true,
+	"series":      true,
+	"species":     true,
+	"kubernetes":  true,
+	"devops":      true,
+	"finops":      true,
+	"mlops":       true,
+	"macos":       true,
+	"chaos":       true,
+	"canvas":      true,
+	"status":      true,
+	"bonus":       true,
+	"campus":      true,
+	"focus":       true,
+	"thesis":      true,
+	"analysis":    true,
+	"basis":       true,
+	"crisis":      true,
+	"analytics":   true,
+	"economics":   true,
+	"electronics": true,
+	"ethics":      true,
+	"logistics":   true,
+	"mathematics": true,
+	"physics":     true,
+	"politics":    true,
+	"robotics":    true,
+}
+
+// NormalizeTag cleans up a free-text tag: punctuation separators become
+// spaces, whitespace is collapsed and lowercase words are capitalized.
+// Words that already carry their own casing (e.g. "AI", "iOS") are kept.
+func NormalizeTag(tag string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#' || r == '&' {
+			return r
+		}
+		return ' '
+	}, tag)
+
+	words := strings.Fields(cleaned)
+	for i, word := range words {
+		if word == strings.ToLower(word) {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			words[i] = string(runes)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// TagKey returns the comparison key for a tag. Two tags with the same key
+// are treated as the same tag regardless of case, punctuation or plurality.
+func TagKey(tag string) string {
+	words := strings.Fields(strings.ToLower(NormalizeTag(tag)))
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] = singularize(words[len(words)-1])
+	return strings.Join(words, " ")
+}
+
+func singularize(word string) string {
+	if len(word) <= 3 || singularExceptions[word] {
+		return word
+	}
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "sses"),
+		strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "xes"),
+		strings.HasSuffix(word, "zzes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"),
+		strings.HasSuffix(word, "us"),
+		strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// CanonicalizeTags maps each tag onto the user's vocabulary. Synonyms
+// (keyed by TagKey) win first, then any existing tag with the same key,
+// otherwise the normalized tag itself is used. Duplicates are dropped.
+func CanonicalizeTags(tags []string, existing []string, synonyms map[string]string) []string {
+	existingByKey := make(map[string]string, len(existing))
+	for _, tag := range existing {
+		key := TagKey(tag)
+		if _, ok := existingByKey[key]; !ok {
+			existingByKey[key] = tag
+		}
+	}
+
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		normalized := NormalizeTag(tag)
+		if normalized == "" {
+			continue
+		}
+		key := TagKey(normalized)
+		if synonym, ok := synonyms[key]; ok {
+			normalized = synonym
+			key = TagKey(synonym)
+		} else if existingTag, ok := existingByKey[key]; ok {
+			normalized = existingTag
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, normalized)
+	}
+	return result
+}
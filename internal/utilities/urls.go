@@ -0,0 +1,66 @@
+package utilities
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// trackingParams are query parameters that only say where a link was
+// shared from, so links differing in them point at the same page.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"ref_src": true,
+	"ref":     true,
+	"source":  true,
+	"si":      true,
+}
+
+// CanonicalURL normalizes a link so the same page shared in different ways
+// compares equal: the scheme and host are lowercased, default ports,
+// fragments and tracking parameters are dropped and the remaining
+// parameters are sorted. Links that don't parse are returned trimmed.
+func CanonicalURL(link string) string {
+	link = strings.TrimSpace(link)
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host == "" {
+		return link
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if port != "" && !(parsed.Scheme == "http" && port == "80") && !(parsed.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	parsed.Host = host
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+
+	query := parsed.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") || trackingParams[strings.ToLower(key)] {
+			delete(query, key)
+		}
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			values = append(values, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	parsed.RawQuery = strings.Join(values, "&")
+	parsed.ForceQuery = false
+	return parsed.String()
+}